@@ -0,0 +1,584 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Config file ---
+
+// netConfig is the desired state read by `apply` and `plan`.
+type netConfig struct {
+	Profiles []netProfileConfig `yaml:"profiles" toml:"profiles"`
+}
+
+// netProfileConfig describes one desired NetworkManager profile. Profiles are
+// matched to existing ones by name.
+type netProfileConfig struct {
+	Name        string            `yaml:"name" toml:"name"`
+	Type        string            `yaml:"type" toml:"type"` // wifi | ethernet | vpn
+	Interface   string            `yaml:"interface,omitempty" toml:"interface"`
+	Autoconnect *bool             `yaml:"autoconnect,omitempty" toml:"autoconnect"`
+	Priority    *int              `yaml:"priority,omitempty" toml:"priority"`
+	SSID        string            `yaml:"ssid,omitempty" toml:"ssid"`
+	Security    string            `yaml:"security,omitempty" toml:"security"` // open | wpa-psk
+	Password    string            `yaml:"password,omitempty" toml:"password"`
+	PasswordEnv string            `yaml:"password_env,omitempty" toml:"password_env"`
+	Hidden      *bool             `yaml:"hidden,omitempty" toml:"hidden"`
+	IPv4        string            `yaml:"ipv4,omitempty" toml:"ipv4"` // "auto" or an address in CIDR form
+	Gateway     string            `yaml:"gateway,omitempty" toml:"gateway"`
+	DNS         []string          `yaml:"dns,omitempty" toml:"dns"`
+	VPNType     string            `yaml:"vpn_type,omitempty" toml:"vpn_type"` // e.g. openvpn
+	Settings    map[string]string `yaml:"settings,omitempty" toml:"settings"` // raw nmcli properties
+}
+
+const (
+	netTypeWifi     = "wifi"
+	netTypeEthernet = "ethernet"
+	netTypeVPN      = "vpn"
+
+	nmPropInterface    = "connection.interface-name"
+	nmPropAutoconnect  = "connection.autoconnect"
+	nmPropPriority     = "connection.autoconnect-priority"
+	nmPropSSID         = "802-11-wireless.ssid"
+	nmPropHidden       = "802-11-wireless.hidden"
+	nmPropKeyMgmt      = "802-11-wireless-security.key-mgmt"
+	nmPropPSK          = "802-11-wireless-security.psk"
+	nmPropVPNService   = "vpn.service-type"
+	nmVPNServicePrefix = "org.freedesktop.NetworkManager."
+)
+
+// wifiCoreProps are handled by CreateWifiProfile/UpdateWifiProfile; anything
+// else on a Wi-Fi profile is applied with a plain modify.
+var wifiCoreProps = map[string]bool{nmPropAutoconnect: true, nmPropPriority: true, nmPropSSID: true, nmPropHidden: true, nmPropKeyMgmt: true}
+
+// loadNetConfig reads a YAML (.yaml/.yml) or TOML (.toml) desired-state file.
+func loadNetConfig(path string) (netConfig, error) {
+	var cfg netConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+			return cfg, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return cfg, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return cfg, fmt.Errorf("parsing %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported config format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	return cfg, cfg.validate()
+}
+
+func (c *netConfig) validate() error {
+	seen := make(map[string]bool)
+	for i := range c.Profiles {
+		p := &c.Profiles[i]
+		p.Name = strings.TrimSpace(p.Name)
+		p.Type = strings.ToLower(strings.TrimSpace(p.Type))
+		if p.Name == "" {
+			return fmt.Errorf("profile #%d: name is required", i+1)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("profile %q is defined more than once", p.Name)
+		}
+		seen[p.Name] = true
+		switch p.Type {
+		case netTypeWifi:
+			if p.SSID == "" {
+				p.SSID = p.Name
+			}
+			security, err := normalizeConfigSecurity(p.Security)
+			if err != nil {
+				return fmt.Errorf("profile %q: %w", p.Name, err)
+			}
+			p.Security = security
+			if p.PasswordEnv != "" && p.Password == "" {
+				p.Password = os.Getenv(p.PasswordEnv)
+				if p.Password == "" {
+					return fmt.Errorf("profile %q: environment variable %s is empty", p.Name, p.PasswordEnv)
+				}
+			}
+			if p.Security == "wpa-psk" && p.Password == "" {
+				return fmt.Errorf("profile %q: wpa-psk needs password or password_env", p.Name)
+			}
+			if p.Security == "wpa-psk" {
+				if err := gonetworkmanager.ValidateWifiPSK(p.Password); err != nil {
+					return fmt.Errorf("profile %q: %w", p.Name, err)
+				}
+			}
+		case netTypeEthernet:
+		case netTypeVPN:
+			if p.VPNType == "" {
+				return fmt.Errorf("profile %q: vpn_type is required for VPN profiles", p.Name)
+			}
+		default:
+			return fmt.Errorf("profile %q: unsupported type %q (wifi, ethernet or vpn)", p.Name, p.Type)
+		}
+		if p.IPv4 != "" && p.IPv4 != "auto" && !strings.Contains(p.IPv4, "/") {
+			return fmt.Errorf("profile %q: ipv4 must be \"auto\" or an address with prefix, e.g. 10.0.0.5/24", p.Name)
+		}
+	}
+	return nil
+}
+
+// normalizeConfigSecurity maps the security values a config file may use to
+// "open" or "wpa-psk". Anything else, including WPA-Enterprise, SAE and WEP,
+// is refused rather than applied as a network it is not.
+func normalizeConfigSecurity(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "open", "none":
+		return "open", nil
+	case "wpa-psk", "wpa2-psk", "wpa", "wpa2", "psk":
+		return "wpa-psk", nil
+	default:
+		return "", fmt.Errorf("unsupported security %q (open or wpa-psk)", raw)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// properties returns the comparable nmcli properties this profile pins down.
+// Properties the file leaves unset are not managed.
+func (p netProfileConfig) properties() []gonetworkmanager.ConnectionSetting {
+	var props []gonetworkmanager.ConnectionSetting
+	add := func(k, v string) { props = append(props, gonetworkmanager.ConnectionSetting{Key: k, Value: v}) }
+	if p.Interface != "" {
+		add(nmPropInterface, p.Interface)
+	}
+	if p.Autoconnect != nil {
+		add(nmPropAutoconnect, yesNo(*p.Autoconnect))
+	}
+	if p.Priority != nil {
+		add(nmPropPriority, strconv.Itoa(*p.Priority))
+	}
+	switch p.Type {
+	case netTypeWifi:
+		add(nmPropSSID, p.SSID)
+		if p.Hidden != nil {
+			add(nmPropHidden, yesNo(*p.Hidden))
+		}
+		if p.Security == "wpa-psk" {
+			add(nmPropKeyMgmt, "wpa-psk")
+		} else {
+			add(nmPropKeyMgmt, "")
+		}
+	case netTypeVPN:
+		service := p.VPNType
+		if !strings.Contains(service, ".") {
+			service = nmVPNServicePrefix + service
+		}
+		add(nmPropVPNService, service)
+	}
+	switch {
+	case p.IPv4 == "auto":
+		add("ipv4.method", "auto")
+	case p.IPv4 != "":
+		add("ipv4.method", "manual")
+		add("ipv4.addresses", p.IPv4)
+	}
+	if p.Gateway != "" {
+		add("ipv4.gateway", p.Gateway)
+	}
+	if len(p.DNS) > 0 {
+		add("ipv4.dns", strings.Join(p.DNS, ","))
+	}
+	keys := make([]string, 0, len(p.Settings))
+	for k := range p.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, p.Settings[k])
+	}
+	return props
+}
+
+func (p netProfileConfig) secrets() []gonetworkmanager.ConnectionSetting {
+	if p.Type == netTypeWifi && p.Security == "wpa-psk" {
+		return []gonetworkmanager.ConnectionSetting{{Key: nmPropPSK, Value: p.Password}}
+	}
+	return nil
+}
+
+// --- Plan ---
+
+type planAction int
+
+const (
+	planCreate planAction = iota
+	planModify
+	planDelete
+	planUnchanged
+)
+
+func (a planAction) String() string {
+	switch a {
+	case planCreate:
+		return "create"
+	case planModify:
+		return "modify"
+	case planDelete:
+		return "delete"
+	default:
+		return "unchanged"
+	}
+}
+
+type planChange struct {
+	Key  string
+	From string
+	To   string
+}
+
+type planItem struct {
+	Action  planAction
+	Name    string
+	Type    string
+	UUID    string
+	Changes []planChange
+	Desired *netProfileConfig
+	Current gonetworkmanager.ConnectionProfile
+}
+
+type applyPlan struct {
+	Items     []planItem
+	Unmanaged int // existing profiles left alone because --prune was not given
+}
+
+func (p applyPlan) count(a planAction) int {
+	n := 0
+	for _, it := range p.Items {
+		if it.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+func (p applyPlan) hasChanges() bool {
+	return p.count(planCreate)+p.count(planModify)+p.count(planDelete) > 0
+}
+
+// canonicalConnType maps nmcli's setting names onto the config file types.
+func canonicalConnType(t string) string {
+	switch strings.TrimSpace(t) {
+	case "802-11-wireless":
+		return netTypeWifi
+	case "802-3-ethernet":
+		return netTypeEthernet
+	default:
+		return strings.TrimSpace(t)
+	}
+}
+
+// normalizeNMValue makes nmcli output comparable with config values.
+func normalizeNMValue(key, v string) string {
+	v = strings.TrimSpace(v)
+	if v == "--" {
+		return ""
+	}
+	if strings.HasSuffix(key, ".dns") || strings.HasSuffix(key, ".addresses") {
+		return strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }), ",")
+	}
+	return v
+}
+
+// buildPlan diffs cfg against the existing profiles. lookup loads the full
+// property set of an existing profile by UUID.
+func buildPlan(cfg netConfig, existing []gonetworkmanager.ConnectionProfile, lookup func(string) (gonetworkmanager.ConnectionProfile, error), prune, updateSecrets bool) (applyPlan, error) {
+	var plan applyPlan
+	byName := make(map[string][]gonetworkmanager.ConnectionProfile)
+	for _, p := range existing {
+		name := p[gonetworkmanager.NmcliFieldConnectionName]
+		byName[name] = append(byName[name], p)
+	}
+
+	for i := range cfg.Profiles {
+		desired := &cfg.Profiles[i]
+		matches := byName[desired.Name]
+		item := planItem{Name: desired.Name, Type: desired.Type, Desired: desired}
+		switch len(matches) {
+		case 0:
+			item.Action = planCreate
+			plan.Items = append(plan.Items, item)
+			continue
+		case 1:
+		default:
+			return plan, fmt.Errorf("profile name %q matches %d existing profiles; remove the duplicates first", desired.Name, len(matches))
+		}
+		match := matches[0]
+		item.UUID = match[gonetworkmanager.NmcliFieldConnectionUUID]
+		if t := canonicalConnType(match[gonetworkmanager.NmcliFieldConnectionType]); t != desired.Type {
+			return plan, fmt.Errorf("profile %q exists with type %s, config wants %s; delete it first", desired.Name, t, desired.Type)
+		}
+		current, err := lookup(item.UUID)
+		if err != nil {
+			return plan, fmt.Errorf("loading profile %q: %w", desired.Name, err)
+		}
+		item.Current = current
+		for _, prop := range desired.properties() {
+			have := normalizeNMValue(prop.Key, current[prop.Key])
+			want := normalizeNMValue(prop.Key, prop.Value)
+			if have != want {
+				item.Changes = append(item.Changes, planChange{Key: prop.Key, From: have, To: want})
+			}
+		}
+		if updateSecrets {
+			for _, sec := range desired.secrets() {
+				item.Changes = append(item.Changes, planChange{Key: sec.Key, From: "(secret)", To: "(secret)"})
+			}
+		}
+		item.Action = planUnchanged
+		if len(item.Changes) > 0 {
+			item.Action = planModify
+		}
+		plan.Items = append(plan.Items, item)
+		delete(byName, desired.Name)
+	}
+
+	var leftovers []planItem
+	for name, profiles := range byName {
+		for _, p := range profiles {
+			t := canonicalConnType(p[gonetworkmanager.NmcliFieldConnectionType])
+			if t != netTypeWifi && t != netTypeEthernet && t != netTypeVPN {
+				continue // loopback, bridges, etc. are never ours to manage
+			}
+			if !prune {
+				plan.Unmanaged++
+				continue
+			}
+			leftovers = append(leftovers, planItem{Action: planDelete, Name: name, Type: t, UUID: p[gonetworkmanager.NmcliFieldConnectionUUID]})
+		}
+	}
+	sort.Slice(leftovers, func(i, j int) bool { return leftovers[i].Name < leftovers[j].Name })
+	plan.Items = append(plan.Items, leftovers...)
+	return plan, nil
+}
+
+func displayValue(key, v string) string {
+	if v == "" {
+		return "(empty)"
+	}
+	if key == nmPropPSK {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+func writePlan(w io.Writer, plan applyPlan) {
+	symbols := map[planAction]string{planCreate: "+", planModify: "~", planDelete: "-", planUnchanged: "="}
+	for _, it := range plan.Items {
+		fmt.Fprintf(w, "  %s %-9s %s (%s)\n", symbols[it.Action], it.Action, it.Name, it.Type)
+		for _, c := range it.Changes {
+			fmt.Fprintf(w, "        %s: %s -> %s\n", c.Key, displayValue(c.Key, c.From), displayValue(c.Key, c.To))
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to modify, %d to delete, %d unchanged.\n",
+		plan.count(planCreate), plan.count(planModify), plan.count(planDelete), plan.count(planUnchanged))
+	if plan.Unmanaged > 0 {
+		fmt.Fprintf(w, "%d unmanaged profile(s) left alone (use --prune to delete them).\n", plan.Unmanaged)
+	}
+}
+
+// --- Apply ---
+
+func currentBool(current gonetworkmanager.ConnectionProfile, key string, fallback bool) bool {
+	switch normalizeNMValue(key, current[key]) {
+	case "yes":
+		return true
+	case "no":
+		return false
+	}
+	return fallback
+}
+
+// wifiSpec merges the desired Wi-Fi fields with the current profile so that
+// settings the file leaves unset are preserved by UpdateWifiProfile.
+func wifiSpec(p *netProfileConfig, current gonetworkmanager.ConnectionProfile) gonetworkmanager.WifiProfileSpec {
+	spec := gonetworkmanager.WifiProfileSpec{Name: p.Name, SSID: p.SSID, Security: p.Security, Password: p.Password}
+	spec.Autoconnect = currentBool(current, nmPropAutoconnect, true)
+	if p.Autoconnect != nil {
+		spec.Autoconnect = *p.Autoconnect
+	}
+	spec.Hidden = currentBool(current, nmPropHidden, false)
+	if p.Hidden != nil {
+		spec.Hidden = *p.Hidden
+	}
+	spec.Priority = p.Priority
+	if spec.Priority == nil {
+		if v, err := strconv.Atoi(normalizeNMValue(nmPropPriority, current[nmPropPriority])); err == nil {
+			spec.Priority = &v
+		}
+	}
+	return spec
+}
+
+func applyItem(it planItem, updateSecrets bool) error {
+	switch it.Action {
+	case planCreate:
+		p := it.Desired
+		var extras []gonetworkmanager.ConnectionSetting
+		if p.Type == netTypeWifi {
+			if _, err := gonetworkmanager.CreateWifiProfile(wifiSpec(p, nil)); err != nil {
+				return err
+			}
+			for _, prop := range p.properties() {
+				if !wifiCoreProps[prop.Key] {
+					extras = append(extras, prop)
+				}
+			}
+			// Part of creating the profile, so there is nothing to snapshot.
+			_, err := gonetworkmanager.ModifyConnectionUnrecorded(p.Name, extras)
+			return err
+		}
+		for _, prop := range p.properties() {
+			if prop.Key != nmPropInterface {
+				extras = append(extras, prop)
+			}
+		}
+		_, err := gonetworkmanager.AddConnection(p.Type, p.Name, p.Interface, append(extras, p.secrets()...))
+		return err
+
+	case planModify:
+		p := it.Desired
+		var changed []gonetworkmanager.ConnectionSetting
+		coreChanged, keyMgmtChanged := false, false
+		for _, c := range it.Changes {
+			if c.Key == nmPropPSK {
+				continue
+			}
+			if p.Type == netTypeWifi && wifiCoreProps[c.Key] {
+				coreChanged = true
+				keyMgmtChanged = keyMgmtChanged || c.Key == nmPropKeyMgmt
+				continue
+			}
+			changed = append(changed, gonetworkmanager.ConnectionSetting{Key: c.Key, Value: c.To})
+		}
+		if coreChanged {
+			withPassword := p.Security == "wpa-psk" && (keyMgmtChanged || updateSecrets)
+			if _, err := gonetworkmanager.UpdateWifiProfile(it.UUID, wifiSpec(p, it.Current), withPassword, false); err != nil {
+				return err
+			}
+		} else if updateSecrets {
+			changed = append(changed, p.secrets()...)
+		}
+		_, err := gonetworkmanager.ModifyConnection(it.UUID, changed)
+		return err
+
+	case planDelete:
+		_, err := gonetworkmanager.ConnectionDelete(it.UUID)
+		return err
+	}
+	return nil
+}
+
+// --- CLI ---
+
+// runApplyCLI implements both `apply` and `plan` (planOnly).
+func runApplyCLI(args []string, planOnly bool, stdout, stderr io.Writer) int {
+	name := "apply"
+	if planOnly {
+		name = "plan"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	prune := fs.Bool("prune", false, "delete Wi-Fi/ethernet/VPN profiles that are not in the file")
+	updateSecrets := fs.Bool("update-secrets", false, "rewrite passwords of existing profiles (secrets are not compared)")
+	exitCode := fs.Bool("exit-code", false, "with plan: exit 3 when changes are pending")
+	if !planOnly {
+		fs.BoolVar(&planOnly, "plan", false, "only show the plan, change nothing")
+	}
+	// Allow flags both before and after the file argument.
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fmt.Fprintf(stderr, "Usage: %s %s [--prune] [--update-secrets] FILE\n", effectiveAppName(), name)
+		return 2
+	}
+	path := rest[0]
+	if err := fs.Parse(rest[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Unexpected argument: %s\n", fs.Arg(0))
+		return 2
+	}
+
+	cfg, err := loadNetConfig(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	existing, err := gonetworkmanager.GetConnectionProfilesList(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error listing profiles: %v\n", err)
+		return 1
+	}
+	plan, err := buildPlan(cfg, existing, gonetworkmanager.GetConnectionProfileByID, *prune, *updateSecrets)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Plan for %s:\n", path)
+	writePlan(stdout, plan)
+	if planOnly {
+		if *exitCode && plan.hasChanges() {
+			return 3
+		}
+		return 0
+	}
+	if !plan.hasChanges() {
+		fmt.Fprintln(stdout, "Nothing to do.")
+		return 0
+	}
+
+	failed := 0
+	for _, it := range plan.Items {
+		if it.Action == planUnchanged {
+			continue
+		}
+		if err := applyItem(it, *updateSecrets); err != nil {
+			failed++
+			fmt.Fprintf(stdout, "  ✗ %s %s: %v\n", it.Action, it.Name, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "  ✓ %s %s\n", it.Action, it.Name)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "Apply finished with %d error(s).\n", failed)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Apply complete.")
+	return 0
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nmtui/gonetworkmanager"
+)
+
+const applyYAML = `profiles:
+  - name: Office
+    type: wifi
+    security: wpa-psk
+    password_env: OFFICE_PSK
+    priority: 10
+  - name: Guest
+    type: wifi
+    ssid: Guest WiFi
+    autoconnect: false
+  - name: Wired
+    type: ethernet
+    interface: eth0
+    ipv4: 10.0.0.5/24
+    gateway: 10.0.0.1
+    dns: [1.1.1.1, 9.9.9.9]
+  - name: CorpVPN
+    type: vpn
+    vpn_type: openvpn
+    settings:
+      vpn.data: "remote = vpn.example.com"
+`
+
+const applyTOML = `[[profiles]]
+name = "Office"
+type = "wifi"
+security = "wpa-psk"
+password_env = "OFFICE_PSK"
+priority = 10
+
+[[profiles]]
+name = "Guest"
+type = "wifi"
+ssid = "Guest WiFi"
+autoconnect = false
+
+[[profiles]]
+name = "Wired"
+type = "ethernet"
+interface = "eth0"
+ipv4 = "10.0.0.5/24"
+gateway = "10.0.0.1"
+dns = ["1.1.1.1", "9.9.9.9"]
+
+[[profiles]]
+name = "CorpVPN"
+type = "vpn"
+vpn_type = "openvpn"
+[profiles.settings]
+"vpn.data" = "remote = vpn.example.com"
+`
+
+func writeConfigFile(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadNetConfigYAMLAndTOMLAgree(t *testing.T) {
+	t.Setenv("OFFICE_PSK", "correct horse")
+	fromYAML, err := loadNetConfig(writeConfigFile(t, "net.yaml", applyYAML))
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	fromTOML, err := loadNetConfig(writeConfigFile(t, "net.toml", applyTOML))
+	if err != nil {
+		t.Fatalf("toml: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromTOML) {
+		t.Fatalf("YAML and TOML decoded differently:\n%+v\n%+v", fromYAML, fromTOML)
+	}
+	office := fromYAML.Profiles[0]
+	if office.SSID != "Office" || office.Password != "correct horse" || office.Security != "wpa-psk" {
+		t.Fatalf("unexpected defaults for Office: %+v", office)
+	}
+}
+
+func TestLoadNetConfigRejectsBadInput(t *testing.T) {
+	cases := map[string]string{
+		"typo.yaml":    "profiles:\n  - name: A\n    type: wifi\n    ssdi: x\n",
+		"dup.yaml":     "profiles:\n  - {name: A, type: ethernet}\n  - {name: A, type: ethernet}\n",
+		"psk.yaml":     "profiles:\n  - {name: A, type: wifi, security: wpa-psk}\n",
+		"short.yaml":   "profiles:\n  - {name: A, type: wifi, security: wpa-psk, password: short}\n",
+		"eap.yaml":     "profiles:\n  - {name: A, type: wifi, security: wpa-eap, password: longenough}\n",
+		"sec.yaml":     "profiles:\n  - {name: A, type: wifi, security: wpa-pks, password: longenough}\n",
+		"type.yaml":    "profiles:\n  - {name: A, type: bond}\n",
+		"vpn.yaml":     "profiles:\n  - {name: A, type: vpn}\n",
+		"ip.yaml":      "profiles:\n  - {name: A, type: ethernet, ipv4: 10.0.0.5}\n",
+		"unknown.toml": "[[profiles]]\nname = \"A\"\ntype = \"ethernet\"\nbogus = 1\n",
+		"net.json":     "{}",
+	}
+	for name, body := range cases {
+		if _, err := loadNetConfig(writeConfigFile(t, name, body)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := loadNetConfig(writeConfigFile(t, "eap.yaml", cases["eap.yaml"])); err == nil || err.Error() != `profile "A": unsupported security "wpa-eap" (open or wpa-psk)` {
+		t.Errorf("unexpected error for an unknown security: %v", err)
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	t.Setenv("OFFICE_PSK", "correct horse")
+	cfg, err := loadNetConfig(writeConfigFile(t, "net.yaml", applyYAML))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	existing := []gonetworkmanager.ConnectionProfile{
+		{"NAME": "Office", "UUID": "u-office", "TYPE": "802-11-wireless"},
+		{"NAME": "Wired", "UUID": "u-wired", "TYPE": "802-3-ethernet"},
+		{"NAME": "OldCafe", "UUID": "u-cafe", "TYPE": "wifi"},
+		{"NAME": "lo", "UUID": "u-lo", "TYPE": "loopback"},
+	}
+	details := map[string]gonetworkmanager.ConnectionProfile{
+		"u-office": {nmPropSSID: "Office", nmPropKeyMgmt: "wpa-psk", nmPropPriority: "10", nmPropAutoconnect: "yes"},
+		"u-wired": {nmPropInterface: "eth0", "ipv4.method": "manual", "ipv4.addresses": "10.0.0.4/24",
+			"ipv4.gateway": "10.0.0.1", "ipv4.dns": "1.1.1.1 9.9.9.9"},
+	}
+	lookup := func(uuid string) (gonetworkmanager.ConnectionProfile, error) { return details[uuid], nil }
+
+	plan, err := buildPlan(cfg, existing, lookup, false, false)
+	if err != nil {
+		t.Fatalf("buildPlan: %v", err)
+	}
+	got := map[string]planAction{}
+	for _, it := range plan.Items {
+		got[it.Name] = it.Action
+	}
+	want := map[string]planAction{"Office": planUnchanged, "Guest": planCreate, "Wired": planModify, "CorpVPN": planCreate}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("plan actions = %v, want %v", got, want)
+	}
+	if plan.Unmanaged != 1 {
+		t.Fatalf("expected OldCafe to be reported as unmanaged (loopback ignored), got %d", plan.Unmanaged)
+	}
+	wired := plan.Items[2]
+	if len(wired.Changes) != 1 || wired.Changes[0] != (planChange{Key: "ipv4.addresses", From: "10.0.0.4/24", To: "10.0.0.5/24"}) {
+		t.Fatalf("unexpected Wired changes: %+v", wired.Changes)
+	}
+
+	pruned, err := buildPlan(cfg, existing, lookup, true, true)
+	if err != nil {
+		t.Fatalf("buildPlan prune: %v", err)
+	}
+	last := pruned.Items[len(pruned.Items)-1]
+	if last.Action != planDelete || last.Name != "OldCafe" || pruned.Unmanaged != 0 {
+		t.Fatalf("expected OldCafe deletion with --prune, got %+v", last)
+	}
+	if pruned.Items[0].Action != planModify {
+		t.Fatalf("--update-secrets should turn Office into a modify")
+	}
+
+	var out bytes.Buffer
+	writePlan(&out, plan)
+	for _, line := range []string{"+ create    Guest (wifi)", "~ modify    Wired (ethernet)", `ipv4.addresses: "10.0.0.4/24" -> "10.0.0.5/24"`, "= unchanged Office (wifi)",
+		"Plan: 2 to create, 1 to modify, 0 to delete, 1 unchanged.", "1 unmanaged profile(s)"} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("plan output missing %q:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "correct horse") {
+		t.Fatalf("plan output leaked a password")
+	}
+}
+
+func TestBuildPlanRejectsAmbiguousAndTypeMismatch(t *testing.T) {
+	cfg := netConfig{Profiles: []netProfileConfig{{Name: "A", Type: netTypeEthernet}}}
+	lookup := func(string) (gonetworkmanager.ConnectionProfile, error) { return nil, nil }
+	dup := []gonetworkmanager.ConnectionProfile{{"NAME": "A", "UUID": "1", "TYPE": "ethernet"}, {"NAME": "A", "UUID": "2", "TYPE": "ethernet"}}
+	if _, err := buildPlan(cfg, dup, lookup, false, false); err == nil {
+		t.Fatalf("expected duplicate-name error")
+	}
+	wrong := []gonetworkmanager.ConnectionProfile{{"NAME": "A", "UUID": "1", "TYPE": "wifi"}}
+	if _, err := buildPlan(cfg, wrong, lookup, false, false); err == nil {
+		t.Fatalf("expected type mismatch error")
+	}
+}
+
+func TestRunApplyCLIReconciles(t *testing.T) {
+	t.Setenv("OFFICE_PSK", "correct horse")
+	calls := filepath.Join(t.TempDir(), "calls")
+	installFakeCommand(t, "nmcli", `
+echo "$*" >> "`+calls+`"
+case "$*" in
+  "-m multiline connection show --order name") printf 'NAME: Wired\nUUID: u-wired\nTYPE: ethernet\nNAME: OldCafe\nUUID: u-cafe\nTYPE: wifi\n' ;;
+  "-m multiline connection show u-wired") printf 'connection.id: Wired\nconnection.interface-name: eth0\nipv4.method: auto\nipv4.addresses: --\nipv4.gateway: --\nipv4.dns: --\n' ;;
+  "connection add type vpn"*) echo "Error: vpn plugin missing" >&2; exit 2 ;;
+  *) echo ok ;;
+esac
+`)
+	path := writeConfigFile(t, "net.yaml", applyYAML)
+
+	var out, errOut bytes.Buffer
+	if code := runApplyCLI([]string{path, "--exit-code"}, true, &out, &errOut); code != 3 {
+		t.Fatalf("plan --exit-code should report pending changes with 3, got %d (%s)", code, errOut.String())
+	}
+	if data, _ := os.ReadFile(calls); strings.Contains(string(data), "connection add") || strings.Contains(string(data), "modify") {
+		t.Fatalf("plan mode must not change anything:\n%s", data)
+	}
+
+	out.Reset()
+	os.Remove(calls)
+	var snapshots []string
+	gonetworkmanager.BeforeProfileChange = func(id, op string) error {
+		snapshots = append(snapshots, op+" "+id)
+		return nil
+	}
+	t.Cleanup(func() { gonetworkmanager.BeforeProfileChange = nil })
+	code := runApplyCLI([]string{"--prune", path}, false, &out, &errOut)
+	if code != 1 {
+		t.Fatalf("expected exit 1 because the VPN create fails, got %d\n%s", code, out.String())
+	}
+	data, _ := os.ReadFile(calls)
+	log := string(data)
+	for _, want := range []string{
+		"connection add type wifi con-name Office ifname * ssid Office wifi-sec.key-mgmt wpa-psk wifi-sec.psk correct horse 802-11-wireless.hidden no connection.autoconnect yes connection.autoconnect-priority 10",
+		"connection add type wifi con-name Guest ifname * ssid Guest WiFi 802-11-wireless.hidden no connection.autoconnect no",
+		"connection modify u-wired ipv4.method manual ipv4.addresses 10.0.0.5/24 ipv4.gateway 10.0.0.1 ipv4.dns 1.1.1.1,9.9.9.9",
+		"connection delete u-cafe",
+	} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("missing nmcli call %q in:\n%s", want, log)
+		}
+	}
+	if !strings.Contains(out.String(), "✗ create CorpVPN") || !strings.Contains(out.String(), "✓ delete OldCafe") {
+		t.Fatalf("expected per-item results, got:\n%s", out.String())
+	}
+	// Newly created profiles have no earlier version to snapshot.
+	if got := strings.Join(snapshots, ", "); got != "modify u-wired, delete u-cafe" {
+		t.Fatalf("unexpected snapshots: %s", got)
+	}
+}
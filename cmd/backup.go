@@ -0,0 +1,738 @@
+// nmtui/cmd/backup.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+
+	"nmtui/gonetworkmanager"
+)
+
+// Archive layout: magic | version (1 byte) | PBKDF2 iterations (uint32 BE) |
+// salt | GCM nonce | AES-256-GCM ciphertext of the JSON archive. The header is
+// authenticated as additional data, so tampering with it fails decryption.
+const (
+	backupMagic         = "NMTUIBAK"
+	backupFormatVersion = 1
+	backupSaltSize      = 16
+	backupFileExt       = ".nmbak"
+)
+
+// Iteration counts accepted from an archive header. The header is only
+// authenticated once the key is derived, so a corrupted or hostile count must
+// not stall the derivation for hours.
+const (
+	backupMinKDFIterations = 10000
+	backupMaxKDFIterations = 10000000
+)
+
+// backupKDFIterations is a variable so tests can keep key derivation cheap;
+// archives record the count they were written with.
+var backupKDFIterations = 600000
+
+var errBadPassphrase = errors.New("wrong passphrase or corrupted backup")
+
+type backupEntry struct {
+	Name    string `json:"name"`
+	UUID    string `json:"uuid"`
+	Type    string `json:"type"`
+	Keyfile string `json:"keyfile"`
+}
+
+type backupArchive struct {
+	Version  int           `json:"version"`
+	Created  time.Time     `json:"created"`
+	Host     string        `json:"host,omitempty"`
+	Profiles []backupEntry `json:"profiles"`
+}
+
+// --- Encryption ---
+
+func backupKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+}
+
+func backupAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealBackup(a backupArchive, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+	plain, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := backupKey(passphrase, salt, backupKDFIterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := backupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var header bytes.Buffer
+	header.WriteString(backupMagic)
+	header.WriteByte(backupFormatVersion)
+	binary.Write(&header, binary.BigEndian, uint32(backupKDFIterations))
+	header.Write(salt)
+	header.Write(nonce)
+	return aead.Seal(header.Bytes(), nonce, plain, header.Bytes()), nil
+}
+
+func openBackup(data []byte, passphrase string) (*backupArchive, error) {
+	const fixed = len(backupMagic) + 1 + 4 + backupSaltSize
+	if len(data) < fixed || string(data[:len(backupMagic)]) != backupMagic {
+		return nil, errors.New("not an nmtui-go backup")
+	}
+	if v := data[len(backupMagic)]; v != backupFormatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", v)
+	}
+	iterations := int(binary.BigEndian.Uint32(data[len(backupMagic)+1:]))
+	if iterations < backupMinKDFIterations || iterations > backupMaxKDFIterations {
+		return nil, fmt.Errorf("backup header has an implausible key derivation count (%d); the file is corrupted", iterations)
+	}
+	salt := data[fixed-backupSaltSize : fixed]
+	key, err := backupKey(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := backupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	headerLen := fixed + aead.NonceSize()
+	if len(data) < headerLen {
+		return nil, errors.New("backup is truncated")
+	}
+	plain, err := aead.Open(nil, data[fixed:headerLen], data[headerLen:], data[:headerLen])
+	if err != nil {
+		return nil, errBadPassphrase
+	}
+	var a backupArchive
+	if err := json.Unmarshal(plain, &a); err != nil {
+		return nil, fmt.Errorf("reading backup contents: %w", err)
+	}
+	return &a, nil
+}
+
+// --- Collect / restore ---
+
+// collectBackup exports every Wi-Fi, ethernet and VPN profile with secrets.
+// missingSecrets counts PSK profiles whose password nmcli did not reveal
+// (usually because the caller may not read system secrets).
+func collectBackup() (archive backupArchive, missingSecrets int, err error) {
+	profiles, err := exportableProfiles()
+	if err != nil {
+		return archive, 0, err
+	}
+	archive = backupArchive{Version: backupFormatVersion, Created: time.Now().UTC()}
+	archive.Host, _ = os.Hostname()
+	for _, p := range profiles {
+		uuid := p[gonetworkmanager.NmcliFieldConnectionUUID]
+		kf, err := gonetworkmanager.ExportKeyfile(uuid, true)
+		if err != nil {
+			return archive, 0, fmt.Errorf("%s: %w", p[gonetworkmanager.NmcliFieldConnectionName], err)
+		}
+		if keyMgmt := kf.Get("wifi-security", "key-mgmt"); (keyMgmt == "wpa-psk" || keyMgmt == "sae") &&
+			kf.Get("wifi-security", "psk") == "" && (kf.Get("wifi-security", "psk-flags") == "" || kf.Get("wifi-security", "psk-flags") == "0") {
+			missingSecrets++
+		}
+		archive.Profiles = append(archive.Profiles, backupEntry{Name: kf.ID(), UUID: kf.UUID(), Type: kf.Type(), Keyfile: string(kf.Marshal())})
+	}
+	return archive, missingSecrets, nil
+}
+
+type restoreItem struct {
+	Entry     backupEntry
+	Overwrite bool
+	Selected  bool
+}
+
+// planRestore marks which backup entries would overwrite an existing profile
+// with the same UUID.
+func planRestore(a *backupArchive, existing []gonetworkmanager.ConnectionProfile) []restoreItem {
+	have := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		have[p[gonetworkmanager.NmcliFieldConnectionUUID]] = true
+	}
+	items := make([]restoreItem, 0, len(a.Profiles))
+	for _, e := range a.Profiles {
+		items = append(items, restoreItem{Entry: e, Overwrite: have[e.UUID], Selected: true})
+	}
+	return items
+}
+
+func (it restoreItem) action() string {
+	if it.Overwrite {
+		return "overwrite"
+	}
+	return "add"
+}
+
+// restoreProfile recreates one profile with its original UUID, replacing a
+// profile that already uses that UUID.
+func restoreProfile(e backupEntry) error {
+	kf, err := gonetworkmanager.ParseKeyfile([]byte(e.Keyfile))
+	if err != nil {
+		return err
+	}
+	_, err = gonetworkmanager.ImportKeyfile(kf, true)
+	return err
+}
+
+type restoreResult struct {
+	Name string
+	Err  error
+}
+
+func runRestore(items []restoreItem) []restoreResult {
+	var results []restoreResult
+	for _, it := range items {
+		if !it.Selected {
+			continue
+		}
+		err := restoreProfile(it.Entry)
+		if err != nil {
+			log.Printf("Restore: %s (%s) failed: %v", it.Entry.Name, it.Entry.UUID, err)
+		} else {
+			log.Printf("Restore: %s %s (%s)", it.action(), it.Entry.Name, it.Entry.UUID)
+		}
+		results = append(results, restoreResult{Name: it.Entry.Name, Err: err})
+	}
+	return results
+}
+
+// --- CLI ---
+
+// readPassphrase takes the passphrase from --passphrase-file, then
+// NMTUI_BACKUP_PASSPHRASE, and finally prompts on the terminal without echo.
+func readPassphrase(file string, confirm bool, stderr io.Writer) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if p := os.Getenv("NMTUI_BACKUP_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", errors.New("no passphrase: use --passphrase-file or NMTUI_BACKUP_PASSPHRASE when not on a terminal")
+	}
+	fmt.Fprint(stderr, "Passphrase: ")
+	p, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(p) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(p), nil
+}
+
+// writeBackupFile writes the archive with owner-only permissions. An
+// existing file is only replaced with force: it may be an older backup.
+func writeBackupFile(path string, data []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runBackupCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "archive path (default nmtui-backup-<timestamp>"+backupFileExt+")")
+	passFile := fs.String("passphrase-file", "", "read the passphrase from this file")
+	force := fs.Bool("force", false, "overwrite an existing archive")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Unexpected argument: %s\n", fs.Arg(0))
+		return 2
+	}
+	path := *out
+	if path == "" {
+		path = "nmtui-backup-" + time.Now().Format("20060102-150405") + backupFileExt
+	}
+	// Checked before asking for the passphrase; the write below checks again.
+	if _, err := os.Lstat(path); err == nil && !*force {
+		fmt.Fprintf(stderr, "Error: %s already exists; use --force to overwrite it\n", path)
+		return 1
+	}
+
+	passphrase, err := readPassphrase(*passFile, true, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if passphrase == "" {
+		fmt.Fprintln(stderr, "Error: passphrase cannot be empty")
+		return 1
+	}
+	archive, missing, err := collectBackup()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	data, err := sealBackup(archive, passphrase)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := writeBackupFile(path, data, *force); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			fmt.Fprintf(stderr, "Error: %s already exists; use --force to overwrite it\n", path)
+		} else {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+		return 1
+	}
+	for _, e := range archive.Profiles {
+		fmt.Fprintf(stdout, "  %s (%s)\n", e.Name, canonicalConnType(e.Type))
+	}
+	fmt.Fprintf(stdout, "Backed up %d profile(s) to %s\n", len(archive.Profiles), path)
+	if missing > 0 {
+		fmt.Fprintf(stdout, "Warning: %d profile(s) were saved without their password; run as root to include system secrets.\n", missing)
+	}
+	return 0
+}
+
+func writeRestorePlan(w io.Writer, items []restoreItem) {
+	adds, overwrites := 0, 0
+	for _, it := range items {
+		if !it.Selected {
+			continue
+		}
+		mark := "+"
+		if it.Overwrite {
+			mark = "~"
+			overwrites++
+		} else {
+			adds++
+		}
+		fmt.Fprintf(w, "  %s %-9s %s (%s, %s)\n", mark, it.action(), it.Entry.Name, canonicalConnType(it.Entry.Type), it.Entry.UUID)
+	}
+	fmt.Fprintf(w, "Restore: %d to add, %d to overwrite.\n", adds, overwrites)
+}
+
+func runRestoreCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	passFile := fs.String("passphrase-file", "", "read the passphrase from this file")
+	dryRun := fs.Bool("dry-run", false, "only show what would be restored")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	only := fs.String("only", "", "comma-separated profile names or UUIDs to restore")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fmt.Fprintf(stderr, "Usage: %s restore [--dry-run] [--yes] [--only NAMES] [--passphrase-file FILE] ARCHIVE\n", effectiveAppName())
+		return 2
+	}
+	path := rest[0]
+	if err := fs.Parse(rest[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Unexpected argument: %s\n", fs.Arg(0))
+		return 2
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	passphrase, err := readPassphrase(*passFile, false, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	archive, err := openBackup(data, passphrase)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	existing, err := gonetworkmanager.GetConnectionProfilesList(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error listing profiles: %v\n", err)
+		return 1
+	}
+	items := planRestore(archive, existing)
+	if *only != "" {
+		want := make(map[string]bool)
+		for _, n := range strings.Split(*only, ",") {
+			want[strings.TrimSpace(n)] = true
+		}
+		for i := range items {
+			items[i].Selected = want[items[i].Entry.Name] || want[items[i].Entry.UUID]
+		}
+	}
+
+	fmt.Fprintf(stdout, "Backup %s (created %s", path, archive.Created.Local().Format("2006-01-02 15:04"))
+	if archive.Host != "" {
+		fmt.Fprintf(stdout, " on %s", archive.Host)
+	}
+	fmt.Fprintln(stdout, "):")
+	writeRestorePlan(stdout, items)
+	if *dryRun {
+		return 0
+	}
+	if !*yes {
+		fmt.Fprint(stdout, "Proceed? [y/N] ")
+		answer, _ := bufio.NewReader(stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintln(stdout, "Aborted.")
+			return 1
+		}
+	}
+
+	failed := 0
+	for _, r := range runRestore(items) {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(stdout, "  ✗ %s: %v\n", r.Name, r.Err)
+			continue
+		}
+		fmt.Fprintf(stdout, "  ✓ %s\n", r.Name)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "Restore finished with %d error(s).\n", failed)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Restore complete.")
+	return 0
+}
+
+// --- TUI ---
+
+type restoreStage int
+
+const (
+	restoreStagePath restoreStage = iota
+	restoreStagePassphrase
+	restoreStageSelect
+	restoreStageRunning
+)
+
+type restoreScreen struct {
+	stage   restoreStage
+	path    string
+	input   textinput.Model
+	archive *backupArchive
+	items   []restoreItem
+	cursor  int
+}
+
+type backupOpenedMsg struct {
+	archive  *backupArchive
+	existing []gonetworkmanager.ConnectionProfile
+	err      error
+}
+
+type restoreDoneMsg struct {
+	results []restoreResult
+}
+
+func openBackupCmd(path, passphrase string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return backupOpenedMsg{err: err}
+		}
+		archive, err := openBackup(data, passphrase)
+		if err != nil {
+			return backupOpenedMsg{err: err}
+		}
+		existing, err := gonetworkmanager.GetConnectionProfilesList(false)
+		return backupOpenedMsg{archive: archive, existing: existing, err: err}
+	}
+}
+
+func runRestoreCmd(items []restoreItem) tea.Cmd {
+	return func() tea.Msg { return restoreDoneMsg{results: runRestore(items)} }
+}
+
+func (r *restoreScreen) promptFor(stage restoreStage) {
+	r.stage = stage
+	r.input = textinput.New()
+	r.input.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	r.input.CharLimit = 256
+	if stage == restoreStagePassphrase {
+		r.input.Prompt = passwordPromptStyle.Render("🔑 Passphrase: ")
+		r.input.EchoMode = textinput.EchoPassword
+		r.input.EchoCharacter = '•'
+	} else {
+		r.input.Prompt = passwordPromptStyle.Render("📦 Backup file: ")
+		r.input.Placeholder = "nmtui-backup-….nmbak"
+	}
+	r.input.Focus()
+}
+
+func (m *model) startRestore() tea.Cmd {
+	m.restore = &restoreScreen{}
+	m.restore.promptFor(restoreStagePath)
+	m.previousState = m.state
+	m.state = viewRestore
+	m.clearStatus()
+	return textinput.Blink
+}
+
+func (m *model) handleRestoreKeys(msg tea.KeyMsg) []tea.Cmd {
+	r := m.restore
+	if r == nil {
+		return nil
+	}
+	switch r.stage {
+	case restoreStagePath, restoreStagePassphrase:
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.closeRestore()
+			return nil
+		case tea.KeyEnter:
+			v := strings.TrimSpace(r.input.Value())
+			if v == "" {
+				return nil
+			}
+			if r.stage == restoreStagePath {
+				if strings.HasPrefix(v, "~/") {
+					if home, err := os.UserHomeDir(); err == nil {
+						v = filepath.Join(home, v[2:])
+					}
+				}
+				r.path = v
+				r.input.Blur()
+				r.promptFor(restoreStagePassphrase)
+				m.clearStatus()
+				return []tea.Cmd{textinput.Blink}
+			}
+			passphrase := r.input.Value()
+			r.input.Reset()
+			r.input.Blur()
+			m.isLoading = true
+			m.setStatus("Decrypting backup...", connectingStyle)
+			return []tea.Cmd{openBackupCmd(r.path, passphrase), m.spinner.Tick}
+		}
+		var cmd tea.Cmd
+		r.input, cmd = r.input.Update(msg)
+		return []tea.Cmd{cmd}
+	case restoreStageSelect:
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			m.closeRestore()
+		case msg.String() == "up":
+			if r.cursor > 0 {
+				r.cursor--
+			}
+		case msg.String() == "down":
+			if r.cursor < len(r.items)-1 {
+				r.cursor++
+			}
+		case key.Matches(msg, m.keys.Mark):
+			if len(r.items) > 0 {
+				r.items[r.cursor].Selected = !r.items[r.cursor].Selected
+			}
+		case key.Matches(msg, m.keys.SelectAll):
+			all := true
+			for _, it := range r.items {
+				all = all && it.Selected
+			}
+			for i := range r.items {
+				r.items[i].Selected = !all
+			}
+		case key.Matches(msg, m.keys.Connect):
+			n := 0
+			for _, it := range r.items {
+				if it.Selected {
+					n++
+				}
+			}
+			if n == 0 {
+				m.setStatus("Nothing selected.", toggleHiddenStatusMsgStyle)
+				return nil
+			}
+			r.stage = restoreStageRunning
+			m.isLoading = true
+			m.setStatus(fmt.Sprintf("Restoring %d profile(s)...", n), connectingStyle)
+			return []tea.Cmd{runRestoreCmd(r.items), m.spinner.Tick}
+		}
+	}
+	return nil
+}
+
+func (m *model) closeRestore() {
+	m.restore = nil
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	m.clearStatus()
+	m.resizeComponents()
+}
+
+func (m *model) handleBackupOpened(msg backupOpenedMsg) []tea.Cmd {
+	m.isLoading = false
+	r := m.restore
+	if r == nil {
+		return nil
+	}
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Cannot open backup: %v", msg.err), errorStyle)
+		if errors.Is(msg.err, errBadPassphrase) {
+			r.promptFor(restoreStagePassphrase)
+		} else {
+			r.promptFor(restoreStagePath)
+			r.input.SetValue(r.path)
+		}
+		return []tea.Cmd{textinput.Blink}
+	}
+	r.archive = msg.archive
+	r.items = planRestore(msg.archive, msg.existing)
+	r.cursor = 0
+	r.stage = restoreStageSelect
+	m.clearStatus()
+	return nil
+}
+
+func (m *model) handleRestoreDone(msg restoreDoneMsg) []tea.Cmd {
+	m.isLoading = false
+	failed := 0
+	var firstErr error
+	for _, res := range msg.results {
+		if res.Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", res.Name, res.Err)
+			}
+		}
+	}
+	m.restore = nil
+	m.state = viewKnownNetworksList
+	if failed > 0 {
+		m.setStatus(fmt.Sprintf("Restored %d of %d profile(s); %v", len(msg.results)-failed, len(msg.results), firstErr), errorStyle)
+	} else {
+		m.setStatus(fmt.Sprintf("Restored %d profile(s).", len(msg.results)), successStyle)
+	}
+	m.isLoading = true
+	m.knownWifiList.Title = "Loading Profiles..."
+	m.resizeComponents()
+	return []tea.Cmd{fetchKnownWifiApsCmd(), m.spinner.Tick}
+}
+
+func (m model) restoreView(width, height int) string {
+	r := m.restore
+	if r == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{titleStyle.Render("Restore Backup")}
+	switch r.stage {
+	case restoreStagePath, restoreStagePassphrase:
+		if r.path != "" && r.stage == restoreStagePassphrase {
+			lines = append(lines, label.Render("File: ")+r.path, "")
+		}
+		lines = append(lines, r.input.View(), "", label.Render("Enter: continue  Esc: cancel"))
+	default:
+		created := r.archive.Created.Local().Format("2006-01-02 15:04")
+		if r.archive.Host != "" {
+			created += " on " + r.archive.Host
+		}
+		lines = append(lines, label.Render("Created "+created), "")
+		maxRows := height - 12
+		if maxRows < 3 {
+			maxRows = 3
+		}
+		start := 0
+		if r.cursor >= maxRows {
+			start = r.cursor - maxRows + 1
+		}
+		for i := start; i < len(r.items) && i < start+maxRows; i++ {
+			it := r.items[i]
+			box := "[ ]"
+			if it.Selected {
+				box = "[x]"
+			}
+			action := lipgloss.NewStyle().Foreground(ansSuccessColor).Render("add")
+			if it.Overwrite {
+				action = lipgloss.NewStyle().Foreground(ansErrorColor).Render("overwrite")
+			}
+			line := fmt.Sprintf("%s %s %s %s", box, truncateRunes(it.Entry.Name, 32), label.Render("("+canonicalConnType(it.Entry.Type)+")"), action)
+			if i == r.cursor {
+				line = listSelectedItemStyle.Render("▸ " + line)
+			} else {
+				line = "  " + line
+			}
+			lines = append(lines, line)
+		}
+		if len(r.items) == 0 {
+			lines = append(lines, label.Render("The backup contains no profiles."))
+		}
+		lines = append(lines, "", label.Render(fmt.Sprintf("%s: toggle  %s: all/none  %s: restore selected  %s: cancel",
+			m.keys.Mark.Help().Key, m.keys.SelectAll.Help().Key, m.keys.Connect.Help().Key, m.keys.Back.Help().Key)))
+	}
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func cheapBackupKDF(t *testing.T) {
+	t.Helper()
+	old := backupKDFIterations
+	backupKDFIterations = backupMinKDFIterations
+	t.Cleanup(func() { backupKDFIterations = old })
+}
+
+func TestSealAndOpenBackup(t *testing.T) {
+	cheapBackupKDF(t)
+	a := backupArchive{Version: backupFormatVersion, Created: time.Unix(1700000000, 0).UTC(), Host: "laptop",
+		Profiles: []backupEntry{{Name: "Home", UUID: "u-home", Type: "wifi", Keyfile: "[connection]\nid=Home\n"}}}
+	data, err := sealBackup(a, "s3cret")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if bytes.Contains(data, []byte("Home")) {
+		t.Fatalf("archive contents are not encrypted")
+	}
+	got, err := openBackup(data, "s3cret")
+	if err != nil || got.Host != "laptop" || len(got.Profiles) != 1 || got.Profiles[0].Keyfile != a.Profiles[0].Keyfile {
+		t.Fatalf("round trip failed: %+v %v", got, err)
+	}
+	if _, err := openBackup(data, "wrong"); !errors.Is(err, errBadPassphrase) {
+		t.Fatalf("expected errBadPassphrase, got %v", err)
+	}
+	tampered := append([]byte(nil), data...)
+	tampered[len(backupMagic)+4]++ // iteration count is authenticated
+	if _, err := openBackup(tampered, "s3cret"); err == nil {
+		t.Fatalf("expected a tampered header to be rejected")
+	}
+	for _, n := range []uint32{0, backupMinKDFIterations - 1, 4000000000} {
+		bad := append([]byte(nil), data...)
+		binary.BigEndian.PutUint32(bad[len(backupMagic)+1:], n)
+		if _, err := openBackup(bad, "s3cret"); err == nil || !strings.Contains(err.Error(), "implausible") {
+			t.Fatalf("expected %d iterations to be refused before deriving a key, got %v", n, err)
+		}
+	}
+	if _, err := openBackup([]byte("hello"), "s3cret"); err == nil {
+		t.Fatalf("expected garbage to be rejected")
+	}
+	if _, err := sealBackup(a, ""); err == nil {
+		t.Fatalf("expected empty passphrase to be rejected")
+	}
+}
+
+const backupFakeNmcli = `
+case "$*" in
+  "-m multiline connection show --order name")
+    printf 'NAME: Home\nUUID: u-home\nTYPE: 802-11-wireless\n'
+    printf 'NAME: lo\nUUID: u-lo\nTYPE: loopback\n'
+    if [ -f "$STATE/wired-gone" ]; then :; else printf 'NAME: Wired\nUUID: u-wired\nTYPE: 802-3-ethernet\n'; fi ;;
+  "-s -m multiline connection show u-home")
+    printf 'connection.id: Home\nconnection.uuid: u-home\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Home\n802-11-wireless-security.key-mgmt: wpa-psk\n802-11-wireless-security.psk: hunter22\n' ;;
+  "-s -m multiline connection show u-wired")
+    printf 'connection.id: Wired\nconnection.uuid: u-wired\nconnection.type: 802-3-ethernet\nipv4.method: auto\n' ;;
+  "-m multiline connection show u-home") printf 'connection.id: Home\n' ;;
+  "-m multiline connection show u-wired") if [ -f "$STATE/wired-gone" ]; then exit 10; fi; printf 'connection.id: Wired\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestBackupAndRestoreCLI(t *testing.T) {
+	cheapBackupKDF(t)
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", backupFakeNmcli)
+	passFile := filepath.Join(state, "pass")
+	os.WriteFile(passFile, []byte("s3cret\n"), 0600)
+	archive := filepath.Join(state, "laptop.nmbak")
+
+	var out, errOut bytes.Buffer
+	if code := runBackupCLI([]string{"-o", archive, "--passphrase-file", passFile}, &out, &errOut); code != 0 {
+		t.Fatalf("backup exit %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "Backed up 2 profile(s)") || strings.Contains(out.String(), "Warning") {
+		t.Fatalf("unexpected backup output:\n%s", out.String())
+	}
+	if info, _ := os.Stat(archive); info == nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected a 0600 archive")
+	}
+	if data, _ := os.ReadFile(archive); bytes.Contains(data, []byte("hunter22")) {
+		t.Fatalf("archive leaks the psk in plain text")
+	}
+	before, _ := os.ReadFile(archive)
+	errOut.Reset()
+	if code := runBackupCLI([]string{"-o", archive, "--passphrase-file", passFile}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "already exists; use --force") {
+		t.Fatalf("expected an existing archive to be kept, got %d: %s", code, errOut.String())
+	}
+	if after, _ := os.ReadFile(archive); !bytes.Equal(before, after) {
+		t.Fatalf("the existing archive was overwritten")
+	}
+	if code := runBackupCLI([]string{"-o", archive, "--passphrase-file", passFile, "--force"}, &out, &errOut); code != 0 {
+		t.Fatalf("--force backup exit %d: %s", code, errOut.String())
+	}
+
+	// Wired was removed since the backup: it is re-added, Home is overwritten.
+	os.WriteFile(filepath.Join(state, "wired-gone"), nil, 0600)
+	t.Setenv("NMTUI_BACKUP_PASSPHRASE", "s3cret")
+	out.Reset()
+	if code := runRestoreCLI([]string{archive, "--dry-run"}, strings.NewReader(""), &out, &errOut); code != 0 {
+		t.Fatalf("dry run exit %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"~ overwrite Home (wifi, u-home)", "+ add       Wired (ethernet, u-wired)", "1 to add, 1 to overwrite"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("restore plan missing %q:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(state, "calls")); err == nil {
+		t.Fatalf("dry run must not change anything")
+	}
+
+	out.Reset()
+	if code := runRestoreCLI([]string{archive}, strings.NewReader("n\n"), &out, &errOut); code != 1 || !strings.Contains(out.String(), "Aborted") {
+		t.Fatalf("declining should abort, got %d:\n%s", code, out.String())
+	}
+
+	out.Reset()
+	if code := runRestoreCLI([]string{"--only", "Home", archive}, strings.NewReader("y\n"), &out, &errOut); code != 0 {
+		t.Fatalf("restore exit %d: %s\n%s", code, errOut.String(), out.String())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if !strings.Contains(string(calls), "connection delete u-home\nconnection add type 802-11-wireless con-name Home ifname * connection.uuid u-home") ||
+		!strings.Contains(string(calls), "802-11-wireless-security.psk hunter22") || strings.Contains(string(calls), "Wired") {
+		t.Fatalf("unexpected restore calls:\n%s", calls)
+	}
+
+	t.Setenv("NMTUI_BACKUP_PASSPHRASE", "wrong")
+	if code := runRestoreCLI([]string{"--yes", archive}, strings.NewReader(""), &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "wrong passphrase") {
+		t.Fatalf("expected a passphrase error, got %d: %s", code, errOut.String())
+	}
+}
+
+func TestRestoreScreenFlow(t *testing.T) {
+	cheapBackupKDF(t)
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", backupFakeNmcli)
+	archive, _, err := collectBackup()
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	data, _ := sealBackup(archive, "s3cret")
+	path := filepath.Join(state, "b.nmbak")
+	os.WriteFile(path, data, 0600)
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	m2 := updated.(model)
+	if m2.state != viewRestore || !m2.isTextInputActive() {
+		t.Fatalf("expected R to open the restore screen")
+	}
+	m2.restore.input.SetValue(path)
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if m2.restore.stage != restoreStagePassphrase || !strings.Contains(m2.View(), "Passphrase") {
+		t.Fatalf("expected the passphrase prompt")
+	}
+
+	m2.restore.input.SetValue("wrong")
+	updated, cmd := m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	updated, _ = m2.Update(cmd().(tea.BatchMsg)[0]())
+	m2 = updated.(model)
+	if m2.restore.stage != restoreStagePassphrase || !strings.Contains(m2.connectionStatusMsg, "wrong passphrase") {
+		t.Fatalf("expected to be asked again after a wrong passphrase, status %q", m2.connectionStatusMsg)
+	}
+
+	m2.restore.input.SetValue("s3cret")
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	updated, _ = m2.Update(cmd().(tea.BatchMsg)[0]())
+	m2 = updated.(model)
+	if m2.restore.stage != restoreStageSelect || len(m2.restore.items) != 2 {
+		t.Fatalf("expected the profile checklist, got stage %v items %d", m2.restore.stage, len(m2.restore.items))
+	}
+	v := m2.View()
+	if !strings.Contains(v, "[x] Home") || !strings.Contains(v, "overwrite") {
+		t.Fatalf("expected checkboxes with actions:\n%s", v)
+	}
+
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m2 = updated.(model)
+	if m2.restore.items[0].Selected {
+		t.Fatalf("space should untick the current profile")
+	}
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if cmd == nil || m2.restore.stage != restoreStageRunning {
+		t.Fatalf("expected Enter to start the restore")
+	}
+	updated, _ = m2.Update(cmd().(tea.BatchMsg)[0]())
+	m2 = updated.(model)
+	if m2.state != viewKnownNetworksList || !strings.Contains(m2.connectionStatusMsg, "Restored 1 profile(s)") {
+		t.Fatalf("expected a restore summary, got %q", m2.connectionStatusMsg)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if strings.Contains(string(calls), "con-name Home") || !strings.Contains(string(calls), "con-name Wired") {
+		t.Fatalf("only the ticked profile should be restored:\n%s", calls)
+	}
+}
@@ -0,0 +1,393 @@
+// nmtui/cmd/bulk.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// profileResult is the outcome of one step of a bulk profile operation.
+type profileResult struct {
+	Name string
+	Err  error
+}
+
+// summarizeResults builds the status line shown after a bulk operation. ok is
+// false when any profile failed; the first failure is named.
+func summarizeResults(verb string, results []profileResult) (summary string, ok bool) {
+	failed := 0
+	var firstErr error
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", r.Name, r.Err)
+			}
+		}
+	}
+	if failed == 0 {
+		return fmt.Sprintf("%s %d profile(s).", verb, len(results)), true
+	}
+	return fmt.Sprintf("%s %d of %d profile(s); %d failed, first: %v", verb, len(results)-failed, len(results), failed, firstErr), false
+}
+
+// profileMarks holds the profiles marked with Space, by UUID. It is shared
+// with the list delegate, so it is cleared in place rather than replaced.
+type profileMarks map[string]string
+
+type markedProfile struct {
+	UUID, Name string
+}
+
+func (pm profileMarks) sorted() []markedProfile {
+	out := make([]markedProfile, 0, len(pm))
+	for uuid, name := range pm {
+		out = append(out, markedProfile{UUID: uuid, Name: name})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+// toggleMark marks or unmarks the selected list item and moves the cursor on,
+// so several profiles can be marked by holding Space.
+func (m *model) toggleMark(l *list.Model) {
+	p, ok := selectedProfile(l)
+	if !ok {
+		m.setStatus("Only saved networks can be marked.", toggleHiddenStatusMsgStyle)
+		return
+	}
+	if _, marked := m.marks[p.UUID]; marked {
+		delete(m.marks, p.UUID)
+	} else {
+		m.marks[p.UUID] = p.Name
+	}
+	l.CursorDown()
+	if len(m.marks) == 0 {
+		m.clearStatus()
+		return
+	}
+	m.setStatus(fmt.Sprintf("%d marked. %s: bulk actions, %s: clear marks", len(m.marks), m.keys.Bulk.Help().Key, m.keys.Back.Help().Key), toggleHiddenStatusMsgStyle)
+}
+
+// selectedProfile is the saved profile behind the selected list item.
+func selectedProfile(l *list.Model) (markedProfile, bool) {
+	ap, ok := l.SelectedItem().(wifiAP)
+	if !ok || ap.WifiAccessPoint == nil || ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID] == "" {
+		return markedProfile{}, false
+	}
+	name := ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionName]
+	if name == "" {
+		name = ap.getSSIDFromScannedAP()
+	}
+	return markedProfile{UUID: ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID], Name: name}, true
+}
+
+func (m *model) clearMarks() {
+	clear(m.marks)
+	m.clearStatus()
+}
+
+// --- Bulk action screen ---
+
+type bulkAction int
+
+const (
+	bulkForget bulkAction = iota
+	bulkAutoconnectOn
+	bulkAutoconnectOff
+	bulkPriority
+	bulkExport
+	bulkExportSecrets
+	bulkShare
+)
+
+var bulkActionLabels = []string{
+	"Forget",
+	"Autoconnect on",
+	"Autoconnect off",
+	"Set priority...",
+	"Export keyfiles",
+	"Export keyfiles with secrets",
+	"Share (Wi-Fi QR strings)",
+}
+
+type bulkScreen struct {
+	profiles   []markedProfile
+	cursor     int
+	confirming bool
+	priority   textinput.Model
+	returnTo   viewState
+}
+
+type bulkDoneMsg struct {
+	action   bulkAction
+	priority int
+	results  []profileResult
+	path     string
+}
+
+func newBulkPriorityInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "0"
+	ti.CharLimit = 4
+	ti.Prompt = passwordPromptStyle.Render("Priority: ")
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	return ti
+}
+
+func (m *model) openBulk() {
+	if len(m.marks) == 0 {
+		m.setStatus("Mark profiles with Space first.", toggleHiddenStatusMsgStyle)
+		return
+	}
+	m.bulk = &bulkScreen{profiles: m.marks.sorted(), priority: newBulkPriorityInput(), returnTo: m.state}
+	m.state = viewBulk
+	m.clearStatus()
+}
+
+func (m *model) closeBulk() {
+	if m.bulk != nil {
+		m.state = m.bulk.returnTo
+	}
+	m.bulk = nil
+	m.clearStatus()
+	m.resizeComponents()
+}
+
+func (m *model) handleBulkKeys(msg tea.KeyMsg) []tea.Cmd {
+	b := m.bulk
+	if b == nil {
+		return nil
+	}
+	if m.isLoading {
+		return nil
+	}
+	if b.confirming {
+		switch {
+		case key.Matches(msg, m.keys.Confirm), msg.String() == "enter":
+			b.confirming = false
+			return m.runBulk(bulkForget, 0)
+		case msg.String() == "n", msg.String() == "esc":
+			b.confirming = false
+			m.clearStatus()
+		}
+		return nil
+	}
+	if b.priority.Focused() {
+		switch msg.Type {
+		case tea.KeyEsc:
+			b.priority.Blur()
+			m.clearStatus()
+			return nil
+		case tea.KeyEnter:
+			p, err := strconv.Atoi(strings.TrimSpace(b.priority.Value()))
+			if err != nil || p < -999 || p > 999 {
+				m.setStatus("Priority must be a whole number between -999 and 999.", errorStyle)
+				return nil
+			}
+			b.priority.Blur()
+			return m.runBulk(bulkPriority, p)
+		}
+		var cmd tea.Cmd
+		b.priority, cmd = b.priority.Update(msg)
+		return []tea.Cmd{cmd}
+	}
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.closeBulk()
+	case msg.String() == "up":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case msg.String() == "down":
+		if b.cursor < len(bulkActionLabels)-1 {
+			b.cursor++
+		}
+	case key.Matches(msg, m.keys.Connect):
+		switch action := bulkAction(b.cursor); action {
+		case bulkForget:
+			b.confirming = true
+			m.setStatus(fmt.Sprintf("Forget %d profile(s)? %s/n", len(b.profiles), m.keys.Confirm.Help().Key), errorStyle)
+		case bulkPriority:
+			b.priority.SetValue("")
+			b.priority.Focus()
+			m.clearStatus()
+			return []tea.Cmd{textinput.Blink}
+		default:
+			return m.runBulk(action, 0)
+		}
+	}
+	return nil
+}
+
+// shareNetworks shares the marked profiles, or the selected saved network,
+// like the bulk Share action; it has no screen of its own.
+func (m *model) shareNetworks() []tea.Cmd {
+	profiles := m.marks.sorted()
+	if len(profiles) == 0 {
+		l := &m.wifiList
+		if m.state == viewKnownNetworksList {
+			l = &m.knownWifiList
+		}
+		p, ok := selectedProfile(l)
+		if !ok {
+			m.setStatus("Only saved networks can be shared.", toggleHiddenStatusMsgStyle)
+			return nil
+		}
+		profiles = []markedProfile{p}
+	}
+	m.isLoading = true
+	m.setStatus(fmt.Sprintf("Sharing %d profile(s)...", len(profiles)), connectingStyle)
+	return []tea.Cmd{bulkActionCmd(bulkShare, 0, profiles), m.spinner.Tick}
+}
+
+func (m *model) runBulk(action bulkAction, priority int) []tea.Cmd {
+	profiles := m.bulk.profiles
+	m.isLoading = true
+	m.setStatus(fmt.Sprintf("%s: %d profile(s)...", strings.TrimSuffix(bulkActionLabels[action], "..."), len(profiles)), connectingStyle)
+	return []tea.Cmd{bulkActionCmd(action, priority, profiles), m.spinner.Tick}
+}
+
+// bulkActionCmd applies one action to every profile, continuing past
+// failures so the summary can list them.
+func bulkActionCmd(action bulkAction, priority int, profiles []markedProfile) tea.Cmd {
+	return func() tea.Msg {
+		done := bulkDoneMsg{action: action, priority: priority}
+		dir, err := os.Getwd()
+		if err != nil {
+			dir = "."
+		}
+		used := make(map[string]bool)
+		var shared []string
+		for _, p := range profiles {
+			var err error
+			switch action {
+			case bulkForget:
+				_, err = gonetworkmanager.ConnectionDelete(p.UUID)
+			case bulkAutoconnectOn, bulkAutoconnectOff:
+				_, err = gonetworkmanager.SetAutoconnect(p.UUID, action == bulkAutoconnectOn)
+			case bulkPriority:
+				_, err = gonetworkmanager.SetAutoconnectPriority(p.UUID, priority)
+			case bulkExport, bulkExportSecrets:
+				_, err = exportProfileKeyfile(p.UUID, dir, action == bulkExportSecrets, used, false)
+				done.path = dir
+			case bulkShare:
+				var uri string
+				if uri, err = gonetworkmanager.WifiShareURI(p.UUID); err == nil {
+					shared = append(shared, "# "+p.Name, uri)
+				}
+			}
+			if err != nil {
+				log.Printf("Bulk %s: %q (%s) failed: %v", bulkActionLabels[action], p.Name, p.UUID, err)
+			}
+			done.results = append(done.results, profileResult{Name: p.Name, Err: err})
+		}
+		if len(shared) > 0 {
+			path := filepath.Join(dir, "wifi-share-"+time.Now().Format("20060102-150405")+".txt")
+			if err := os.WriteFile(path, []byte(strings.Join(shared, "\n")+"\n"), 0600); err != nil {
+				for i := range done.results {
+					if done.results[i].Err == nil {
+						done.results[i].Err = err
+					}
+				}
+			} else {
+				done.path = path
+			}
+		}
+		return done
+	}
+}
+
+func (m *model) handleBulkDone(msg bulkDoneMsg) []tea.Cmd {
+	m.isLoading = false
+	verb := map[bulkAction]string{
+		bulkForget:         "Forgot",
+		bulkAutoconnectOn:  "Enabled autoconnect for",
+		bulkAutoconnectOff: "Disabled autoconnect for",
+		bulkPriority:       fmt.Sprintf("Set priority %d for", msg.priority),
+		bulkExport:         "Exported",
+		bulkExportSecrets:  "Exported",
+		bulkShare:          "Shared",
+	}[msg.action]
+	summary, ok := summarizeResults(verb, msg.results)
+	if ok && msg.path != "" {
+		summary = strings.TrimSuffix(summary, ".") + " to " + msg.path
+	}
+	if ok && msg.action == bulkForget {
+		summary += " Restore any of them from history (H)."
+	}
+
+	// Failed profiles stay marked so the action can be retried.
+	if m.bulk != nil {
+		for i, r := range msg.results {
+			if r.Err == nil && i < len(m.bulk.profiles) {
+				delete(m.marks, m.bulk.profiles[i].UUID)
+			}
+		}
+	}
+	m.closeBulk()
+	style := successStyle
+	if !ok {
+		style = errorStyle
+	}
+	m.setStatus(summary, style)
+
+	switch msg.action {
+	case bulkExport, bulkExportSecrets, bulkShare:
+		return nil
+	}
+	if m.state == viewKnownNetworksList {
+		m.isLoading = true
+		m.knownWifiList.Title = "Loading Profiles..."
+		return []tea.Cmd{fetchKnownWifiApsCmd(), m.spinner.Tick}
+	}
+	return []tea.Cmd{fetchKnownNetworksCmd()}
+}
+
+func (m model) bulkView(width, height int) string {
+	b := m.bulk
+	if b == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	names := make([]string, 0, len(b.profiles))
+	for _, p := range b.profiles {
+		names = append(names, p.Name)
+	}
+	lines := []string{
+		titleStyle.Render(fmt.Sprintf("Bulk Actions (%d profiles)", len(b.profiles))),
+		label.Render(truncateRunes(strings.Join(names, ", "), width-8)),
+		"",
+	}
+	for i, l := range bulkActionLabels {
+		if i == b.cursor {
+			lines = append(lines, listSelectedItemStyle.Render("▸ "+l))
+		} else {
+			lines = append(lines, "  "+l)
+		}
+	}
+	if b.priority.Focused() {
+		lines = append(lines, "", b.priority.View(), label.Render("Higher values are preferred by autoconnect."))
+	}
+	lines = append(lines, "", label.Render(fmt.Sprintf("%s: apply  %s: back", m.keys.Connect.Help().Key, m.keys.Back.Help().Key)))
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
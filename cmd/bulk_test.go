@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+const bulkFakeNmcli = `
+case "$*" in
+  "connection modify u-cafe "*) echo "Error: permission denied" >&2; exit 1 ;;
+  "-s -m multiline connection show u-home")
+    printf 'connection.id: Home\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Home\n802-11-wireless-security.key-mgmt: wpa-psk\n802-11-wireless-security.psk: hunter22\n' ;;
+  "-s -m multiline connection show u-cafe")
+    printf 'connection.id: Cafe\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Cafe\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func bulkTestModel(t *testing.T) model {
+	t.Helper()
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", bulkFakeNmcli)
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	var items []list.Item
+	for _, p := range []struct{ name, uuid string }{{"Cafe", "u-cafe"}, {"Home", "u-home"}, {"Office", "u-office"}} {
+		items = append(items, wifiAP{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{
+			gonetworkmanager.NmcliFieldWifiSSID:       p.name,
+			gonetworkmanager.NmcliFieldConnectionName: p.name,
+			gonetworkmanager.NmcliFieldConnectionUUID: p.uuid,
+		}, IsKnown: true})
+	}
+	m.knownWifiList.SetItems(items)
+	return m
+}
+
+func press(t *testing.T, m model, msgs ...tea.KeyMsg) (model, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, msg := range msgs {
+		var updated tea.Model
+		updated, cmd = m.Update(msg)
+		m = updated.(model)
+	}
+	return m, cmd
+}
+
+var (
+	spaceKey = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	downKey  = tea.KeyMsg{Type: tea.KeyDown}
+	enterKey = tea.KeyMsg{Type: tea.KeyEnter}
+)
+
+func runeKey(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+func TestMarkAndBulkAutoconnectReportsPartialFailure(t *testing.T) {
+	m := bulkTestModel(t)
+	m, _ = press(t, m, spaceKey, spaceKey)
+	if len(m.marks) != 2 || !strings.Contains(m.View(), "● Cafe") {
+		t.Fatalf("expected Cafe and Home to be marked, got %v", m.marks)
+	}
+	m, _ = press(t, m, runeKey('b'))
+	if m.state != viewBulk || !strings.Contains(m.View(), "Bulk Actions (2 profiles)") {
+		t.Fatalf("expected the bulk menu:\n%s", m.View())
+	}
+	m, cmd := press(t, m, downKey, downKey, enterKey) // Autoconnect off
+	if cmd == nil {
+		t.Fatalf("expected the action to start")
+	}
+	updated, _ := m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if m.state != viewKnownNetworksList || !strings.Contains(m.connectionStatusMsg, "Disabled autoconnect for 1 of 2 profile(s); 1 failed, first: Cafe") {
+		t.Fatalf("expected a partial failure summary, got %q", m.connectionStatusMsg)
+	}
+	if _, ok := m.marks["u-cafe"]; !ok || len(m.marks) != 1 {
+		t.Fatalf("the failed profile should stay marked, got %v", m.marks)
+	}
+	calls, _ := os.ReadFile(filepath.Join(os.Getenv("STATE"), "calls"))
+	if !strings.Contains(string(calls), "connection modify u-home connection.autoconnect no") {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.marks) != 0 || m.state != viewKnownNetworksList {
+		t.Fatalf("Esc should clear the marks before leaving the view")
+	}
+}
+
+func TestBulkForgetAndPriority(t *testing.T) {
+	m := bulkTestModel(t)
+	m, _ = press(t, m, downKey, spaceKey, spaceKey, runeKey('b'), enterKey)
+	if !m.bulk.confirming || !strings.Contains(m.connectionStatusMsg, "Forget 2 profile(s)?") {
+		t.Fatalf("expected a confirmation, got %q", m.connectionStatusMsg)
+	}
+	m, cmd := press(t, m, runeKey('y'))
+	updated, _ := m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if !strings.Contains(m.connectionStatusMsg, "Forgot 2 profile(s).") || len(m.marks) != 0 {
+		t.Fatalf("unexpected result %q, marks %v", m.connectionStatusMsg, m.marks)
+	}
+	m.isLoading = false // profiles reloaded
+
+	m, _ = press(t, m, spaceKey, runeKey('b'), downKey, downKey, downKey, enterKey)
+	if !m.isTextInputActive() {
+		t.Fatalf("expected the priority prompt")
+	}
+	m.bulk.priority.SetValue("abc")
+	m, _ = press(t, m, enterKey)
+	if !strings.Contains(m.connectionStatusMsg, "whole number") {
+		t.Fatalf("expected a validation error, got %q", m.connectionStatusMsg)
+	}
+	m.bulk.priority.SetValue("10")
+	m, cmd = press(t, m, enterKey)
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	calls, _ := os.ReadFile(filepath.Join(os.Getenv("STATE"), "calls"))
+	if !strings.Contains(string(calls), "connection delete u-home\nconnection delete u-office\n") ||
+		!strings.Contains(string(calls), "connection.autoconnect-priority 10") {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+}
+
+func TestBulkShareWritesJoinStrings(t *testing.T) {
+	t.Chdir(t.TempDir())
+	m := bulkTestModel(t)
+	m, _ = press(t, m, spaceKey, spaceKey, runeKey('b'))
+	m.bulk.cursor = int(bulkShare)
+	m, cmd := press(t, m, enterKey)
+	done := cmd().(tea.BatchMsg)[0]().(bulkDoneMsg)
+	if done.path == "" {
+		t.Fatalf("expected a share file, got %+v", done)
+	}
+	data, err := os.ReadFile(done.path)
+	if err != nil || string(data) != "# Cafe\nWIFI:T:nopass;S:Cafe;;\n# Home\nWIFI:T:WPA;S:Home;P:hunter22;;\n" {
+		t.Fatalf("unexpected share file: %v\n%s", err, data)
+	}
+	if info, _ := os.Stat(done.path); info.Mode().Perm() != 0600 {
+		t.Fatalf("share file contains passwords and must be 0600")
+	}
+}
+
+func TestSummarizeResults(t *testing.T) {
+	if s, ok := summarizeResults("Exported", []profileResult{{Name: "A"}}); !ok || s != "Exported 1 profile(s)." {
+		t.Fatalf("got %q %v", s, ok)
+	}
+	s, ok := summarizeResults("Forgot", []profileResult{{Name: "A", Err: errors.New("boom")}, {Name: "B"}, {Name: "C", Err: errors.New("bang")}})
+	if ok || s != "Forgot 1 of 3 profile(s); 2 failed, first: A: boom" {
+		t.Fatalf("got %q %v", s, ok)
+	}
+}
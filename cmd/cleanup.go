@@ -0,0 +1,426 @@
+// nmtui/cmd/cleanup.go
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	defaultStaleAge = 90 * 24 * time.Hour
+	staleAgeStep    = 30 * 24 * time.Hour
+	minStaleAge     = 7 * 24 * time.Hour
+)
+
+// staleCandidate is a Wi-Fi profile the cleanup assistant suggests deleting.
+type staleCandidate struct {
+	Profile gonetworkmanager.KnownWifiProfile
+	Unused  bool   // last used longer ago than the threshold
+	Never   bool   // connection.timestamp is 0
+	DupOf   string // name of the more recently used profile for the same SSID
+}
+
+func (c staleCandidate) reasons(now time.Time) string {
+	var r []string
+	switch {
+	case c.Never:
+		r = append(r, "never connected")
+	case c.Unused:
+		r = append(r, fmt.Sprintf("unused for %d days", int(now.Sub(time.Unix(c.Profile.Timestamp, 0)).Hours()/24)))
+	}
+	if c.DupOf != "" {
+		r = append(r, "duplicate of "+c.DupOf)
+	}
+	return strings.Join(r, ", ")
+}
+
+// parseAge accepts Go durations plus day and week suffixes ("90d", "2w").
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 2w or 36h)", s)
+	}
+	return d, nil
+}
+
+// findStaleProfiles classifies profiles, grouping duplicates by SSID. Active
+// profiles are never suggested. Within a duplicate-SSID group the most recently used profile is
+// kept. Results are ordered least recently used first.
+func findStaleProfiles(profiles []gonetworkmanager.KnownWifiProfile, olderThan time.Duration, now time.Time) []staleCandidate {
+	byUUID := make(map[string]*staleCandidate)
+	var order []string
+	add := func(p gonetworkmanager.KnownWifiProfile) *staleCandidate {
+		if c, ok := byUUID[p.UUID]; ok {
+			return c
+		}
+		c := &staleCandidate{Profile: p}
+		byUUID[p.UUID] = c
+		order = append(order, p.UUID)
+		return c
+	}
+
+	groups := make(map[string][]gonetworkmanager.KnownWifiProfile)
+	for _, p := range profiles {
+		groups[p.SSID] = append(groups[p.SSID], p)
+		if p.Device != "" {
+			continue
+		}
+		switch {
+		case p.Timestamp == 0:
+			add(p).Never = true
+		case now.Sub(time.Unix(p.Timestamp, 0)) > olderThan:
+			add(p).Unused = true
+		}
+	}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			a, b := group[i], group[j]
+			if (a.Device != "") != (b.Device != "") {
+				return a.Device != ""
+			}
+			if a.Timestamp != b.Timestamp {
+				return a.Timestamp > b.Timestamp
+			}
+			return a.Priority > b.Priority
+		})
+		for _, p := range group[1:] {
+			if p.Device == "" {
+				add(p).DupOf = group[0].Name
+			}
+		}
+	}
+
+	out := make([]staleCandidate, 0, len(order))
+	for _, uuid := range order {
+		out = append(out, *byUUID[uuid])
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Profile.Timestamp != out[j].Profile.Timestamp {
+			return out[i].Profile.Timestamp < out[j].Profile.Timestamp
+		}
+		return out[i].Profile.Name < out[j].Profile.Name
+	})
+	return out
+}
+
+func scanStaleProfiles(olderThan time.Duration) ([]staleCandidate, error) {
+	profiles, err := gonetworkmanager.GetKnownWifiProfiles()
+	if err != nil {
+		return nil, err
+	}
+	return findStaleProfiles(profiles, olderThan, time.Now()), nil
+}
+
+// deleteProfiles removes profiles one by one; each is snapshotted to the
+// history first, so a bulk delete can still be undone per profile.
+func deleteProfiles(profiles []gonetworkmanager.KnownWifiProfile) []profileResult {
+	results := make([]profileResult, 0, len(profiles))
+	for _, p := range profiles {
+		_, err := gonetworkmanager.ConnectionDelete(p.UUID)
+		if err != nil {
+			log.Printf("Cleanup: deleting %q (%s) failed: %v", p.Name, p.UUID, err)
+		}
+		results = append(results, profileResult{Name: p.Name, Err: err})
+	}
+	return results
+}
+
+// --- CLI ---
+
+func runProfilePruneCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("profile prune", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	olderThan := fs.String("older-than", "90d", "delete Wi-Fi profiles not used for this long (e.g. 90d, 2w)")
+	never := fs.Bool("never-used", false, "also delete profiles that never connected")
+	dups := fs.Bool("duplicates", false, "also delete older duplicates of the same SSID")
+	dryRun := fs.Bool("dry-run", false, "only list what would be deleted")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Unexpected argument: %s\n", fs.Arg(0))
+		return 2
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	candidates, err := scanStaleProfiles(age)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error listing profiles: %v\n", err)
+		return 1
+	}
+	now := time.Now()
+	var doomed []gonetworkmanager.KnownWifiProfile
+	for _, c := range candidates {
+		if !(c.Unused || (*never && c.Never) || (*dups && c.DupOf != "")) {
+			continue
+		}
+		doomed = append(doomed, c.Profile)
+		fmt.Fprintf(stdout, "  - %s (%s)\n", c.Profile.Name, c.reasons(now))
+	}
+	if len(doomed) == 0 {
+		fmt.Fprintln(stdout, "No stale profiles found.")
+		return 0
+	}
+	fmt.Fprintf(stdout, "%d profile(s) to delete.\n", len(doomed))
+	if *dryRun {
+		return 0
+	}
+	if !*yes {
+		fmt.Fprint(stdout, "Delete them? [y/N] ")
+		answer, _ := bufio.NewReader(stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintln(stdout, "Aborted.")
+			return 1
+		}
+	}
+	failed := 0
+	for _, r := range deleteProfiles(doomed) {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(stdout, "  ✗ %s: %v\n", r.Name, r.Err)
+		}
+	}
+	fmt.Fprintf(stdout, "Deleted %d profile(s); snapshots are kept in `%s history`.\n", len(doomed)-failed, effectiveAppName())
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// --- TUI ---
+
+type cleanupScreen struct {
+	age        time.Duration
+	candidates []staleCandidate
+	selected   map[string]bool
+	cursor     int
+	confirming bool
+}
+
+type staleProfilesMsg struct {
+	age        time.Duration
+	candidates []staleCandidate
+	err        error
+}
+
+type cleanupDoneMsg struct {
+	results []profileResult
+}
+
+func scanStaleProfilesCmd(age time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		c, err := scanStaleProfiles(age)
+		return staleProfilesMsg{age: age, candidates: c, err: err}
+	}
+}
+
+func (m *model) openCleanup() []tea.Cmd {
+	m.cleanup = &cleanupScreen{age: defaultStaleAge, selected: make(map[string]bool)}
+	m.isLoading = true
+	m.state = viewCleanup
+	m.clearStatus()
+	return []tea.Cmd{scanStaleProfilesCmd(m.cleanup.age), m.spinner.Tick}
+}
+
+func (m *model) handleStaleProfiles(msg staleProfilesMsg) {
+	c := m.cleanup
+	if c == nil || msg.age != c.age {
+		return
+	}
+	m.isLoading = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not list profiles: %v", msg.err), errorStyle)
+		return
+	}
+	c.candidates = msg.candidates
+	keep := make(map[string]bool)
+	for _, cand := range c.candidates {
+		if c.selected[cand.Profile.UUID] {
+			keep[cand.Profile.UUID] = true
+		}
+	}
+	c.selected = keep
+	if c.cursor >= len(c.candidates) {
+		c.cursor = 0
+	}
+}
+
+func (c *cleanupScreen) selectedProfiles() []gonetworkmanager.KnownWifiProfile {
+	var out []gonetworkmanager.KnownWifiProfile
+	for _, cand := range c.candidates {
+		if c.selected[cand.Profile.UUID] {
+			out = append(out, cand.Profile)
+		}
+	}
+	return out
+}
+
+func (m *model) handleCleanupKeys(msg tea.KeyMsg) []tea.Cmd {
+	c := m.cleanup
+	if c == nil {
+		return nil
+	}
+	if c.confirming {
+		switch {
+		case key.Matches(msg, m.keys.Confirm), msg.String() == "enter":
+			c.confirming = false
+			m.isLoading = true
+			doomed := c.selectedProfiles()
+			m.setStatus(fmt.Sprintf("Deleting %d profile(s)...", len(doomed)), connectingStyle)
+			return []tea.Cmd{func() tea.Msg { return cleanupDoneMsg{results: deleteProfiles(doomed)} }, m.spinner.Tick}
+		case msg.String() == "n", msg.String() == "esc":
+			c.confirming = false
+			m.clearStatus()
+		}
+		return nil
+	}
+	if m.isLoading {
+		if key.Matches(msg, m.keys.Back) {
+			m.closeCleanup()
+		}
+		return nil
+	}
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.closeCleanup()
+	case msg.String() == "up":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case msg.String() == "down":
+		if c.cursor < len(c.candidates)-1 {
+			c.cursor++
+		}
+	case key.Matches(msg, m.keys.Mark):
+		if len(c.candidates) > 0 {
+			uuid := c.candidates[c.cursor].Profile.UUID
+			c.selected[uuid] = !c.selected[uuid]
+		}
+	case key.Matches(msg, m.keys.SelectAll):
+		all := len(c.selectedProfiles()) == len(c.candidates)
+		for _, cand := range c.candidates {
+			c.selected[cand.Profile.UUID] = !all
+		}
+	case key.Matches(msg, m.keys.ThresholdUp, m.keys.ThresholdDown):
+		if key.Matches(msg, m.keys.ThresholdUp) {
+			c.age += staleAgeStep
+		} else if c.age-staleAgeStep >= minStaleAge {
+			c.age -= staleAgeStep
+		}
+		m.isLoading = true
+		return []tea.Cmd{scanStaleProfilesCmd(c.age), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Refresh):
+		m.isLoading = true
+		return []tea.Cmd{scanStaleProfilesCmd(c.age), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Connect), key.Matches(msg, m.keys.Forget):
+		n := len(c.selectedProfiles())
+		if n == 0 {
+			m.setStatus("Select profiles with Space first.", toggleHiddenStatusMsgStyle)
+			return nil
+		}
+		c.confirming = true
+		m.setStatus(fmt.Sprintf("Delete %d profile(s)? %s/n", n, m.keys.Confirm.Help().Key), errorStyle)
+	}
+	return nil
+}
+
+func (m *model) closeCleanup() {
+	m.cleanup = nil
+	m.isLoading = false
+	m.state = viewKnownNetworksList
+	m.clearStatus()
+	m.resizeComponents()
+}
+
+func (m *model) handleCleanupDone(msg cleanupDoneMsg) []tea.Cmd {
+	m.isLoading = false
+	if summary, ok := summarizeResults("Deleted", msg.results); ok {
+		m.setStatus(summary+" Restore any of them from history (H).", successStyle)
+	} else {
+		m.setStatus(summary, errorStyle)
+	}
+	cmds := []tea.Cmd{fetchKnownWifiApsCmd()}
+	if m.cleanup != nil {
+		m.cleanup.selected = make(map[string]bool)
+		m.isLoading = true
+		cmds = append(cmds, scanStaleProfilesCmd(m.cleanup.age))
+	}
+	return cmds
+}
+
+func (m model) cleanupView(width, height int) string {
+	c := m.cleanup
+	if c == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{titleStyle.Render("Clean Up Profiles")}
+	lines = append(lines, label.Render(fmt.Sprintf("Unused for more than %d days, never connected, or duplicate SSIDs", int(c.age.Hours()/24))), "")
+	now := time.Now()
+	switch {
+	case m.isLoading && len(c.candidates) == 0:
+		lines = append(lines, connectingStyle.Render(m.spinner.View()+" Scanning profiles..."))
+	case len(c.candidates) == 0:
+		lines = append(lines, label.Render("Nothing to clean up."))
+	}
+	maxRows := height - 12
+	if maxRows < 3 {
+		maxRows = 3
+	}
+	start := 0
+	if c.cursor >= maxRows {
+		start = c.cursor - maxRows + 1
+	}
+	for i := start; i < len(c.candidates) && i < start+maxRows; i++ {
+		cand := c.candidates[i]
+		box := "[ ]"
+		if c.selected[cand.Profile.UUID] {
+			box = "[x]"
+		}
+		line := fmt.Sprintf("%s %s %s", box, truncateRunes(cand.Profile.Name, 32), label.Render(cand.reasons(now)))
+		if i == c.cursor {
+			line = listSelectedItemStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", label.Render(fmt.Sprintf("%d selected  %s: toggle  %s: all/none  %s/%s: threshold  %s: delete  %s: back",
+		len(c.selectedProfiles()), m.keys.Mark.Help().Key, m.keys.SelectAll.Help().Key, m.keys.ThresholdUp.Help().Key, m.keys.ThresholdDown.Help().Key,
+		m.keys.Connect.Help().Key, m.keys.Back.Help().Key)))
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestFindStaleProfiles(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	days := func(n int) int64 { return now.Add(-time.Duration(n) * 24 * time.Hour).Unix() }
+	profiles := []gonetworkmanager.KnownWifiProfile{
+		{Name: "Home", UUID: "u-home", SSID: "Home", Timestamp: days(1)},
+		{Name: "Hotel", UUID: "u-hotel", SSID: "Hotel", Timestamp: days(200)},
+		{Name: "Expo", UUID: "u-expo", SSID: "Expo"},
+		{Name: "Home 1", UUID: "u-home1", SSID: "Home", Timestamp: days(10)},
+		{Name: "Office", UUID: "u-office", SSID: "Office", Device: "wlan0"},
+		{Name: "Office 1", UUID: "u-office1", SSID: "Office", Timestamp: days(2)},
+	}
+
+	got := findStaleProfiles(profiles, 90*24*time.Hour, now)
+	var names []string
+	for _, c := range got {
+		names = append(names, c.Profile.Name+": "+c.reasons(now))
+	}
+	want := []string{
+		"Expo: never connected",
+		"Hotel: unused for 200 days",
+		"Home 1: duplicate of Home",
+		"Office 1: duplicate of Office",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected candidates:\n%s", strings.Join(names, "\n"))
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "d", "-3d", "soon"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("parseAge(%q) should fail", in)
+		}
+	}
+}
+
+const cleanupFakeNmcli = `
+case "$*" in
+  "-m multiline -f NAME,UUID,TYPE,DEVICE,AUTOCONNECT,AUTOCONNECT-PRIORITY,TIMESTAMP connection show --order name")
+    now=$(date +%s)
+    printf 'NAME: Home\nUUID: u-home\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: %s\n' "$now"
+    printf 'NAME: Hotel\nUUID: u-hotel\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 1000000000\n'
+    printf 'NAME: Expo\nUUID: u-expo\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 0\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless.ssid connection show "*)
+    printf 'connection.uuid: u-home\n802-11-wireless.ssid: Home\nconnection.uuid: u-hotel\n802-11-wireless.ssid: Hotel\nconnection.uuid: u-expo\n802-11-wireless.ssid: Expo\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestProfilePruneCLI(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", cleanupFakeNmcli)
+
+	var out, errOut bytes.Buffer
+	if code := runProfileCLI([]string{"prune", "--older-than", "90d", "--never-used", "--dry-run"}, nil, &out, &errOut); code != 0 {
+		t.Fatalf("dry run exit %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "Hotel (unused for") || !strings.Contains(out.String(), "Expo (never connected)") ||
+		strings.Contains(out.String(), "Home") || !strings.Contains(out.String(), "2 profile(s) to delete") {
+		t.Fatalf("unexpected dry run output:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(state, "calls")); err == nil {
+		t.Fatalf("dry run must not delete anything")
+	}
+
+	out.Reset()
+	if code := runProfileCLI([]string{"prune"}, strings.NewReader("n\n"), &out, &errOut); code != 1 || !strings.Contains(out.String(), "Aborted") {
+		t.Fatalf("declining should abort, got %d:\n%s", code, out.String())
+	}
+	out.Reset()
+	if code := runProfileCLI([]string{"prune"}, strings.NewReader("y\n"), &out, &errOut); code != 0 {
+		t.Fatalf("prune exit %d: %s\n%s", code, errOut.String(), out.String())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if string(calls) != "connection delete u-hotel\n" {
+		t.Fatalf("only the stale profile should be deleted, got:\n%s", calls)
+	}
+
+	if code := runProfileCLI([]string{"prune", "--older-than", "soon"}, nil, &out, &errOut); code != 2 {
+		t.Fatalf("a bad age should be a usage error, got %d", code)
+	}
+}
+
+func TestCleanupScreenFlow(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", cleanupFakeNmcli)
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	m2 := updated.(model)
+	if m2.state != viewCleanup || cmd == nil {
+		t.Fatalf("expected C to open the cleanup view")
+	}
+	updated, _ = m2.Update(scanStaleProfilesCmd(m2.cleanup.age)())
+	m2 = updated.(model)
+	if len(m2.cleanup.candidates) != 2 || !strings.Contains(m2.View(), "[ ] Hotel") {
+		t.Fatalf("expected two candidates:\n%s", m2.View())
+	}
+
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if m2.cleanup.confirming {
+		t.Fatalf("nothing selected, nothing to confirm")
+	}
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m2 = updated.(model)
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if !m2.cleanup.confirming || !strings.Contains(m2.connectionStatusMsg, "Delete 1 profile(s)?") {
+		t.Fatalf("expected a confirmation, status %q", m2.connectionStatusMsg)
+	}
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m2 = updated.(model)
+	updated, _ = m2.Update(cmd().(tea.BatchMsg)[0]())
+	m2 = updated.(model)
+	if !strings.Contains(m2.connectionStatusMsg, "Deleted 1 profile(s)") {
+		t.Fatalf("expected a summary, got %q", m2.connectionStatusMsg)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if string(calls) != "connection delete u-expo\n" {
+		t.Fatalf("unexpected deletes:\n%s", calls)
+	}
+}
@@ -0,0 +1,603 @@
+// nmtui/cmd/config.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+const configFileName = "config.toml"
+
+// Sort orders for the network list.
+const (
+	sortBySignal = "signal"
+	sortByName   = "name"
+)
+
+// appConfig is the effective user configuration. Every setting is resolved
+// as flags > environment > config file > built-in default.
+type appConfig struct {
+	UpdateCheck      bool
+	UpdatePrerelease bool
+	UpdateKeepBackup bool
+	Debug            bool
+	LogFile          string
+	ListWidth        int
+	DetailPane       bool
+	NmcliTimeout     time.Duration
+	ScanOnStart      bool
+	ShowHidden       bool
+	SortOrder        string
+	RescanInterval   time.Duration
+	ClipboardClear   time.Duration
+	SecretCommand    string
+	SecretAgentOwned bool
+	Watchdog         bool
+	WatchdogInterval time.Duration
+	WatchdogGrace    time.Duration
+	DiagDNSName      string
+	DiagDNSPort      string
+	DiagTCPEndpoint  string
+	DiagMTUTarget    string
+	DiagTimeout      time.Duration
+	Theme            string
+	Colors           map[string]string // per-role overrides of the theme
+	Keymap           string
+	Keys             map[string][]string // per-action overrides of the keymap
+
+	path    string            // config file consulted
+	sources map[string]string // setting key -> where its value came from
+}
+
+func defaultAppConfig() appConfig {
+	return appConfig{
+		UpdateCheck:      true,
+		UpdateKeepBackup: true,
+		LogFile:          debugLogFile,
+		ListWidth:        100,
+		DetailPane:       true,
+		NmcliTimeout:     45 * time.Second,
+		ScanOnStart:      true,
+		SortOrder:        sortBySignal,
+		ClipboardClear:   30 * time.Second,
+		WatchdogInterval: watchdogDefaultInterval,
+		WatchdogGrace:    watchdogDefaultGrace,
+		DiagDNSName:      diagDefaultDNSName,
+		DiagDNSPort:      diagDefaultDNSPort,
+		DiagTCPEndpoint:  diagDefaultTCPEndpoint,
+		DiagMTUTarget:    diagDefaultMTUTarget,
+		DiagTimeout:      diagDefaultTimeout,
+		Theme:            defaultThemeName,
+		Colors:           make(map[string]string),
+		Keymap:           defaultKeymapName,
+		Keys:             make(map[string][]string),
+		sources:          make(map[string]string),
+	}
+}
+
+// configSetting describes one key of config.toml and the environment
+// variable that overrides it.
+type configSetting struct {
+	key  string
+	env  string
+	help string
+	set  func(c *appConfig, v string) error
+	get  func(c *appConfig) string
+	// fromEnv maps the variable's historical values onto config values;
+	// nil means the value is used as is.
+	fromEnv func(v string) string
+}
+
+func boolSetting(key, env, help string, field func(*appConfig) *bool, fromEnv func(string) string) configSetting {
+	return configSetting{key: key, env: env, help: help, fromEnv: fromEnv,
+		set: func(c *appConfig, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%q is not true or false", v)
+			}
+			*field(c) = b
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.FormatBool(*field(c)) },
+	}
+}
+
+func durationSetting(key, env, help string, min time.Duration, field func(*appConfig) *time.Duration) configSetting {
+	return configSetting{key: key, env: env, help: help,
+		set: func(c *appConfig, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < min {
+				return fmt.Errorf("%q is not a duration of at least %s (e.g. \"30s\")", v, min)
+			}
+			*field(c) = d
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(field(c).String()) },
+	}
+}
+
+// stringSetting is a plain string value; check, when set, rejects invalid
+// ones.
+func stringSetting(key, help string, field func(*appConfig) *string, check func(string) error) configSetting {
+	return configSetting{key: key, help: help,
+		set: func(c *appConfig, v string) error {
+			v = strings.TrimSpace(v)
+			if check != nil {
+				if err := check(v); err != nil {
+					return err
+				}
+			}
+			*field(c) = v
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(*field(c)) },
+	}
+}
+
+// configSettings lists every setting in the order `config show` prints them.
+var configSettings = []configSetting{
+	boolSetting("update_check", "NMTUI_NO_UPDATE_CHECK", "check for a newer release on startup",
+		func(c *appConfig) *bool { return &c.UpdateCheck },
+		func(v string) string { return strconv.FormatBool(v != "1") }),
+	boolSetting("update_prerelease", "NMTUI_UPDATE_PRERELEASE", "include pre-releases in update checks",
+		func(c *appConfig) *bool { return &c.UpdatePrerelease },
+		func(v string) string { return strconv.FormatBool(v == "1") }),
+	boolSetting("update_keep_backup", "NMTUI_UPDATE_KEEP_BACKUP", "keep the old binary as .old after updating",
+		func(c *appConfig) *bool { return &c.UpdateKeepBackup },
+		func(v string) string { return strconv.FormatBool(v != "0" && strings.ToLower(v) != "false") }),
+	boolSetting("debug", "DEBUG_TEA", "write a debug log",
+		func(c *appConfig) *bool { return &c.Debug },
+		func(string) string { return "true" }),
+	{key: "log_file", env: "NMTUI_LOG_FILE", help: "debug log location",
+		set: func(c *appConfig, v string) error {
+			if strings.TrimSpace(v) == "" {
+				return errors.New("must not be empty")
+			}
+			c.LogFile = expandHome(v)
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(c.LogFile) },
+	},
+	{key: "network_list_width", help: "maximum width of the network list in columns (0 = no limit)",
+		set: func(c *appConfig, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%q is not a width (0 or more columns)", v)
+			}
+			c.ListWidth = n
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Itoa(c.ListWidth) },
+	},
+	boolSetting("detail_pane", "", "show the highlighted network's details beside the list on wide terminals",
+		func(c *appConfig) *bool { return &c.DetailPane }, nil),
+	durationSetting("nmcli_timeout", "NMTUI_NMCLI_TIMEOUT", "how long a single nmcli call may take", time.Second,
+		func(c *appConfig) *time.Duration { return &c.NmcliTimeout }),
+	boolSetting("scan_on_start", "", "trigger a fresh Wi-Fi scan on startup (false lists NetworkManager's last results)",
+		func(c *appConfig) *bool { return &c.ScanOnStart }, nil),
+	boolSetting("show_hidden", "", "show unnamed networks by default",
+		func(c *appConfig) *bool { return &c.ShowHidden }, nil),
+	{key: "sort_order", help: "network list order after active/known networks: signal or name",
+		set: func(c *appConfig, v string) error {
+			switch v = strings.ToLower(strings.TrimSpace(v)); v {
+			case sortBySignal, sortByName:
+				c.SortOrder = v
+				return nil
+			}
+			return fmt.Errorf("%q is not signal or name", v)
+		},
+		get: func(c *appConfig) string { return strconv.Quote(c.SortOrder) },
+	},
+	durationSetting("rescan_interval", "", "rescan automatically while the network list is shown (\"0s\" = off)", 0,
+		func(c *appConfig) *time.Duration { return &c.RescanInterval }),
+	durationSetting("clipboard_clear", "", "clear a copied password from the clipboard after this long (\"0s\" = never)", 0,
+		func(c *appConfig) *time.Duration { return &c.ClipboardClear }),
+	{key: "secret_command", env: "NMTUI_SECRET_COMMAND", help: "command printing a network's password, {ssid} is replaced (e.g. \"pass show wifi/{ssid}\")",
+		set: func(c *appConfig, v string) error {
+			c.SecretCommand = strings.TrimSpace(v)
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(c.SecretCommand) },
+	},
+	boolSetting("secret_agent_owned", "", "save Wi-Fi passwords as agent-owned (psk-flags=1) instead of in NetworkManager's profiles",
+		func(c *appConfig) *bool { return &c.SecretAgentOwned }, nil),
+	boolSetting("watchdog", "NMTUI_WATCHDOG", "start the TUI with the connection watchdog enabled",
+		func(c *appConfig) *bool { return &c.Watchdog },
+		func(v string) string { return strconv.FormatBool(v == "1") }),
+	durationSetting("watchdog_interval", "NMTUI_WATCHDOG_INTERVAL", "watchdog sampling interval (TUI and watch)", time.Second,
+		func(c *appConfig) *time.Duration { return &c.WatchdogInterval }),
+	durationSetting("watchdog_grace", "NMTUI_WATCHDOG_GRACE", "how long limited/none connectivity is tolerated", 0,
+		func(c *appConfig) *time.Duration { return &c.WatchdogGrace }),
+	stringSetting("diagnose_dns_name", "hostname the diagnostics resolve through each DNS server",
+		func(c *appConfig) *string { return &c.DiagDNSName },
+		func(v string) error {
+			if v == "" {
+				return errors.New("the hostname must not be empty")
+			}
+			return nil
+		}),
+	stringSetting("diagnose_dns_port", "port the diagnostics query DNS servers on",
+		func(c *appConfig) *string { return &c.DiagDNSPort },
+		func(v string) error {
+			if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("%q is not a port (1-65535)", v)
+			}
+			return nil
+		}),
+	stringSetting("diagnose_tcp", "host:port for the diagnostics' TCP reachability check (\"\" = skip)",
+		func(c *appConfig) *string { return &c.DiagTCPEndpoint },
+		func(v string) error {
+			if v == "" {
+				return nil
+			}
+			if host, port, err := net.SplitHostPort(v); err != nil || host == "" || port == "" {
+				return fmt.Errorf("%q is not host:port", v)
+			}
+			return nil
+		}),
+	stringSetting("diagnose_mtu_target", "host for the diagnostics' MTU probe (\"\" = the gateway)",
+		func(c *appConfig) *string { return &c.DiagMTUTarget }, nil),
+	durationSetting("diagnose_timeout", "", "timeout of each diagnostics check", 100*time.Millisecond,
+		func(c *appConfig) *time.Duration { return &c.DiagTimeout }),
+	{key: "theme", env: "NMTUI_THEME", help: "color theme: " + strings.Join(themeNames(), ", ") + " (NO_COLOR selects monochrome)",
+		set: func(c *appConfig, v string) error {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if _, ok := themes[v]; !ok {
+				return fmt.Errorf("%q is not one of %s", v, strings.Join(themeNames(), ", "))
+			}
+			c.Theme = v
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(c.Theme) },
+	},
+	{key: "keymap", env: "NMTUI_KEYMAP", help: "key binding preset: " + strings.Join(keyPresetNames(), ", "),
+		set: func(c *appConfig, v string) error {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if _, ok := keyPresets[v]; !ok {
+				return fmt.Errorf("%q is not one of %s", v, strings.Join(keyPresetNames(), ", "))
+			}
+			c.Keymap = v
+			return nil
+		},
+		get: func(c *appConfig) string { return strconv.Quote(c.Keymap) },
+	},
+}
+
+// colorSetting is the [colors] override of one theme role, written
+// "colors.success" in --set.
+func colorSetting(role string) configSetting {
+	return configSetting{key: "colors." + role,
+		set: func(c *appConfig, v string) error {
+			color, err := parseThemeColor(v)
+			if err != nil {
+				return err
+			}
+			c.Colors[role] = color
+			return nil
+		},
+		get: func(c *appConfig) string {
+			if c.Colors[role] == "" {
+				return strconv.Quote("none")
+			}
+			return strconv.Quote(c.Colors[role])
+		},
+	}
+}
+
+func findConfigSetting(key string) (configSetting, bool) {
+	if role, ok := strings.CutPrefix(key, "colors."); ok && isThemeRole(role) {
+		return colorSetting(role), true
+	}
+	if name, ok := strings.CutPrefix(key, "keys."); ok {
+		if a, ok := findKeyAction(name); ok {
+			return keySetting(a), true
+		}
+	}
+	for _, s := range configSettings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return configSetting{}, false
+}
+
+// expandHome turns a leading "~/" into the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// Set by the global --config and --set options, and by the --update flags.
+var (
+	configPathOverride string
+	configFlags        []string // "key=value", later entries win
+)
+
+func configPath() (string, error) {
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
+	dir, err := appConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// loadAppConfig resolves the effective configuration. It always returns a
+// usable config: a broken file or flag is reported as an error and skipped.
+func loadAppConfig() (appConfig, error) {
+	c := defaultAppConfig()
+	var errs []error
+
+	path, err := configPath()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	c.path = path
+	if path != "" {
+		if err := c.applyFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range configSettings {
+		v := os.Getenv(s.env)
+		if s.env == "" || v == "" {
+			continue
+		}
+		if s.fromEnv != nil {
+			v = s.fromEnv(v)
+		}
+		if err := s.set(&c, v); err != nil {
+			log.Printf("Config: ignoring %s: %v", s.env, err)
+			continue
+		}
+		c.sources[s.key] = "env " + s.env
+	}
+	// NO_COLOR (no-color.org) yields to an explicitly configured theme.
+	if os.Getenv("NO_COLOR") != "" && c.sources["theme"] == "" {
+		c.Theme = "monochrome"
+		c.sources["theme"] = "env NO_COLOR"
+	}
+
+	for _, kv := range configFlags {
+		k, v, _ := strings.Cut(kv, "=")
+		s, ok := findConfigSetting(strings.TrimSpace(k))
+		if !ok {
+			errs = append(errs, fmt.Errorf("--set: unknown setting %q", k))
+			continue
+		}
+		if err := s.set(&c, strings.TrimSpace(v)); err != nil {
+			errs = append(errs, fmt.Errorf("--set %s: %w", s.key, err))
+			continue
+		}
+		c.sources[s.key] = "flag"
+	}
+
+	if _, err := buildKeyMap(c.Keymap, c.Keys); err != nil {
+		errs = append(errs, fmt.Errorf("keys: %w", err))
+	}
+	return c, errors.Join(errs...)
+}
+
+func (c *appConfig) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if table, ok := raw[k].(map[string]any); ok && (k == "colors" || k == "keys") {
+			apply := c.applyColors
+			if k == "keys" {
+				apply = c.applyKeys
+			}
+			if err := apply(path, table); err != nil {
+				return err
+			}
+			continue
+		}
+		s, ok := findConfigSetting(k)
+		if !ok {
+			return fmt.Errorf("%s: unknown key %q", path, k)
+		}
+		var v string
+		switch val := raw[k].(type) {
+		case string:
+			v = val
+		case bool:
+			v = strconv.FormatBool(val)
+		case int64:
+			v = strconv.FormatInt(val, 10)
+		default:
+			return fmt.Errorf("%s: %s: unsupported value %v", path, k, val)
+		}
+		if err := s.set(c, v); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, k, err)
+		}
+		c.sources[k] = "file"
+	}
+	return nil
+}
+
+// applyColors reads the [colors] table of role overrides.
+func (c *appConfig) applyColors(path string, table map[string]any) error {
+	roles := make([]string, 0, len(table))
+	for role := range table {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		s, ok := findConfigSetting("colors." + role)
+		if !ok {
+			return fmt.Errorf("%s: [colors]: unknown role %q (use %s)", path, role, strings.Join(themeRoles, ", "))
+		}
+		v, ok := table[role].(string)
+		if !ok {
+			return fmt.Errorf("%s: colors.%s: color must be a string such as \"10\" or \"#00ff00\"", path, role)
+		}
+		if err := s.set(c, v); err != nil {
+			return fmt.Errorf("%s: colors.%s: %w", path, role, err)
+		}
+		c.sources[s.key] = "file"
+	}
+	return nil
+}
+
+// currentConfig is loadAppConfig for callers that cannot report errors;
+// main has already complained about them at startup.
+func currentConfig() appConfig {
+	c, err := loadAppConfig()
+	if err != nil {
+		log.Printf("Config: %v", err)
+	}
+	return c
+}
+
+// parseGlobalOptions strips the leading --config FILE and --set KEY=VALUE
+// options, which apply to the TUI and every subcommand.
+func parseGlobalOptions(args []string) ([]string, error) {
+	for len(args) > 0 {
+		opt, val, hasVal := strings.Cut(args[0], "=")
+		if opt != "--config" && opt != "--set" {
+			return args, nil
+		}
+		if opt == "--set" && hasVal {
+			// "--set=key=value"
+			val, hasVal = strings.CutPrefix(args[0], "--set=")
+		}
+		args = args[1:]
+		if !hasVal {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s needs a value", opt)
+			}
+			val, args = args[0], args[1:]
+		}
+		if opt == "--config" {
+			configPathOverride = expandHome(val)
+			continue
+		}
+		if !strings.Contains(val, "=") {
+			return nil, fmt.Errorf("--set expects KEY=VALUE, got %q", val)
+		}
+		configFlags = append(configFlags, val)
+	}
+	return args, nil
+}
+
+// applyProcessConfig pushes settings that live outside the model into
+// their package-level homes.
+func applyProcessConfig(c appConfig) {
+	gonetworkmanager.NmcliCommandTimeout = c.NmcliTimeout
+	networkListFixedWidth = c.ListWidth
+	detailPaneEnabled = c.DetailPane
+	if err := applyTheme(c.Theme, c.Colors); err != nil {
+		log.Printf("Config: %v", err)
+	}
+}
+
+// runConfigCLI implements `nmtui-go config [show | path]`.
+func runConfigCLI(args []string, stdout, stderr io.Writer) int {
+	sub := "show"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	c, err := loadAppConfig()
+	switch sub {
+	case "show":
+		printAppConfig(stdout, c)
+	case "path":
+		fmt.Fprintln(stdout, c.path)
+	default:
+		fmt.Fprintf(stderr, "Unknown config command %q (use show or path)\n", sub)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printAppConfig prints the effective configuration as TOML, annotated
+// with where each value came from, so it can be saved as a config file.
+func printAppConfig(w io.Writer, c appConfig) {
+	note := "not found, using defaults"
+	if _, err := os.Stat(c.path); err == nil {
+		note = "exists"
+	}
+	fmt.Fprintf(w, "# %s (%s)\n", c.path, note)
+	for _, s := range configSettings {
+		source := c.sources[s.key]
+		if source == "" {
+			source = "default"
+		}
+		fmt.Fprintf(w, "\n# %s\n%s = %s  # %s\n", s.help, s.key, s.get(&c), source)
+	}
+	fmt.Fprintf(w, "\n# per-role color overrides (ANSI 0-255, #rrggbb or none): %s\n[colors]\n", strings.Join(themeRoles, ", "))
+	for _, role := range themeRoles {
+		if _, ok := c.Colors[role]; ok {
+			s := colorSetting(role)
+			fmt.Fprintf(w, "%s = %s  # %s\n", role, s.get(&c), c.sources[s.key])
+		}
+	}
+	fmt.Fprintf(w, "\n# per-action key overrides, e.g. refresh = [\"r\", \"ctrl+r\"]; actions: %s\n[keys]\n", strings.Join(keyActionNames(), ", "))
+	for _, a := range keyActions {
+		if _, ok := c.Keys[a.name]; ok {
+			s := keySetting(a)
+			fmt.Fprintf(w, "%s = %s  # %s\n", a.name, s.get(&c), c.sources[s.key])
+		}
+	}
+}
+
+// --- Periodic rescan ---
+
+type rescanTickMsg struct{}
+
+func rescanTickCmd(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return rescanTickMsg{} })
+}
+
+// handleRescanTick rescans when the network list is idle on screen, so an
+// automatic scan never interrupts typing a filter or another action.
+func (m *model) handleRescanTick() []tea.Cmd {
+	cmds := []tea.Cmd{rescanTickCmd(m.rescanInterval)}
+	if m.state != viewNetworksList || m.isLoading || m.isScanning || m.isFiltering {
+		return cmds
+	}
+	m.isScanning = true
+	return append(cmds, fetchKnownNetworksCmd(), fetchWifiNetworksCmd(true))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nmtui/gonetworkmanager"
+)
+
+// writeAppConfig points XDG_CONFIG_HOME at a temp dir holding config.toml
+// and resets the global option state afterwards.
+func writeAppConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "nmtui-go", configFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldPath, oldFlags := configPathOverride, configFlags
+	t.Cleanup(func() { configPathOverride, configFlags = oldPath, oldFlags })
+	return path
+}
+
+func TestAppConfigPrecedence(t *testing.T) {
+	writeAppConfig(t, `
+show_hidden = true
+sort_order = "name"
+nmcli_timeout = "10s"
+watchdog_interval = "20s"
+update_check = true
+network_list_width = 0
+`)
+	t.Setenv("NMTUI_WATCHDOG_INTERVAL", "30s")
+	t.Setenv("NMTUI_NO_UPDATE_CHECK", "1")
+	t.Setenv("NMTUI_UPDATE_PRERELEASE", "")
+	configFlags = []string{"watchdog_interval=40s"}
+
+	c, err := loadAppConfig()
+	if err != nil {
+		t.Fatalf("loadAppConfig: %v", err)
+	}
+	if !c.ShowHidden || c.SortOrder != sortByName || c.NmcliTimeout != 10*time.Second || c.ListWidth != 0 {
+		t.Fatalf("file values not applied: %+v", c)
+	}
+	if c.UpdateCheck || c.sources["update_check"] != "env NMTUI_NO_UPDATE_CHECK" {
+		t.Fatalf("env should override the file: %v (%s)", c.UpdateCheck, c.sources["update_check"])
+	}
+	if c.WatchdogInterval != 40*time.Second || c.sources["watchdog_interval"] != "flag" {
+		t.Fatalf("flags should override env: %s (%s)", c.WatchdogInterval, c.sources["watchdog_interval"])
+	}
+	if c.WatchdogGrace != watchdogDefaultGrace || c.LogFile != debugLogFile {
+		t.Fatalf("defaults should remain: %+v", c)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runConfigCLI([]string{"show"}, &out, &errOut); code != 0 {
+		t.Fatalf("config show failed: %s", errOut.String())
+	}
+	for _, want := range []string{
+		`show_hidden = true  # file`,
+		`update_check = false  # env NMTUI_NO_UPDATE_CHECK`,
+		`watchdog_interval = "40s"  # flag`,
+		`watchdog_grace = "1m0s"  # default`,
+		`(exists)`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("config show lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	// The output is itself a valid config file.
+	saved := filepath.Join(t.TempDir(), "saved.toml")
+	if err := os.WriteFile(saved, out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPathOverride, configFlags = saved, nil
+	os.Unsetenv("NMTUI_WATCHDOG_INTERVAL")
+	os.Unsetenv("NMTUI_NO_UPDATE_CHECK")
+	again, err := loadAppConfig()
+	if err != nil || again.WatchdogInterval != 40*time.Second || again.UpdateCheck {
+		t.Fatalf("config show output does not round-trip: %+v %v", again, err)
+	}
+}
+
+func TestAppConfigErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		`colour = "red"`:          `unknown key "colour"`,
+		`sort_order = "random"`:   `sort_order: "random" is not signal or name`,
+		`nmcli_timeout = "100ms"`: `nmcli_timeout`,
+		`show_hidden = "maybe"`:   `show_hidden`,
+		`show_hidden = [true]`:    `unsupported value`,
+	} {
+		writeAppConfig(t, content)
+		if _, err := loadAppConfig(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error mentioning %q, got %v", content, want, err)
+		}
+	}
+
+	writeAppConfig(t, "")
+	configFlags = []string{"nope=1"}
+	c, err := loadAppConfig()
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "nope"`) || c.SortOrder != sortBySignal {
+		t.Fatalf("a bad --set should be reported and skipped: %v", err)
+	}
+}
+
+func TestParseGlobalOptions(t *testing.T) {
+	writeAppConfig(t, "")
+	rest, err := parseGlobalOptions([]string{"--config", "/tmp/x.toml", "--set", "sort_order=name", "--set=debug=true", "watch", "--interval", "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(rest, " ") != "watch --interval 5s" || configPathOverride != "/tmp/x.toml" || strings.Join(configFlags, ",") != "sort_order=name,debug=true" {
+		t.Fatalf("unexpected result %v %q %v", rest, configPathOverride, configFlags)
+	}
+	if _, err := parseGlobalOptions([]string{"--set", "debug"}); err == nil {
+		t.Fatalf("--set without = should fail")
+	}
+	if _, err := parseGlobalOptions([]string{"--config"}); err == nil {
+		t.Fatalf("--config without a value should fail")
+	}
+}
+
+func TestConfiguredListAndRescan(t *testing.T) {
+	writeAppConfig(t, "show_hidden = true\nsort_order = \"name\"\nrescan_interval = \"1m\"\nscan_on_start = false\n")
+	m := windowedModel(t)
+	if !m.showHiddenNetworks || m.rescanInterval != time.Minute || m.scanOnStart {
+		t.Fatalf("config not applied to the model: %+v", m.rescanInterval)
+	}
+	ap := func(ssid, signal string) wifiAP {
+		return wifiAP{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{gonetworkmanager.NmcliFieldWifiSSID: ssid, gonetworkmanager.NmcliFieldWifiSignal: signal}}
+	}
+	m.processAndSetWifiList([]wifiAP{ap("zeta", "90"), ap("Alpha", "20"), ap("", "50")})
+	var got []string
+	for _, item := range m.wifiList.Items() {
+		got = append(got, item.(wifiAP).getSSIDFromScannedAP())
+	}
+	if strings.Join(got, ",") != "Alpha,zeta," {
+		t.Fatalf("expected name order with the unnamed network shown last, got %q", got)
+	}
+
+	m.isLoading, m.isScanning = false, false
+	if cmds := m.handleRescanTick(); len(cmds) != 3 || !m.isScanning {
+		t.Fatalf("an idle list should rescan")
+	}
+	m.isScanning = false
+	m.state = viewKnownNetworksList
+	if cmds := m.handleRescanTick(); len(cmds) != 1 || m.isScanning {
+		t.Fatalf("other views only reschedule the tick")
+	}
+}
@@ -0,0 +1,171 @@
+// nmtui/cmd/detailpane.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	// detailPaneMinWidth is the narrowest detail pane worth showing; below
+	// it the lists keep the single-column layout.
+	detailPaneMinWidth = 40
+	detailPaneGap      = 2
+	// signalHistoryLen is how many scans of signal history are kept per SSID.
+	signalHistoryLen = 30
+)
+
+// detailPaneEnabled switches the two-pane layout; set from detail_pane.
+var detailPaneEnabled = true
+
+// recordSignalHistory appends the strongest signal of every SSID in a scan
+// to its history. SSIDs missing from the scan record 0, so the chart shows
+// a network going out of range.
+func (m *model) recordSignalHistory(aps []wifiAP) {
+	if m.signalHistory == nil {
+		m.signalHistory = make(map[string][]float64)
+	}
+	best := make(map[string]float64)
+	for _, ap := range aps {
+		ssid := ap.getSSIDFromScannedAP()
+		if ssid == "" || ssid == "--" {
+			continue
+		}
+		signal, _ := strconv.Atoi(ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSignal])
+		if s := float64(signal); s >= best[ssid] {
+			best[ssid] = s
+		}
+	}
+	for ssid := range m.signalHistory {
+		if _, ok := best[ssid]; !ok {
+			best[ssid] = 0
+		}
+	}
+	for ssid, signal := range best {
+		h := append(m.signalHistory[ssid], signal)
+		if len(h) > signalHistoryLen {
+			h = h[len(h)-signalHistoryLen:]
+		}
+		m.signalHistory[ssid] = h
+	}
+}
+
+// detailPaneWidth is the width left beside the list for the detail pane,
+// or 0 when the terminal is too narrow and the layout stays one column.
+func (m model) detailPaneWidth(avW int) int {
+	if !detailPaneEnabled {
+		return 0
+	}
+	w := avW - m.listDisplayWidth - detailPaneGap
+	if w < detailPaneMinWidth {
+		return 0
+	}
+	return w
+}
+
+// withDetailPane lays out a list screen: the list on the left and the
+// details of its highlighted item on the right, or the list centered on
+// its own when there is no room for both.
+func (m model) withDetailPane(avW, height int, listR string, l list.Model) string {
+	pw := m.detailPaneWidth(avW)
+	if pw == 0 {
+		return lipgloss.PlaceHorizontal(avW, lipgloss.Center, listR)
+	}
+	left := lipgloss.NewStyle().Width(m.listDisplayWidth + detailPaneGap).Render(listR)
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, m.detailPaneView(l.SelectedItem(), pw, height))
+}
+
+func (m model) detailPaneView(item list.Item, width, height int) string {
+	box := infoBoxStyle.Copy().MarginTop(0).Width(width - infoBoxStyle.GetHorizontalBorderSize())
+	faint := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	ap, ok := item.(wifiAP)
+	if !ok {
+		return box.Render(faint.Render("Nothing selected."))
+	}
+	label := func(s string) string { return faint.Render(fmt.Sprintf("%-13s", s)) }
+	ssid := ap.getSSIDFromScannedAP()
+	named := ssid != "" && ssid != "--"
+
+	lines := []string{titleStyle.Render(truncateRunes(ap.FilterValue(), width-8))}
+	switch {
+	case ap.IsActive:
+		lines = append(lines, successStyle.Render("Connected"))
+	case ap.IsKnown:
+		lines = append(lines, lipgloss.NewStyle().Foreground(ansAccentColor).Render("Saved profile"))
+	default:
+		lines = append(lines, faint.Render("Not saved"))
+	}
+
+	// Every access point of the network, strongest first.
+	var bssids []gonetworkmanager.WifiAccessPoint
+	for _, s := range m.allScannedAps {
+		if named && s.getSSIDFromScannedAP() == ssid ||
+			!named && s.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiBSSID] == ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiBSSID] {
+			bssids = append(bssids, s.WifiAccessPoint)
+		}
+	}
+	sort.SliceStable(bssids, func(i, j int) bool {
+		si, _ := strconv.Atoi(bssids[i][gonetworkmanager.NmcliFieldWifiSignal])
+		sj, _ := strconv.Atoi(bssids[j][gonetworkmanager.NmcliFieldWifiSignal])
+		return si > sj
+	})
+
+	security := ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSecurity]
+	if len(bssids) > 0 {
+		security = bssids[0][gonetworkmanager.NmcliFieldWifiSecurity]
+	}
+	if security == "" || security == "--" {
+		security = "Open"
+	}
+	lines = append(lines, "", label("Security:")+security)
+	if len(bssids) > 0 {
+		lines = append(lines, label("Channel:")+bssids[0][gonetworkmanager.NmcliFieldWifiChannel],
+			label("Signal:")+bssids[0][gonetworkmanager.NmcliFieldWifiSignal]+"%")
+	} else {
+		lines = append(lines, label("Signal:")+faint.Render("out of range"))
+	}
+	if h := m.signalHistory[ssid]; named && len(h) > 1 {
+		chart := h
+		if max := width - 8 - 13; len(chart) > max && max > 0 {
+			chart = chart[len(chart)-max:]
+		}
+		lines = append(lines, label("History:")+connectingStyle.Render(sparkline(chart)))
+	}
+
+	if len(bssids) > 0 {
+		lines = append(lines, "", faint.Render(fmt.Sprintf("Access points (%d):", len(bssids))))
+		for _, b := range bssids {
+			mark := "  "
+			if b[gonetworkmanager.NmcliFieldWifiInUse] == "*" {
+				mark = successStyle.Render("* ")
+			}
+			lines = append(lines, fmt.Sprintf("%s%s  ch %-3s %4s  %s", mark, b[gonetworkmanager.NmcliFieldWifiBSSID],
+				b[gonetworkmanager.NmcliFieldWifiChannel], b[gonetworkmanager.NmcliFieldWifiSignal]+"%",
+				faint.Render(b[gonetworkmanager.NmcliFieldWifiRate])))
+		}
+	}
+
+	if profile, ok := m.knownProfiles[ssid]; ok && named {
+		lines = append(lines, "", faint.Render("Saved profile:"),
+			label("Name:")+profile[gonetworkmanager.NmcliFieldConnectionName],
+			label("Autoconnect:")+profile[gonetworkmanager.NmcliFieldConnectionAutocon],
+			label("Priority:")+profile[gonetworkmanager.NmcliFieldConnectionPriority])
+		lines = append(lines, label("Metered:")+meteredLabel(profile[gonetworkmanager.NmcliFieldConnectionMetered]))
+		lastUsed := "never"
+		if ts, _ := strconv.ParseInt(profile[gonetworkmanager.NmcliFieldConnectionTime], 10, 64); ts > 0 {
+			lastUsed = time.Unix(ts, 0).Format("2006-01-02 15:04")
+		}
+		lines = append(lines, label("Last used:")+lastUsed)
+	}
+
+	content := lipgloss.NewStyle().MaxWidth(width - box.GetHorizontalFrameSize()).Render(strings.Join(lines, "\n"))
+	return lipgloss.NewStyle().MaxHeight(height).Render(box.Render(content))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestDetailPaneOnWideTerminals(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	m := windowedModel(t)
+	m.knownProfiles = map[string]gonetworkmanager.ConnectionProfile{"Home": {
+		gonetworkmanager.NmcliFieldConnectionName:     "Home",
+		gonetworkmanager.NmcliFieldConnectionUUID:     "u-home",
+		gonetworkmanager.NmcliFieldConnectionAutocon:  "yes",
+		gonetworkmanager.NmcliFieldConnectionPriority: "10",
+	}}
+	scan := func(homeSignal string) {
+		updated, _ := m.Update(wifiListLoadedMsg{allAps: []wifiAP{
+			surveyAP("Home", "AA:AA:AA:AA:AA:01", homeSignal, "6"),
+			surveyAP("Home", "AA:AA:AA:AA:AA:02", "35", "36"),
+			surveyAP("Cafe", "BB:BB:BB:BB:BB:01", "50", "11"),
+		}})
+		m = updated.(model)
+	}
+	scan("40")
+	scan("80")
+	if h := m.signalHistory["Home"]; len(h) != 2 || h[0] != 40 || h[1] != 80 {
+		t.Fatalf("unexpected signal history %v", h)
+	}
+
+	// 120 columns leave no room beside the 100-column list.
+	if strings.Contains(m.View(), "Access points") {
+		t.Fatalf("the pane should collapse on narrow terminals:\n%s", m.View())
+	}
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 180, Height: 40})
+	m = updated.(model)
+	v := ansi.Strip(m.View())
+	for _, want := range []string{"Access points (2):", "AA:AA:AA:AA:AA:01  ch 6    80%", "AA:AA:AA:AA:AA:02  ch 36   35%",
+		"Security:    WPA2", "Autoconnect: yes", "Priority:    10", "Metered:     auto", "Last used:   never", "History:     " + sparkline([]float64{40, 80})} {
+		if !strings.Contains(v, want) {
+			t.Errorf("pane lacks %q:\n%s", want, v)
+		}
+	}
+	// The list rows stay clickable, the pane beside them is not.
+	m = clickOn(t, m, "Cafe")
+	if m.wifiList.SelectedItem().(wifiAP).getSSIDFromScannedAP() != "Cafe" {
+		t.Fatalf("the click should select Cafe")
+	}
+	if v := ansi.Strip(m.View()); !strings.Contains(v, "Not saved") || strings.Contains(v, "Autoconnect:") {
+		t.Fatalf("the pane should follow the selection:\n%s", v)
+	}
+	m = clickOn(t, m, "Not saved") // level with the Home row
+	if m.wifiList.SelectedItem().(wifiAP).getSSIDFromScannedAP() != "Cafe" {
+		t.Fatalf("clicks in the pane must not change the selection")
+	}
+
+	detailPaneEnabled = false
+	t.Cleanup(func() { detailPaneEnabled = true })
+	if strings.Contains(m.View(), "Access points") {
+		t.Fatalf("detail_pane = false should keep one column")
+	}
+}
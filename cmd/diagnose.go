@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Constants ---
+
+const (
+	diagDefaultDNSName     = "example.com"
+	diagDefaultDNSPort     = "53"
+	diagDefaultTCPEndpoint = "1.1.1.1:443"
+	diagDefaultMTUTarget   = "1.1.1.1"
+	diagDefaultTimeout     = 3 * time.Second
+
+	// ICMP payload sizes for the MTU probe: payload + 28 bytes of IP/ICMP headers.
+	diagMTUMinPayload = 1252 // 1280, the IPv6 minimum link MTU
+	diagMTUMaxPayload = 1472 // 1500, standard Ethernet
+	diagICMPOverhead  = 28
+)
+
+// pingCommand is the ping binary used for the gateway and MTU checks.
+var pingCommand = "ping"
+
+var pingTimeRe = regexp.MustCompile(`time[=<]([0-9.]+ ?ms)`)
+
+// --- Types ---
+
+type diagStatus int
+
+const (
+	diagPass diagStatus = iota
+	diagFail
+	diagSkip
+)
+
+func (s diagStatus) String() string {
+	switch s {
+	case diagPass:
+		return "PASS"
+	case diagFail:
+		return "FAIL"
+	default:
+		return "SKIP"
+	}
+}
+
+// diagResult is the outcome of one diagnostic step with a human explanation.
+type diagResult struct {
+	Name   string
+	Status diagStatus
+	Detail string
+}
+
+// diagnosticsConfig holds the endpoints used by the checks. Every endpoint can be
+// overridden so the checks can run against local stand-ins.
+type diagnosticsConfig struct {
+	Device           string
+	DNSName          string
+	DNSPort          string
+	TCPEndpoint      string
+	MTUTarget        string
+	Timeout          time.Duration
+	SkipConnectivity bool
+}
+
+type diagnosticsReport struct {
+	Device     string
+	Connection string
+	Results    []diagResult
+}
+
+type diagnosticsResultMsg struct {
+	report diagnosticsReport
+	err    error
+}
+
+// defaultDiagnosticsConfig takes the endpoints and timeout from the user
+// configuration (the diagnose_* settings), so the TUI and the CLI agree.
+func defaultDiagnosticsConfig() diagnosticsConfig {
+	c := currentConfig()
+	return diagnosticsConfig{
+		DNSName:     c.DiagDNSName,
+		DNSPort:     c.DiagDNSPort,
+		TCPEndpoint: c.DiagTCPEndpoint,
+		MTUTarget:   c.DiagMTUTarget,
+		Timeout:     c.DiagTimeout,
+	}
+}
+
+func (r diagnosticsReport) counts() (pass, fail, skip int) {
+	for _, res := range r.Results {
+		switch res.Status {
+		case diagPass:
+			pass++
+		case diagFail:
+			fail++
+		default:
+			skip++
+		}
+	}
+	return
+}
+
+// --- Runner ---
+
+// runDiagnostics runs every check in order. Only a failure to find a device is
+// returned as an error; individual check failures are reported as results.
+func runDiagnostics(cfg diagnosticsConfig) (diagnosticsReport, error) {
+	var report diagnosticsReport
+	device := cfg.Device
+	if device == "" {
+		d, err := findConnectedDevice()
+		if err != nil {
+			return report, err
+		}
+		device = d
+	}
+	report.Device = device
+
+	detail, err := gonetworkmanager.GetDeviceInfoIPDetail(device)
+	if err != nil {
+		return report, fmt.Errorf("reading IP configuration for %s: %w", device, err)
+	}
+	if detail == nil {
+		return report, fmt.Errorf("device %s not found", device)
+	}
+	report.Connection = detail.Connection
+	log.Printf("Diagnose: running checks on %s (connection %q, gw %q, dns %v)", device, detail.Connection, detail.GatewayV4, detail.DNS)
+
+	report.Results = append(report.Results, checkGateway(detail.GatewayV4, cfg.Timeout))
+	report.Results = append(report.Results, checkDNSServers(detail.DNS, cfg)...)
+	if !cfg.SkipConnectivity {
+		report.Results = append(report.Results, checkConnectivity())
+	}
+	report.Results = append(report.Results, checkTCP(cfg.TCPEndpoint, cfg.Timeout))
+	mtuTarget := cfg.MTUTarget
+	if mtuTarget == "" {
+		mtuTarget = detail.GatewayV4
+	}
+	report.Results = append(report.Results, checkMTU(mtuTarget, cfg.Timeout))
+	return report, nil
+}
+
+// findConnectedDevice picks the first connected Wi-Fi device, then any connected device.
+func findConnectedDevice() (string, error) {
+	statuses, err := gonetworkmanager.DeviceStatus()
+	if err != nil {
+		return "", err
+	}
+	fallback := ""
+	for _, st := range statuses {
+		if !strings.HasPrefix(st.State, "connected") || st.Type == "loopback" {
+			continue
+		}
+		if st.Type == gonetworkmanager.ConnectionTypeWifi {
+			return st.Device, nil
+		}
+		if fallback == "" {
+			fallback = st.Device
+		}
+	}
+	if fallback == "" {
+		return "", fmt.Errorf("no connected network device found")
+	}
+	return fallback, nil
+}
+
+func runPing(timeout time.Duration, extra ...string) (string, error) {
+	secs := int(timeout / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	args := append([]string{"-n", "-c", "1", "-W", strconv.Itoa(secs)}, extra...)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+2*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, pingCommand, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+func checkGateway(gateway string, timeout time.Duration) diagResult {
+	res := diagResult{Name: "Gateway"}
+	if gateway == "" {
+		res.Status = diagFail
+		res.Detail = "No IPv4 gateway configured; DHCP may not have completed."
+		return res
+	}
+	out, err := runPing(timeout, gateway)
+	if err != nil {
+		res.Status = diagFail
+		res.Detail = fmt.Sprintf("Gateway %s did not answer ping (%v). The link may be up without working Layer 3, or the router blocks ICMP.", gateway, err)
+		return res
+	}
+	res.Status = diagPass
+	res.Detail = fmt.Sprintf("Gateway %s is reachable", gateway)
+	if m := pingTimeRe.FindStringSubmatch(out); m != nil {
+		res.Detail += fmt.Sprintf(" (%s)", m[1])
+	}
+	res.Detail += "."
+	return res
+}
+
+func checkDNSServers(servers []string, cfg diagnosticsConfig) []diagResult {
+	if len(servers) == 0 {
+		return []diagResult{{Name: "DNS", Status: diagFail, Detail: "No DNS servers configured on this connection."}}
+	}
+	var results []diagResult
+	for _, server := range servers {
+		results = append(results, checkDNS(server, cfg.DNSPort, cfg.DNSName, cfg.Timeout))
+	}
+	return results
+}
+
+// checkDNS resolves name by querying server directly, bypassing the system resolver.
+func checkDNS(server, port, name string, timeout time.Duration) diagResult {
+	res := diagResult{Name: "DNS " + server}
+	if port == "" {
+		port = diagDefaultDNSPort
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: timeout}
+			return d.DialContext(ctx, network, net.JoinHostPort(server, port))
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	addrs, err := resolver.LookupHost(ctx, name)
+	if err != nil {
+		res.Status = diagFail
+		res.Detail = fmt.Sprintf("Resolving %s via %s failed: %v", name, server, err)
+		return res
+	}
+	res.Status = diagPass
+	res.Detail = fmt.Sprintf("Resolved %s to %s in %s.", name, strings.Join(addrs, ", "), time.Since(start).Round(time.Millisecond))
+	return res
+}
+
+func checkConnectivity() diagResult {
+	res := diagResult{Name: "Connectivity"}
+	state, err := gonetworkmanager.GetNetworkConnectivityState(true)
+	if err != nil {
+		res.Status = diagFail
+		res.Detail = fmt.Sprintf("NetworkManager connectivity check failed: %v", err)
+		return res
+	}
+	state = strings.TrimSpace(state)
+	switch state {
+	case connectivityFull:
+		res.Status = diagPass
+		res.Detail = "NetworkManager reports full internet connectivity."
+	case connectivityPortal:
+		res.Status = diagFail
+		res.Detail = "A captive portal is intercepting traffic; log in through a browser."
+	case "limited":
+		res.Status = diagFail
+		res.Detail = "Connected to the network but the internet is not reachable."
+	case "none":
+		res.Status = diagFail
+		res.Detail = "NetworkManager reports no connectivity."
+	default:
+		res.Status = diagSkip
+		res.Detail = fmt.Sprintf("Connectivity state is %q (checking may be disabled in NetworkManager).", state)
+	}
+	return res
+}
+
+func checkTCP(endpoint string, timeout time.Duration) diagResult {
+	res := diagResult{Name: "TCP " + endpoint}
+	if endpoint == "" {
+		res.Status = diagSkip
+		res.Detail = "No TCP endpoint configured."
+		return res
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", endpoint, timeout)
+	if err != nil {
+		res.Status = diagFail
+		res.Detail = fmt.Sprintf("Could not open a TCP connection to %s: %v", endpoint, err)
+		return res
+	}
+	conn.Close()
+	res.Status = diagPass
+	res.Detail = fmt.Sprintf("Connected to %s in %s.", endpoint, time.Since(start).Round(time.Millisecond))
+	return res
+}
+
+// checkMTU binary-searches the largest unfragmented ICMP payload to target.
+func checkMTU(target string, timeout time.Duration) diagResult {
+	res := diagResult{Name: "MTU"}
+	if target == "" {
+		res.Status = diagSkip
+		res.Detail = "No MTU probe target available."
+		return res
+	}
+	probe := func(payload int) bool {
+		_, err := runPing(timeout, "-M", "do", "-s", strconv.Itoa(payload), target)
+		return err == nil
+	}
+	if !probe(diagMTUMinPayload) {
+		res.Status = diagFail
+		res.Detail = fmt.Sprintf("Even %d-byte packets to %s are dropped; check for a broken tunnel/VPN or ICMP filtering.", diagMTUMinPayload+diagICMPOverhead, target)
+		return res
+	}
+	lo, hi := diagMTUMinPayload, diagMTUMaxPayload
+	if probe(hi) {
+		lo = hi
+	}
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if probe(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	mtu := lo + diagICMPOverhead
+	res.Status = diagPass
+	if mtu < diagMTUMaxPayload+diagICMPOverhead {
+		res.Detail = fmt.Sprintf("Path MTU to %s is %d (below 1500; a tunnel, VPN or PPPoE link is in the path).", target, mtu)
+	} else {
+		res.Detail = fmt.Sprintf("Path MTU to %s is %d.", target, mtu)
+	}
+	return res
+}
+
+// --- Output ---
+
+func writeDiagnosticsReport(w io.Writer, report diagnosticsReport) {
+	title := "Diagnostics for " + report.Device
+	if report.Connection != "" {
+		title += fmt.Sprintf(" (%s)", report.Connection)
+	}
+	fmt.Fprintln(w, title)
+	for _, r := range report.Results {
+		fmt.Fprintf(w, "  [%s] %s: %s\n", r.Status, r.Name, r.Detail)
+	}
+	pass, fail, skip := report.counts()
+	fmt.Fprintf(w, "%d passed, %d failed, %d skipped\n", pass, fail, skip)
+}
+
+// --- CLI ---
+
+// runDiagnoseCLI is the entry point for the `diagnose` subcommand.
+func runDiagnoseCLI(args []string, stdout, stderr io.Writer) int {
+	cfg := defaultDiagnosticsConfig()
+	fs := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.Device, "device", "", "network device to diagnose (default: first connected device)")
+	fs.StringVar(&cfg.DNSName, "dns-name", cfg.DNSName, "hostname to resolve through each DNS server")
+	fs.StringVar(&cfg.DNSPort, "dns-port", cfg.DNSPort, "port used to query DNS servers")
+	fs.StringVar(&cfg.TCPEndpoint, "tcp", cfg.TCPEndpoint, "host:port for the TCP reachability check")
+	fs.StringVar(&cfg.MTUTarget, "mtu-target", cfg.MTUTarget, "host for the MTU probe (empty: gateway)")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "per-check timeout")
+	fs.BoolVar(&cfg.SkipConnectivity, "no-connectivity", false, "skip the NetworkManager connectivity check")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report, err := runDiagnostics(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	writeDiagnosticsReport(stdout, report)
+	if _, fail, _ := report.counts(); fail > 0 {
+		return 1
+	}
+	return 0
+}
+
+// --- TUI ---
+
+func runDiagnosticsCmd(device string) tea.Cmd {
+	return func() tea.Msg {
+		cfg := defaultDiagnosticsConfig()
+		cfg.Device = device
+		report, err := runDiagnostics(cfg)
+		if err != nil {
+			log.Printf("Cmd: Diagnostics error: %v", err)
+		}
+		return diagnosticsResultMsg{report: report, err: err}
+	}
+}
+
+func (m *model) startDiagnostics() tea.Cmd {
+	m.previousState = m.state
+	m.state = viewDiagnostics
+	m.isLoading = true
+	m.activeConnInfoViewport.SetContent("Running diagnostics...")
+	m.activeConnInfoViewport.GotoTop()
+	return tea.Batch(runDiagnosticsCmd(m.activeWifiDevice), m.spinner.Tick)
+}
+
+func renderDiagnosticsReport(report diagnosticsReport, err error, width int) string {
+	if err != nil {
+		return errorStyle.Render(fmt.Sprintf("Diagnostics could not run: %v", err))
+	}
+	var b strings.Builder
+	b.WriteString(listTitleStyle.Render(fmt.Sprintf("Diagnostics for %s", report.Device)))
+	b.WriteString("\n\n")
+	for _, r := range report.Results {
+		var badge string
+		switch r.Status {
+		case diagPass:
+			badge = lipgloss.NewStyle().Foreground(ansSuccessColor).Render("✔ PASS")
+		case diagFail:
+			badge = lipgloss.NewStyle().Foreground(ansErrorColor).Bold(true).Render("✘ FAIL")
+		default:
+			badge = lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("- SKIP")
+		}
+		detailStyle := lipgloss.NewStyle().Foreground(ansFaintTextColor).PaddingLeft(3)
+		if width > 10 {
+			detailStyle = detailStyle.Width(width)
+		}
+		fmt.Fprintf(&b, "%s  %s\n%s\n", badge, r.Name, detailStyle.Render(r.Detail))
+	}
+	pass, fail, skip := report.counts()
+	fmt.Fprintf(&b, "\n%d passed, %d failed, %d skipped", pass, fail, skip)
+	return b.String()
+}
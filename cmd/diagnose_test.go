@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// installFakeCommand writes an executable shell script named name into a temp
+// dir that is prepended to PATH, and returns its full path.
+func installFakeCommand(t *testing.T, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake commands use shell scripts (Linux/macOS only)")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return path
+}
+
+// startFakeDNS answers every A query with 127.0.0.9 and every other query with
+// an empty NOERROR response.
+func startFakeDNS(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			q := buf[:n]
+			if len(q) < 12 {
+				continue
+			}
+			// Find the end of the question name to read QTYPE.
+			i := 12
+			for i < len(q) && q[i] != 0 {
+				i += int(q[i]) + 1
+			}
+			if i+5 > len(q) {
+				continue
+			}
+			question := q[12 : i+5]
+			qtype := binary.BigEndian.Uint16(q[i+1 : i+3])
+
+			resp := make([]byte, 12)
+			copy(resp[0:2], q[0:2])
+			binary.BigEndian.PutUint16(resp[2:4], 0x8180)
+			binary.BigEndian.PutUint16(resp[4:6], 1)
+			if qtype == 1 {
+				binary.BigEndian.PutUint16(resp[6:8], 1)
+			}
+			resp = append(resp, question...)
+			if qtype == 1 {
+				resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 9)
+			}
+			pc.WriteTo(resp, addr)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+	return port
+}
+
+func TestCheckDNSAgainstLocalStandIn(t *testing.T) {
+	port := startFakeDNS(t)
+	res := checkDNS("127.0.0.1", port, "diag.test.", 2*time.Second)
+	if res.Status != diagPass {
+		t.Fatalf("expected DNS pass, got %v: %s", res.Status, res.Detail)
+	}
+	if !strings.Contains(res.Detail, "127.0.0.9") {
+		t.Fatalf("expected resolved address in detail, got %q", res.Detail)
+	}
+}
+
+func TestCheckTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	if res := checkTCP(addr, time.Second); res.Status != diagPass {
+		t.Fatalf("expected TCP pass, got %v: %s", res.Status, res.Detail)
+	}
+	ln.Close()
+	if res := checkTCP(addr, time.Second); res.Status != diagFail {
+		t.Fatalf("expected TCP fail after close, got %v", res.Status)
+	}
+	if res := checkTCP("", time.Second); res.Status != diagSkip {
+		t.Fatalf("expected skip without endpoint, got %v", res.Status)
+	}
+}
+
+func TestCheckGatewayAndMTUWithFakePing(t *testing.T) {
+	// Fails for the unreachable address and for payloads above 1372 (MTU 1400).
+	fake := installFakeCommand(t, "ping", `
+for a in "$@"; do last="$a"; done
+[ "$last" = "10.9.9.9" ] && exit 1
+prev=""
+for a in "$@"; do
+  if [ "$prev" = "-s" ] && [ "$a" -gt 1372 ]; then exit 1; fi
+  prev="$a"
+done
+echo "64 bytes from $last: icmp_seq=1 ttl=64 time=1.23 ms"
+exit 0
+`)
+	old := pingCommand
+	pingCommand = fake
+	t.Cleanup(func() { pingCommand = old })
+
+	if res := checkGateway("192.168.1.1", time.Second); res.Status != diagPass || !strings.Contains(res.Detail, "1.23 ms") {
+		t.Fatalf("expected gateway pass with rtt, got %v: %s", res.Status, res.Detail)
+	}
+	if res := checkGateway("10.9.9.9", time.Second); res.Status != diagFail {
+		t.Fatalf("expected gateway fail, got %v", res.Status)
+	}
+	if res := checkGateway("", time.Second); res.Status != diagFail {
+		t.Fatalf("expected fail without gateway, got %v", res.Status)
+	}
+
+	res := checkMTU("192.168.1.1", time.Second)
+	if res.Status != diagPass || !strings.Contains(res.Detail, "1400") {
+		t.Fatalf("expected MTU 1400, got %v: %s", res.Status, res.Detail)
+	}
+	if res := checkMTU("10.9.9.9", time.Second); res.Status != diagFail {
+		t.Fatalf("expected MTU fail for unreachable target, got %v", res.Status)
+	}
+}
+
+func TestRunDiagnoseCLIWithStandIns(t *testing.T) {
+	installFakeCommand(t, "nmcli", `
+case "$*" in
+  *"device show wlan0"*) printf 'GENERAL.DEVICE: wlan0\nGENERAL.TYPE: wifi\nGENERAL.STATE: 100 (connected)\nGENERAL.CONNECTION: Office\nIP4.GATEWAY: 192.168.1.1\nIP4.DNS[1]: 127.0.0.1\n' ;;
+  *"networking connectivity check"*) echo portal ;;
+  *) exit 9 ;;
+esac
+`)
+	fake := installFakeCommand(t, "ping", "echo 'time=0.5 ms'\nexit 0\n")
+	old := pingCommand
+	pingCommand = fake
+	t.Cleanup(func() { pingCommand = old })
+
+	port := startFakeDNS(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	var out, errOut bytes.Buffer
+	code := runDiagnoseCLI([]string{"--device", "wlan0", "--dns-name", "diag.test.", "--dns-port", port, "--tcp", ln.Addr().String(), "--mtu-target", "127.0.0.1", "--timeout", "2s"}, &out, &errOut)
+	if code != 1 {
+		t.Fatalf("expected exit 1 because of the captive portal, got %d (stderr %q)", code, errOut.String())
+	}
+	report := out.String()
+	for _, want := range []string{"Diagnostics for wlan0 (Office)", "[PASS] Gateway", "[PASS] DNS 127.0.0.1", "[FAIL] Connectivity", "captive portal", "[PASS] TCP", "[PASS] MTU", "4 passed, 1 failed"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestHandleCLIFlagsDiagnoseBadFlag(t *testing.T) {
+	var errOut bytes.Buffer
+	if code := runDiagnoseCLI([]string{"--bogus"}, &bytes.Buffer{}, &errOut); code != 2 {
+		t.Fatalf("expected exit 2 for bad flag, got %d", code)
+	}
+}
+
+func TestDiagnosticsViewShowsReport(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewNetworksList
+	m.isLoading = false
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	m2 := updated.(model)
+	if m2.state != viewDiagnostics || !m2.isLoading || cmd == nil {
+		t.Fatalf("expected diagnostics to start, got state %v loading %v", m2.state, m2.isLoading)
+	}
+
+	report := diagnosticsReport{Device: "wlan0", Results: []diagResult{
+		{Name: "Gateway", Status: diagPass, Detail: "reachable"},
+		{Name: "DNS 1.1.1.1", Status: diagFail, Detail: "timeout"},
+	}}
+	updated, _ = m2.Update(diagnosticsResultMsg{report: report})
+	m2 = updated.(model)
+	view := m2.View()
+	if m2.isLoading || !strings.Contains(view, "Diagnostics for wlan0") || !strings.Contains(view, "1 passed, 1 failed") {
+		t.Fatalf("diagnostics report not rendered:\n%s", view)
+	}
+
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if s := updated.(model).state; s != viewNetworksList {
+		t.Fatalf("expected esc to return to the network list, got %v", s)
+	}
+}
+
+func TestDiagnosticsConfigFromFile(t *testing.T) {
+	writeAppConfig(t, `
+diagnose_dns_name = "probe.example."
+diagnose_tcp = "192.0.2.1:80"
+diagnose_mtu_target = ""
+diagnose_timeout = "5s"
+`)
+	cfg := defaultDiagnosticsConfig()
+	if cfg.DNSName != "probe.example." || cfg.TCPEndpoint != "192.0.2.1:80" || cfg.MTUTarget != "" || cfg.Timeout != 5*time.Second {
+		t.Fatalf("config file not applied: %+v", cfg)
+	}
+	if cfg.DNSPort != diagDefaultDNSPort {
+		t.Fatalf("unset port should keep its default, got %q", cfg.DNSPort)
+	}
+
+	configFlags = []string{"diagnose_tcp="}
+	if cfg := defaultDiagnosticsConfig(); cfg.TCPEndpoint != "" {
+		t.Fatalf("an empty diagnose_tcp should turn the TCP check off, got %q", cfg.TCPEndpoint)
+	}
+	if res := checkTCP("", time.Second); res.Status != diagSkip {
+		t.Fatalf("expected the TCP check to be skipped, got %v", res.Status)
+	}
+
+	configFlags = []string{"diagnose_tcp=nonsense"}
+	if _, err := loadAppConfig(); err == nil || !strings.Contains(err.Error(), "host:port") {
+		t.Fatalf("expected a host:port error, got %v", err)
+	}
+}
@@ -0,0 +1,401 @@
+// nmtui/cmd/history.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	historyFileName = "history.json"
+	historyLimit    = 50
+	undoWindow      = 10 * time.Second
+)
+
+// appDataDir returns $XDG_DATA_HOME/nmtui-go (or ~/.local/share/nmtui-go),
+// creating it with owner-only permissions because it holds secrets.
+func appDataDir() (string, error) {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine data directory: %w", err)
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(base, "nmtui-go")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("cannot create data directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// historyEntry is a full snapshot of a profile, secrets included, taken just
+// before it was deleted or modified.
+type historyEntry struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Name      string    `json:"name"`
+	UUID      string    `json:"uuid"`
+	Type      string    `json:"type"`
+	Keyfile   string    `json:"keyfile"`
+}
+
+func (e historyEntry) describe() string {
+	verb := "deleted"
+	if e.Operation == gonetworkmanager.ProfileChangeModify {
+		verb = "modified"
+	}
+	return fmt.Sprintf("%s %s", e.Name, verb)
+}
+
+// historyMu serialises read-modify-write of the history file; snapshots are
+// taken from tea.Cmd goroutines.
+var historyMu sync.Mutex
+
+func historyPath() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFileName), nil
+}
+
+// loadHistory returns the snapshots, newest first.
+func loadHistory() ([]historyEntry, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return loadHistoryLocked()
+}
+
+func loadHistoryLocked() ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func saveHistoryLocked(entries []historyEntry) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// snapshotProfile records the current state of a profile in the history.
+func snapshotProfile(profileIdentifier, operation string) (historyEntry, error) {
+	kf, err := gonetworkmanager.ExportKeyfile(profileIdentifier, true)
+	if err != nil {
+		return historyEntry{}, err
+	}
+	now := time.Now()
+	entry := historyEntry{
+		ID:        strconv.FormatInt(now.UnixNano(), 36),
+		Time:      now,
+		Operation: operation,
+		Name:      kf.ID(),
+		UUID:      kf.UUID(),
+		Type:      kf.Type(),
+		Keyfile:   string(kf.Marshal()),
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	// As with usage data, an unreadable file is left alone rather than
+	// replaced: it holds every earlier snapshot.
+	entries, err := loadHistoryLocked()
+	if err != nil {
+		return historyEntry{}, err
+	}
+	entries = append([]historyEntry{entry}, entries...)
+	if len(entries) > historyLimit {
+		entries = entries[:historyLimit]
+	}
+	if err := saveHistoryLocked(entries); err != nil {
+		return historyEntry{}, err
+	}
+	log.Printf("History: snapshot of %q (%s) before %s", entry.Name, entry.UUID, operation)
+	return entry, nil
+}
+
+// installProfileSnapshots makes every delete/modify go through the history.
+func installProfileSnapshots() {
+	gonetworkmanager.BeforeProfileChange = func(profileIdentifier, operation string) error {
+		_, err := snapshotProfile(profileIdentifier, operation)
+		return err
+	}
+}
+
+// latestSnapshot finds the snapshot taken for profileIdentifier since t.
+func latestSnapshot(profileIdentifier string, since time.Time) *historyEntry {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if e.Time.Before(since) {
+			break
+		}
+		if e.UUID == profileIdentifier || e.Name == profileIdentifier {
+			return &e
+		}
+	}
+	return nil
+}
+
+// restoreSnapshot recreates the profile exactly as snapshotted, replacing the
+// current version if it still exists. The restore itself is not snapshotted.
+func restoreSnapshot(e historyEntry) error {
+	kf, err := gonetworkmanager.ParseKeyfile([]byte(e.Keyfile))
+	if err != nil {
+		return err
+	}
+	_, err = gonetworkmanager.RestoreKeyfile(kf)
+	if err == nil {
+		log.Printf("History: restored %q (%s) from %s", e.Name, e.UUID, e.Time.Format(time.RFC3339))
+	}
+	return err
+}
+
+// --- CLI ---
+
+func runHistoryCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	entries, err := loadHistory()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	switch fs.Arg(0) {
+	case "", "list":
+		if len(entries) == 0 {
+			fmt.Fprintln(stdout, "No profile history yet.")
+			return 0
+		}
+		for i, e := range entries {
+			fmt.Fprintf(stdout, "%3d  %s  %-6s  %s (%s)\n", i+1, e.Time.Local().Format("2006-01-02 15:04:05"), e.Operation, e.Name, canonicalConnType(e.Type))
+		}
+		return 0
+	case "restore":
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n < 1 || n > len(entries) {
+			fmt.Fprintf(stderr, "Usage: %s history restore N   (N from `%s history`)\n", effectiveAppName(), effectiveAppName())
+			return 2
+		}
+		e := entries[n-1]
+		if err := restoreSnapshot(e); err != nil {
+			fmt.Fprintf(stderr, "Error restoring %s: %v\n", e.Name, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Restored %s as of %s.\n", e.Name, e.Time.Local().Format("2006-01-02 15:04:05"))
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown history command: %s\n", fs.Arg(0))
+		return 2
+	}
+}
+
+// --- TUI ---
+
+type undoToast struct {
+	entry   historyEntry
+	expires time.Time
+}
+
+type undoExpiredMsg struct{ id string }
+
+type historyLoadedMsg struct {
+	entries []historyEntry
+	err     error
+}
+
+type snapshotRestoredMsg struct {
+	entry historyEntry
+	err   error
+}
+
+func loadHistoryCmd() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := loadHistory()
+		return historyLoadedMsg{entries: entries, err: err}
+	}
+}
+
+func restoreSnapshotCmd(e historyEntry) tea.Cmd {
+	return func() tea.Msg { return snapshotRestoredMsg{entry: e, err: restoreSnapshot(e)} }
+}
+
+// offerUndo shows the undo toast for a fresh snapshot.
+func (m *model) offerUndo(e *historyEntry) tea.Cmd {
+	if e == nil {
+		return nil
+	}
+	m.undo = &undoToast{entry: *e, expires: time.Now().Add(undoWindow)}
+	id := e.ID
+	return tea.Batch(m.relayoutCmd(), tea.Tick(undoWindow, func(time.Time) tea.Msg { return undoExpiredMsg{id: id} }))
+}
+
+func (m *model) handleUndoExpired(msg undoExpiredMsg) tea.Cmd {
+	if m.undo == nil || m.undo.entry.ID != msg.id {
+		return nil
+	}
+	m.undo = nil
+	return m.relayoutCmd()
+}
+
+// undoLast restores the snapshot behind the toast.
+func (m *model) undoLast() []tea.Cmd {
+	if m.undo == nil {
+		return nil
+	}
+	e := m.undo.entry
+	m.undo = nil
+	m.isLoading = true
+	m.setStatus(fmt.Sprintf("Restoring %s...", e.Name), connectingStyle)
+	return []tea.Cmd{restoreSnapshotCmd(e), m.relayoutCmd(), m.spinner.Tick}
+}
+
+func (m *model) handleSnapshotRestored(msg snapshotRestoredMsg) []tea.Cmd {
+	m.isLoading = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not restore %s: %v", msg.entry.Name, msg.err), errorStyle)
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Restored %s.", msg.entry.Name), successStyle)
+	cmds := []tea.Cmd{fetchKnownNetworksCmd()}
+	switch m.state {
+	case viewKnownNetworksList:
+		cmds = append(cmds, fetchKnownWifiApsCmd())
+	case viewHistory:
+		cmds = append(cmds, loadHistoryCmd())
+	default:
+		cmds = append(cmds, fetchWifiNetworksCmd(false))
+	}
+	return cmds
+}
+
+func (m model) undoToastView(width int) string {
+	if m.undo == nil {
+		return ""
+	}
+	text := fmt.Sprintf("↶ %s — press %s to undo", m.undo.entry.describe(), m.keys.Undo.Help().Key)
+	return lipgloss.NewStyle().Foreground(ansAccentColor).Bold(true).MaxWidth(width).Render(truncateRunes(text, width))
+}
+
+func (m *model) openHistory() []tea.Cmd {
+	m.previousState = m.state
+	m.state = viewHistory
+	m.historyEntries = nil
+	m.historyCursor = 0
+	m.isLoading = true
+	m.clearStatus()
+	return []tea.Cmd{loadHistoryCmd(), m.spinner.Tick}
+}
+
+func (m *model) handleHistoryKeys(msg tea.KeyMsg) []tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.state = viewKnownNetworksList
+		m.clearStatus()
+		m.resizeComponents()
+	case msg.String() == "up":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case msg.String() == "down":
+		if m.historyCursor < len(m.historyEntries)-1 {
+			m.historyCursor++
+		}
+	case key.Matches(msg, m.keys.Connect):
+		if m.isLoading || m.historyCursor >= len(m.historyEntries) {
+			return nil
+		}
+		e := m.historyEntries[m.historyCursor]
+		m.isLoading = true
+		m.setStatus(fmt.Sprintf("Restoring %s...", e.Name), connectingStyle)
+		return []tea.Cmd{restoreSnapshotCmd(e), m.spinner.Tick}
+	}
+	return nil
+}
+
+func (m model) historyView(width, height int) string {
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{titleStyle.Render("Profile History")}
+	if len(m.historyEntries) == 0 {
+		if m.isLoading {
+			lines = append(lines, label.Render("Loading..."))
+		} else {
+			lines = append(lines, label.Render("No deleted or modified profiles recorded yet."))
+		}
+	}
+	maxRows := height - 10
+	if maxRows < 3 {
+		maxRows = 3
+	}
+	start := 0
+	if m.historyCursor >= maxRows {
+		start = m.historyCursor - maxRows + 1
+	}
+	for i := start; i < len(m.historyEntries) && i < start+maxRows; i++ {
+		e := m.historyEntries[i]
+		op := lipgloss.NewStyle().Foreground(ansErrorColor).Render("deleted ")
+		if e.Operation == gonetworkmanager.ProfileChangeModify {
+			op = lipgloss.NewStyle().Foreground(ansAccentColor).Render("modified")
+		}
+		line := fmt.Sprintf("%s  %s  %s %s", label.Render(e.Time.Local().Format("Jan 02 15:04")), op,
+			truncateRunes(e.Name, 32), label.Render("("+canonicalConnType(e.Type)+")"))
+		if i == m.historyCursor {
+			line = listSelectedItemStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", label.Render(fmt.Sprintf("%s: restore this version  %s: back", m.keys.Connect.Help().Key, m.keys.Back.Help().Key)))
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+const historyFakeNmcli = `
+echo "$*" >> "$STATE/calls"
+case "$*" in
+  "-s -m multiline connection show u-corp"|"-s -m multiline connection show Corp")
+    printf 'connection.id: Corp\nconnection.uuid: u-corp\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Corp\n802-1x.eap: peap\n802-1x.identity: alice\n802-1x.password: s3cret\n' ;;
+  "-s -m multiline connection show "*) echo "Error: unknown connection" >&2; exit 10 ;;
+  "-m multiline connection show u-corp") if [ -f "$STATE/deleted" ]; then exit 10; fi; printf 'connection.id: Corp\n' ;;
+  "connection delete u-corp") touch "$STATE/deleted" ;;
+  "connection add "*) if [ -f "$STATE/fail-add" ]; then rm "$STATE/fail-add"; echo "Error: invalid property" >&2; exit 2; fi ;;
+  *) : ;;
+esac
+`
+
+func setupHistoryTest(t *testing.T) string {
+	t.Helper()
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(state, "data"))
+	installFakeCommand(t, "nmcli", historyFakeNmcli)
+	installProfileSnapshots()
+	t.Cleanup(func() { gonetworkmanager.BeforeProfileChange = nil })
+	return state
+}
+
+func TestDeleteSnapshotsProfileFirst(t *testing.T) {
+	state := setupHistoryTest(t)
+
+	if _, err := gonetworkmanager.ConnectionDelete("u-corp"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if !strings.HasPrefix(string(calls), "-s -m multiline connection show u-corp\n") || !strings.Contains(string(calls), "connection delete u-corp") {
+		t.Fatalf("expected a snapshot with secrets before the delete:\n%s", calls)
+	}
+	entries, err := loadHistory()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one history entry, got %d (%v)", len(entries), err)
+	}
+	e := entries[0]
+	if e.Operation != gonetworkmanager.ProfileChangeDelete || e.UUID != "u-corp" || !strings.Contains(e.Keyfile, "password=s3cret") {
+		t.Fatalf("unexpected snapshot: %+v", e)
+	}
+	path, _ := historyPath()
+	if info, _ := os.Stat(path); info == nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("history file must be 0600")
+	}
+
+	// No snapshot, no delete.
+	os.Remove(filepath.Join(state, "calls"))
+	if _, err := gonetworkmanager.ConnectionDelete("u-unknown"); err == nil || !strings.Contains(err.Error(), "snapshot failed") {
+		t.Fatalf("expected the delete to be aborted, got %v", err)
+	}
+	if calls, _ := os.ReadFile(filepath.Join(state, "calls")); strings.Contains(string(calls), "connection delete") {
+		t.Fatalf("delete must not run when the snapshot fails:\n%s", calls)
+	}
+}
+
+func TestHistoryIsCapped(t *testing.T) {
+	setupHistoryTest(t)
+	for i := 0; i < historyLimit+3; i++ {
+		if _, err := snapshotProfile("u-corp", gonetworkmanager.ProfileChangeModify); err != nil {
+			t.Fatalf("snapshot %d: %v", i, err)
+		}
+	}
+	entries, _ := loadHistory()
+	if len(entries) != historyLimit {
+		t.Fatalf("expected %d entries, got %d", historyLimit, len(entries))
+	}
+	if entries[0].Time.Before(entries[len(entries)-1].Time) {
+		t.Fatalf("expected newest first")
+	}
+}
+
+func TestUnreadableHistoryIsNotOverwritten(t *testing.T) {
+	state := setupHistoryTest(t)
+	path, err := historyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("[not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gonetworkmanager.ConnectionDelete("u-corp"); err == nil || !strings.Contains(err.Error(), "snapshot failed") {
+		t.Fatalf("expected the delete to be aborted, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "[not json" {
+		t.Fatalf("the history was overwritten: %q", data)
+	}
+	if calls, _ := os.ReadFile(filepath.Join(state, "calls")); strings.Contains(string(calls), "connection delete") {
+		t.Fatalf("delete must not run without a snapshot:\n%s", calls)
+	}
+}
+
+func TestForgetOffersUndo(t *testing.T) {
+	state := setupHistoryTest(t)
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.previousState = viewKnownNetworksList
+	m.isLoading = false
+
+	msg := forgetNetworkCmd("u-corp", "Corp")().(forgetNetworkResultMsg)
+	if !msg.success || msg.undo == nil || msg.undo.UUID != "u-corp" {
+		t.Fatalf("expected the forget result to carry its snapshot, got %+v", msg)
+	}
+	updated, _ := m.Update(msg)
+	m2 := updated.(model)
+	if m2.undo == nil || !strings.Contains(m2.View(), "Corp deleted — press z to undo") {
+		t.Fatalf("expected an undo toast in the header")
+	}
+
+	updated, _ = m2.Update(undoExpiredMsg{id: "stale"})
+	m2 = updated.(model)
+	if m2.undo == nil {
+		t.Fatalf("an old timer must not hide a newer toast")
+	}
+
+	updated, cmd := m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}})
+	m2 = updated.(model)
+	if m2.undo != nil || cmd == nil {
+		t.Fatalf("expected z to start the undo")
+	}
+	restored := restoreSnapshotCmd(*msg.undo)().(snapshotRestoredMsg)
+	if restored.err != nil {
+		t.Fatalf("restore: %v", restored.err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if !strings.Contains(string(calls), "connection add type 802-11-wireless con-name Corp ifname * connection.uuid u-corp") ||
+		!strings.Contains(string(calls), "802-1x.password s3cret") {
+		t.Fatalf("expected the profile to be recreated with its secrets:\n%s", calls)
+	}
+	updated, _ = m2.Update(restored)
+	m2 = updated.(model)
+	if !strings.Contains(m2.connectionStatusMsg, "Restored Corp") {
+		t.Fatalf("unexpected status %q", m2.connectionStatusMsg)
+	}
+}
+
+func TestHistoryViewAndCLI(t *testing.T) {
+	setupHistoryTest(t)
+	if _, err := snapshotProfile("u-corp", gonetworkmanager.ProfileChangeModify); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runHistoryCLI(nil, &out, &errOut); code != 0 || !strings.Contains(out.String(), "modify  Corp (wifi)") {
+		t.Fatalf("unexpected history list (%d):\n%s%s", code, out.String(), errOut.String())
+	}
+	out.Reset()
+	if code := runHistoryCLI([]string{"restore", "1"}, &out, &errOut); code != 0 || !strings.Contains(out.String(), "Restored Corp") {
+		t.Fatalf("unexpected history restore (%d):\n%s%s", code, out.String(), errOut.String())
+	}
+	if code := runHistoryCLI([]string{"restore", "9"}, &out, &errOut); code != 2 {
+		t.Fatalf("out-of-range restore should be a usage error, got %d", code)
+	}
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'H'}})
+	m2 := updated.(model)
+	if m2.state != viewHistory || cmd == nil {
+		t.Fatalf("expected H to open the history")
+	}
+	updated, _ = m2.Update(loadHistoryCmd()())
+	m2 = updated.(model)
+	if len(m2.historyEntries) != 1 || !strings.Contains(m2.View(), "modified") {
+		t.Fatalf("expected the one snapshot in the view, restoring adds none:\n%s", m2.View())
+	}
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if cmd == nil || !strings.Contains(m2.connectionStatusMsg, "Restoring Corp") {
+		t.Fatalf("expected Enter to restore the selected snapshot")
+	}
+}
+
+func TestRestoreKeepsCurrentVersionWhenAddFails(t *testing.T) {
+	state := setupHistoryTest(t)
+	e, err := snapshotProfile("u-corp", gonetworkmanager.ProfileChangeModify)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	os.Remove(filepath.Join(state, "calls"))
+	os.WriteFile(filepath.Join(state, "fail-add"), nil, 0600)
+
+	if err := restoreSnapshot(e); err == nil || !strings.Contains(err.Error(), "previous version kept") {
+		t.Fatalf("expected the failed restore to be reported, got %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if strings.Count(string(calls), "connection add type 802-11-wireless con-name Corp ifname * connection.uuid u-corp") != 2 ||
+		!strings.Contains(string(calls), "connection delete u-corp\n") {
+		t.Fatalf("expected the deleted profile to be added back:\n%s", calls)
+	}
+	if entries, _ := loadHistory(); len(entries) != 1 {
+		t.Fatalf("restoring must not add snapshots, got %d entries", len(entries))
+	}
+}
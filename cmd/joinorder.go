@@ -0,0 +1,285 @@
+// nmtui/cmd/joinorder.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// inRangeSSIDs returns the set of named SSIDs in a scan result.
+func inRangeSSIDs(aps []gonetworkmanager.WifiAccessPoint) map[string]bool {
+	inRange := make(map[string]bool)
+	for _, ap := range aps {
+		if ssid := strings.TrimSpace(ap[gonetworkmanager.NmcliFieldWifiSSID]); ssid != "" && ssid != "--" {
+			inRange[ssid] = true
+		}
+	}
+	return inRange
+}
+
+// sortByAutoconnectPreference orders profiles the way NetworkManager ranks
+// autoconnect candidates: higher priority first, then most recently used.
+func sortByAutoconnectPreference(profiles []gonetworkmanager.KnownWifiProfile) {
+	sort.SliceStable(profiles, func(i, j int) bool {
+		if profiles[i].Priority != profiles[j].Priority {
+			return profiles[i].Priority > profiles[j].Priority
+		}
+		return profiles[i].Timestamp > profiles[j].Timestamp
+	})
+}
+
+// autoconnectChoice returns the profile NetworkManager would activate among
+// the networks in range, or false if no autoconnect profile is in range.
+func autoconnectChoice(profiles []gonetworkmanager.KnownWifiProfile, inRange map[string]bool) (gonetworkmanager.KnownWifiProfile, bool) {
+	ranked := append([]gonetworkmanager.KnownWifiProfile(nil), profiles...)
+	sortByAutoconnectPreference(ranked)
+	for _, p := range ranked {
+		if p.Autoconnect && inRange[p.SSID] {
+			return p, true
+		}
+	}
+	return gonetworkmanager.KnownWifiProfile{}, false
+}
+
+// joinOrderPriorities gives the profiles descending, distinct priorities so
+// that NetworkManager prefers them in the given order. The last one gets 0.
+func joinOrderPriorities(order []gonetworkmanager.KnownWifiProfile) []gonetworkmanager.KnownWifiProfile {
+	out := append([]gonetworkmanager.KnownWifiProfile(nil), order...)
+	for i := range out {
+		out[i].Priority = len(out) - 1 - i
+	}
+	return out
+}
+
+type joinOrderScreen struct {
+	profiles     []gonetworkmanager.KnownWifiProfile // display order, preferred first
+	inRange      map[string]bool
+	cursor       int
+	dirty        bool
+	discardArmed bool
+}
+
+type joinOrderLoadedMsg struct {
+	profiles []gonetworkmanager.KnownWifiProfile
+	inRange  map[string]bool
+	err      error
+}
+
+type joinOrderSavedMsg struct {
+	results []profileResult
+}
+
+func loadJoinOrderCmd() tea.Cmd {
+	return func() tea.Msg {
+		profiles, err := gonetworkmanager.GetKnownWifiProfiles()
+		if err != nil {
+			return joinOrderLoadedMsg{err: err}
+		}
+		sortByAutoconnectPreference(profiles)
+		aps, err := gonetworkmanager.GetWifiList(false)
+		if err != nil {
+			return joinOrderLoadedMsg{profiles: profiles, err: err}
+		}
+		return joinOrderLoadedMsg{profiles: profiles, inRange: inRangeSSIDs(aps)}
+	}
+}
+
+// saveJoinOrderCmd rewrites the priority of every profile whose value changes.
+func saveJoinOrderCmd(before, after []gonetworkmanager.KnownWifiProfile) tea.Cmd {
+	old := make(map[string]int, len(before))
+	for _, p := range before {
+		old[p.UUID] = p.Priority
+	}
+	return func() tea.Msg {
+		var results []profileResult
+		for _, p := range after {
+			if prio, ok := old[p.UUID]; ok && prio == p.Priority {
+				continue
+			}
+			_, err := gonetworkmanager.ModifyConnectionUnrecorded(p.UUID, []gonetworkmanager.ConnectionSetting{{Key: nmPropPriority, Value: strconv.Itoa(p.Priority)}})
+			results = append(results, profileResult{Name: p.Name, Err: err})
+		}
+		return joinOrderSavedMsg{results: results}
+	}
+}
+
+func (m *model) openJoinOrder() []tea.Cmd {
+	m.joinOrder = &joinOrderScreen{}
+	m.state = viewJoinOrder
+	m.isLoading = true
+	m.clearStatus()
+	return []tea.Cmd{loadJoinOrderCmd(), m.spinner.Tick}
+}
+
+func (m *model) closeJoinOrder() {
+	m.joinOrder = nil
+	m.isLoading = false
+	m.state = viewKnownNetworksList
+	m.clearStatus()
+	m.resizeComponents()
+}
+
+func (m *model) handleJoinOrderLoaded(msg joinOrderLoadedMsg) {
+	j := m.joinOrder
+	if j == nil {
+		return
+	}
+	m.isLoading = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not load profiles: %v", msg.err), errorStyle)
+	}
+	if msg.profiles == nil && msg.err != nil {
+		return
+	}
+	j.profiles = msg.profiles
+	j.inRange = msg.inRange
+	j.dirty = false
+	if j.cursor >= len(j.profiles) {
+		j.cursor = 0
+	}
+}
+
+func (m *model) handleJoinOrderKeys(msg tea.KeyMsg) []tea.Cmd {
+	j := m.joinOrder
+	if j == nil {
+		return nil
+	}
+	if m.isLoading {
+		if key.Matches(msg, m.keys.Back) {
+			m.closeJoinOrder()
+		}
+		return nil
+	}
+	if !key.Matches(msg, m.keys.Back) {
+		j.discardArmed = false
+	}
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		if j.dirty && !j.discardArmed {
+			j.discardArmed = true
+			m.setStatus(fmt.Sprintf("Order not saved. Press %s again to discard, %s to save.", m.keys.Back.Help().Key, m.keys.Connect.Help().Key), toggleHiddenStatusMsgStyle)
+			return nil
+		}
+		m.closeJoinOrder()
+	case msg.String() == "up":
+		if j.cursor > 0 {
+			j.cursor--
+		}
+	case msg.String() == "down":
+		if j.cursor < len(j.profiles)-1 {
+			j.cursor++
+		}
+	case key.Matches(msg, m.keys.MoveUp):
+		if j.cursor > 0 {
+			j.profiles[j.cursor-1], j.profiles[j.cursor] = j.profiles[j.cursor], j.profiles[j.cursor-1]
+			j.cursor--
+			j.dirty = true
+		}
+	case key.Matches(msg, m.keys.MoveDown):
+		if j.cursor < len(j.profiles)-1 {
+			j.profiles[j.cursor+1], j.profiles[j.cursor] = j.profiles[j.cursor], j.profiles[j.cursor+1]
+			j.cursor++
+			j.dirty = true
+		}
+	case key.Matches(msg, m.keys.Refresh):
+		m.isLoading = true
+		return []tea.Cmd{loadJoinOrderCmd(), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Connect):
+		if !j.dirty {
+			m.setStatus("Order unchanged.", toggleHiddenStatusMsgStyle)
+			return nil
+		}
+		m.isLoading = true
+		m.setStatus("Saving auto-join order...", connectingStyle)
+		return []tea.Cmd{saveJoinOrderCmd(j.profiles, joinOrderPriorities(j.profiles)), m.spinner.Tick}
+	}
+	return nil
+}
+
+func (m *model) handleJoinOrderSaved(msg joinOrderSavedMsg) []tea.Cmd {
+	m.isLoading = false
+	summary, ok := summarizeResults("Updated the priority of", msg.results)
+	if ok {
+		m.setStatus(summary, successStyle)
+	} else {
+		m.setStatus(summary, errorStyle)
+	}
+	if m.joinOrder == nil {
+		return nil
+	}
+	m.isLoading = true
+	return []tea.Cmd{loadJoinOrderCmd(), m.spinner.Tick}
+}
+
+func (m model) joinOrderView(width, height int) string {
+	j := m.joinOrder
+	if j == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	good := lipgloss.NewStyle().Foreground(ansSuccessColor)
+	bad := lipgloss.NewStyle().Foreground(ansErrorColor)
+	lines := []string{titleStyle.Render("Auto-join Order")}
+
+	choice := func(profiles []gonetworkmanager.KnownWifiProfile) string {
+		if p, ok := autoconnectChoice(profiles, j.inRange); ok {
+			return good.Bold(true).Render(p.Name)
+		}
+		return label.Render("nothing (no autoconnect profile in range)")
+	}
+	if len(j.profiles) > 0 {
+		lines = append(lines, label.Render("NetworkManager would join now: ")+choice(j.profiles))
+		if j.dirty {
+			lines = append(lines, label.Render("After saving this order:      ")+choice(joinOrderPriorities(j.profiles)))
+		}
+	}
+	lines = append(lines, "")
+	switch {
+	case m.isLoading && len(j.profiles) == 0:
+		lines = append(lines, connectingStyle.Render(m.spinner.View()+" Loading profiles..."))
+	case len(j.profiles) == 0:
+		lines = append(lines, label.Render("No Wi-Fi profiles."))
+	}
+
+	maxRows := height - 12
+	if maxRows < 3 {
+		maxRows = 3
+	}
+	start := 0
+	if j.cursor >= maxRows {
+		start = j.cursor - maxRows + 1
+	}
+	for i := start; i < len(j.profiles) && i < start+maxRows; i++ {
+		p := j.profiles[i]
+		var notes []string
+		if p.Device != "" {
+			notes = append(notes, good.Render("connected"))
+		} else if j.inRange[p.SSID] {
+			notes = append(notes, good.Render("in range"))
+		}
+		if !p.Autoconnect {
+			notes = append(notes, bad.Render("autoconnect off"))
+		}
+		line := fmt.Sprintf("%2d. %-32s %s %s", i+1, truncateRunes(p.Name, 32), label.Render(fmt.Sprintf("prio %d", p.Priority)), strings.Join(notes, " "))
+		if i == j.cursor {
+			line = listSelectedItemStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", label.Render(fmt.Sprintf("↑/↓: select  %s, %s: move  %s: save  %s: reload  %s: back",
+		m.keys.MoveUp.Help().Key, m.keys.MoveDown.Help().Key, m.keys.Connect.Help().Key, m.keys.Refresh.Help().Key, m.keys.Back.Help().Key)))
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestAutoconnectChoice(t *testing.T) {
+	profiles := []gonetworkmanager.KnownWifiProfile{
+		{Name: "Office", SSID: "Office", Autoconnect: true, Priority: 5},
+		{Name: "Home", SSID: "Home", Autoconnect: true, Priority: 10},
+		{Name: "Phone", SSID: "Phone", Autoconnect: false, Priority: 50},
+		{Name: "Cafe", SSID: "Cafe", Autoconnect: true, Priority: 5, Timestamp: 100},
+	}
+	inRange := map[string]bool{"Office": true, "Phone": true, "Cafe": true}
+	if p, ok := autoconnectChoice(profiles, inRange); !ok || p.Name != "Cafe" {
+		t.Fatalf("expected the more recently used of equal priorities, got %q", p.Name)
+	}
+	if _, ok := autoconnectChoice(profiles, map[string]bool{"Phone": true}); ok {
+		t.Fatalf("a profile with autoconnect off is never picked")
+	}
+
+	reordered := joinOrderPriorities([]gonetworkmanager.KnownWifiProfile{profiles[2], profiles[0], profiles[3]})
+	if reordered[0].Priority != 2 || reordered[1].Priority != 1 || reordered[2].Priority != 0 {
+		t.Fatalf("unexpected priorities %+v", reordered)
+	}
+	if p, _ := autoconnectChoice(reordered, inRange); p.Name != "Office" {
+		t.Fatalf("expected Office after reordering, got %q", p.Name)
+	}
+}
+
+const joinOrderFakeNmcli = `
+case "$*" in
+  "-m multiline -f NAME,UUID,TYPE,DEVICE,AUTOCONNECT,AUTOCONNECT-PRIORITY,TIMESTAMP connection show --order name")
+    printf 'NAME: Cafe\nUUID: u-cafe\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 5\n'
+    printf 'NAME: Home\nUUID: u-home\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 10\nTIMESTAMP: 9\n'
+    printf 'NAME: Office\nUUID: u-office\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 1\nTIMESTAMP: 7\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless.ssid connection show "*)
+    printf 'connection.uuid: u-cafe\n802-11-wireless.ssid: CafeNet\n' ;;
+  *"device wifi list"*) printf 'SSID: CafeNet\nSIGNAL: 50\nSSID: Office\nSIGNAL: 70\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestJoinOrderScreen(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", joinOrderFakeNmcli)
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	m, cmd := press(t, m, runeKey('O'))
+	if m.state != viewJoinOrder || cmd == nil {
+		t.Fatalf("expected O to open the auto-join order")
+	}
+	updated, _ := m.Update(loadJoinOrderCmd()())
+	m = updated.(model)
+	var names []string
+	for _, p := range m.joinOrder.profiles {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "Home,Office,Cafe" {
+		t.Fatalf("expected profiles by priority, got %v", names)
+	}
+	v := m.View()
+	if !strings.Contains(v, "would join now: Office") || !strings.Contains(v, "in range") {
+		t.Fatalf("expected the current pick in the view:\n%s", v)
+	}
+
+	// Move Cafe (matched by its SSID, not its name) to the top.
+	m, _ = press(t, m, downKey, downKey, runeKey('K'), runeKey('K'))
+	if m.joinOrder.profiles[0].Name != "Cafe" || !m.joinOrder.dirty || !strings.Contains(m.View(), "After saving this order:      Cafe") {
+		t.Fatalf("expected Cafe first and a new pick:\n%s", m.View())
+	}
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.state != viewJoinOrder || !strings.Contains(m.connectionStatusMsg, "esc/h again to discard") {
+		t.Fatalf("the first Esc should warn about the unsaved order")
+	}
+	// Saving the order takes no snapshots.
+	gonetworkmanager.BeforeProfileChange = func(id, op string) error {
+		t.Errorf("unexpected snapshot of %s before %s", id, op)
+		return nil
+	}
+	t.Cleanup(func() { gonetworkmanager.BeforeProfileChange = nil })
+	m, cmd = press(t, m, enterKey)
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if !strings.Contains(m.connectionStatusMsg, "Updated the priority of 3 profile(s).") {
+		t.Fatalf("unexpected status %q", m.connectionStatusMsg)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	want := "connection modify u-cafe connection.autoconnect-priority 2\n" +
+		"connection modify u-home connection.autoconnect-priority 1\n" +
+		"connection modify u-office connection.autoconnect-priority 0\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+}
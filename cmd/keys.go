@@ -0,0 +1,334 @@
+// nmtui/cmd/keys.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultKeymapName = "vim"
+
+// keyAction is one rebindable action: its name in config.toml's [keys]
+// table, its help text, its keys in the vim preset and its keyMap field.
+// The command palette lists the actions that have a title.
+type keyAction struct {
+	name  string
+	desc  string
+	keys  []string
+	field func(*keyMap) *key.Binding
+	// title is the palette entry; states limits it to some screens (none
+	// means every screen).
+	title  string
+	states []viewState
+	// run performs an action that no screen handles by key; the palette
+	// and its key binding call it directly.
+	run func(m *model) []tea.Cmd
+	// nav actions always keep their canonical key (enter, esc, up, down);
+	// their other keys are translated to it outside text inputs, so every
+	// screen and list that handles the canonical key honors them.
+	nav       bool
+	canonical tea.KeyType
+}
+
+func navAction(name, desc string, canonical tea.KeyType, keys []string, field func(*keyMap) *key.Binding) keyAction {
+	return keyAction{name: name, desc: desc, keys: keys, field: field, nav: true, canonical: canonical}
+}
+
+func action(name, desc string, keys []string, field func(*keyMap) *key.Binding) keyAction {
+	return keyAction{name: name, desc: desc, keys: keys, field: field}
+}
+
+// in lists the action in the command palette on the given screens.
+func (a keyAction) in(title string, states ...viewState) keyAction {
+	a.title, a.states = title, states
+	return a
+}
+
+func (a keyAction) does(run func(m *model) []tea.Cmd) keyAction {
+	a.run = run
+	return a
+}
+
+func (a keyAction) availableIn(state viewState) bool {
+	return a.title != "" && (len(a.states) == 0 || slices.Contains(a.states, state))
+}
+
+// keyActions lists every action in the order `config show` prints them.
+var keyActions = []keyAction{
+	navAction("connect", "select/conn/confirm", tea.KeyEnter, []string{"enter", "l"}, func(k *keyMap) *key.Binding { return &k.Connect }),
+	navAction("back", "back/cancel", tea.KeyEsc, []string{"esc", "h"}, func(k *keyMap) *key.Binding { return &k.Back }),
+	navAction("up", "up", tea.KeyUp, []string{"up", "k"}, func(k *keyMap) *key.Binding { return &k.Up }),
+	navAction("down", "down", tea.KeyDown, []string{"down", "j"}, func(k *keyMap) *key.Binding { return &k.Down }),
+	action("quit", "quit", []string{"q", "ctrl+c"}, func(k *keyMap) *key.Binding { return &k.Quit }).
+		in("Quit"),
+	action("help", "help", []string{"?"}, func(k *keyMap) *key.Binding { return &k.Help }).
+		in("Toggle full help"),
+	action("refresh", "refresh", []string{"r"}, func(k *keyMap) *key.Binding { return &k.Refresh }).
+		in("Refresh / rescan", viewNetworksList, viewKnownNetworksList, viewDiagnostics, viewSurvey, viewCleanup, viewJoinOrder, viewLocations),
+	action("filter", "filter", []string{"/"}, func(k *keyMap) *key.Binding { return &k.Filter }).
+		in("Filter networks by SSID", viewNetworksList),
+	action("toggle_wifi", "toggle Wi-Fi", []string{"t"}, func(k *keyMap) *key.Binding { return &k.ToggleWifi }).
+		in("Toggle the Wi-Fi radio", viewNetworksList),
+	action("disconnect", "disconnect", []string{"d"}, func(k *keyMap) *key.Binding { return &k.Disconnect }).
+		in("Disconnect the active Wi-Fi", viewNetworksList),
+	action("forget", "forget", []string{"ctrl+f"}, func(k *keyMap) *key.Binding { return &k.Forget }).
+		in("Forget the selected profile", viewNetworksList, viewKnownNetworksList, viewProfileDetails),
+	action("info", "info", []string{"i"}, func(k *keyMap) *key.Binding { return &k.Info }).
+		in("Show active connection info", viewNetworksList),
+	action("toggle_hidden", "unnamed nets", []string{"u"}, func(k *keyMap) *key.Binding { return &k.ToggleHidden }).
+		in("Show or hide unnamed networks", viewNetworksList),
+	action("profiles", "profiles", []string{"p"}, func(k *keyMap) *key.Binding { return &k.Profiles }).
+		in("Go to saved profiles", viewNetworksList),
+	action("new_profile", "new profile", []string{"n"}, func(k *keyMap) *key.Binding { return &k.NewProfile }).
+		in("Create a Wi-Fi profile", viewKnownNetworksList),
+	action("edit_profile", "edit profile", []string{"e"}, func(k *keyMap) *key.Binding { return &k.EditProfile }).
+		in("Edit the selected profile", viewKnownNetworksList, viewProfileDetails),
+	action("clear_secret", "clear password", []string{"ctrl+x"}, func(k *keyMap) *key.Binding { return &k.ClearSecret }),
+	action("update", "update", []string{"U"}, func(k *keyMap) *key.Binding { return &k.Update }).
+		in("Install the available update"),
+	action("survey", "site survey", []string{"S"}, func(k *keyMap) *key.Binding { return &k.Survey }).
+		in("Start a site survey", viewNetworksList),
+	action("export", "export", []string{"x"}, func(k *keyMap) *key.Binding { return &k.Export }).
+		in("Export the selected profile as a keyfile", viewKnownNetworksList),
+	action("export_secrets", "export w/ secrets", []string{"X"}, func(k *keyMap) *key.Binding { return &k.ExportSecrets }).
+		in("Export the selected profile with secrets", viewKnownNetworksList),
+	action("import", "import keyfile", []string{"I"}, func(k *keyMap) *key.Binding { return &k.Import }).
+		in("Import a keyfile", viewKnownNetworksList),
+	action("restore", "restore backup", []string{"R"}, func(k *keyMap) *key.Binding { return &k.Restore }).
+		in("Restore profiles from a backup", viewKnownNetworksList),
+	action("history", "history", []string{"H"}, func(k *keyMap) *key.Binding { return &k.History }).
+		in("Open the profile history", viewKnownNetworksList),
+	action("undo", "undo", []string{"z"}, func(k *keyMap) *key.Binding { return &k.Undo }).
+		in("Undo the last forget or edit"),
+	action("cleanup", "clean up stale", []string{"C"}, func(k *keyMap) *key.Binding { return &k.Cleanup }).
+		in("Clean up stale profiles", viewKnownNetworksList),
+	action("join_order", "auto-join order", []string{"O"}, func(k *keyMap) *key.Binding { return &k.JoinOrder }).
+		in("Edit the auto-join order", viewKnownNetworksList),
+	action("move_up", "move up", []string{"K", "shift+up"}, func(k *keyMap) *key.Binding { return &k.MoveUp }),
+	action("move_down", "move down", []string{"J", "shift+down"}, func(k *keyMap) *key.Binding { return &k.MoveDown }),
+	action("mark", "mark", []string{" "}, func(k *keyMap) *key.Binding { return &k.Mark }).
+		in("Mark or unmark the selected network", viewNetworksList, viewKnownNetworksList),
+	action("select_all", "all/none", []string{"a"}, func(k *keyMap) *key.Binding { return &k.SelectAll }),
+	action("threshold_up", "raise threshold", []string{"+"}, func(k *keyMap) *key.Binding { return &k.ThresholdUp }),
+	action("threshold_down", "lower threshold", []string{"-"}, func(k *keyMap) *key.Binding { return &k.ThresholdDown }),
+	action("confirm", "confirm", []string{"y"}, func(k *keyMap) *key.Binding { return &k.Confirm }),
+	action("bulk", "bulk actions", []string{"b"}, func(k *keyMap) *key.Binding { return &k.Bulk }).
+		in("Bulk actions on marked profiles", viewNetworksList, viewKnownNetworksList),
+	action("diagnose", "diagnostics", []string{"D"}, func(k *keyMap) *key.Binding { return &k.Diagnose }).
+		in("Run network diagnostics", viewNetworksList),
+	action("portal", "open portal login", []string{"o"}, func(k *keyMap) *key.Binding { return &k.Portal }).
+		in("Open the captive portal login", viewNetworksList),
+	action("watchdog", "toggle watchdog", []string{"W"}, func(k *keyMap) *key.Binding { return &k.Watchdog }).
+		in("Toggle the connection watchdog", viewNetworksList),
+	action("connect_for", "connect for…", []string{"T"}, func(k *keyMap) *key.Binding { return &k.ConnectFor }).
+		in("Connect for a limited time", viewNetworksList, viewKnownNetworksList),
+	action("share", "share", nil, func(k *keyMap) *key.Binding { return &k.Share }).
+		in("Share networks as Wi-Fi QR strings", viewNetworksList, viewKnownNetworksList).
+		does((*model).shareNetworks),
+	action("palette", "commands", []string{":", "ctrl+p"}, func(k *keyMap) *key.Binding { return &k.Palette }),
+	action("locations", "locations", []string{"L"}, func(k *keyMap) *key.Binding { return &k.Locations }).
+		in("Switch location", viewNetworksList, viewKnownNetworksList),
+	action("reveal_password", "show password", []string{"v"}, func(k *keyMap) *key.Binding { return &k.RevealPassword }).
+		in("Show or hide the saved password", viewProfileDetails),
+	action("copy_password", "copy password", []string{"c"}, func(k *keyMap) *key.Binding { return &k.CopyPassword }).
+		in("Copy the saved password to the clipboard", viewProfileDetails),
+}
+
+// keyPresets change the keys of some actions; the rest keep the vim
+// preset's keys.
+var keyPresets = map[string]map[string][]string{
+	"vim": {},
+	"emacs": {
+		"connect": {"enter"},
+		"back":    {"esc", "ctrl+g"},
+		"up":      {"up", "ctrl+p"},
+		"down":    {"down", "ctrl+n"},
+		"filter":  {"/", "ctrl+s"},
+		"palette": {":"},
+	},
+	"arrows-only": {
+		"connect":   {"enter"},
+		"back":      {"esc"},
+		"up":        {"up"},
+		"down":      {"down"},
+		"move_up":   {"shift+up"},
+		"move_down": {"shift+down"},
+	},
+}
+
+func keyPresetNames() []string {
+	names := make([]string, 0, len(keyPresets))
+	for name := range keyPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func keyActionNames() []string {
+	names := make([]string, len(keyActions))
+	for i, a := range keyActions {
+		names[i] = a.name
+	}
+	return names
+}
+
+func findKeyAction(name string) (keyAction, bool) {
+	for _, a := range keyActions {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return keyAction{}, false
+}
+
+// parseKeyNames reads a comma-separated key list as written in config.toml
+// or --set, e.g. "r,ctrl+r". "space" is the space bar; an empty list
+// unbinds the action.
+func parseKeyNames(v string) ([]string, error) {
+	var keys []string
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	for _, k := range strings.Split(v, ",") {
+		k = strings.TrimSpace(k)
+		switch {
+		case k == "":
+			return nil, fmt.Errorf("%q contains an empty key name", v)
+		case k == "space":
+			k = " "
+		case len([]rune(k)) > 1:
+			k = strings.ToLower(k) // "Ctrl+R" -> "ctrl+r"; single letters keep their case
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// keyName is how a key is written in config.toml and shown in the help.
+func keyName(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
+
+// buildKeyMap applies a preset and per-action overrides. Every key may
+// belong to one action only; a conflict is reported and the key stays with
+// the action listed first, so the returned map is always usable.
+func buildKeyMap(preset string, overrides map[string][]string) (keyMap, error) {
+	p, ok := keyPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("unknown keymap %q (use %s)", preset, strings.Join(keyPresetNames(), ", "))
+	}
+	km := keyMap{aliases: make(map[string]tea.KeyType)}
+	owner := make(map[string]string)
+	var errs []error
+	for _, a := range keyActions {
+		keys := a.keys
+		if pk, ok := p[a.name]; ok {
+			keys = pk
+		}
+		if o, set := overrides[a.name]; set {
+			keys = o
+		}
+		if a.nav {
+			keys = append([]string{tea.Key{Type: a.canonical}.String()}, keys...)
+		}
+		var bound, names []string
+		for _, k := range keys {
+			if other, taken := owner[k]; taken {
+				if other != a.name {
+					errs = append(errs, fmt.Errorf("%q is bound to both %s and %s", keyName(k), other, a.name))
+				}
+				continue
+			}
+			owner[k] = a.name
+			bound = append(bound, k)
+			names = append(names, keyName(k))
+			if a.nav && len(bound) > 1 {
+				km.aliases[k] = a.canonical
+			}
+		}
+		sep := "/"
+		if slices.Contains(bound, "/") {
+			sep = ", " // "/, ctrl+s" rather than "//ctrl+s"
+		}
+		*a.field(&km) = key.NewBinding(key.WithKeys(bound...), key.WithHelp(strings.Join(names, sep), a.desc))
+	}
+	return km, errors.Join(errs...)
+}
+
+// listCursorKeys leaves j/k to the keymap: the lists move on the arrow
+// keys, which remapKeys produces from the configured up/down keys.
+func listCursorKeys(km *list.KeyMap) {
+	km.CursorUp = key.NewBinding(key.WithKeys("up"))
+	km.CursorDown = key.NewBinding(key.WithKeys("down"))
+}
+
+// keySetting is the [keys] override of one action, written "keys.refresh"
+// in --set.
+func keySetting(a keyAction) configSetting {
+	return configSetting{key: "keys." + a.name,
+		set: func(c *appConfig, v string) error {
+			keys, err := parseKeyNames(v)
+			if err != nil {
+				return err
+			}
+			c.Keys[a.name] = keys
+			return nil
+		},
+		get: func(c *appConfig) string {
+			quoted := make([]string, len(c.Keys[a.name]))
+			for i, k := range c.Keys[a.name] {
+				quoted[i] = strconv.Quote(keyName(k))
+			}
+			return "[" + strings.Join(quoted, ", ") + "]"
+		},
+	}
+}
+
+// applyKeys reads the [keys] table; a value is one key or a list of keys.
+func (c *appConfig) applyKeys(path string, table map[string]any) error {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s, ok := findConfigSetting("keys." + name)
+		if !ok {
+			return fmt.Errorf("%s: [keys]: unknown action %q", path, name)
+		}
+		var v string
+		switch val := table[name].(type) {
+		case string:
+			v = val
+		case []any:
+			parts := make([]string, len(val))
+			for i, k := range val {
+				str, ok := k.(string)
+				if !ok {
+					return fmt.Errorf("%s: keys.%s: keys must be strings such as \"r\" or \"ctrl+r\"", path, name)
+				}
+				parts[i] = str
+			}
+			v = strings.Join(parts, ",")
+		default:
+			return fmt.Errorf("%s: keys.%s: keys must be strings such as \"r\" or \"ctrl+r\"", path, name)
+		}
+		if err := s.set(c, v); err != nil {
+			return fmt.Errorf("%s: keys.%s: %w", path, name, err)
+		}
+		c.sources[s.key] = "file"
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBuildKeyMap(t *testing.T) {
+	km, err := buildKeyMap("vim", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.Connect.Help().Key != "enter/l" || km.Back.Help().Key != "esc/h" || km.Mark.Help().Key != "space" {
+		t.Fatalf("unexpected help keys %q %q %q", km.Connect.Help().Key, km.Back.Help().Key, km.Mark.Help().Key)
+	}
+	if km.aliases["j"] != tea.KeyDown || km.aliases["h"] != tea.KeyEsc {
+		t.Fatalf("unexpected aliases %v", km.aliases)
+	}
+
+	km, err = buildKeyMap("arrows-only", map[string][]string{"refresh": {"ctrl+r", "F5"}, "portal": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(km.aliases) != 0 || km.Refresh.Help().Key != "ctrl+r/F5" || km.Portal.Enabled() {
+		t.Fatalf("unexpected keymap: aliases %v, refresh %q", km.aliases, km.Refresh.Help().Key)
+	}
+
+	// Even canonical keys cannot be taken over by another action.
+	_, err = buildKeyMap("emacs", map[string][]string{"export": {"ctrl+n"}, "info": {"enter"}})
+	if err == nil || !strings.Contains(err.Error(), `"ctrl+n" is bound to both down and export`) ||
+		!strings.Contains(err.Error(), `"enter" is bound to both connect and info`) {
+		t.Fatalf("expected both conflicts, got %v", err)
+	}
+}
+
+func TestKeysConfig(t *testing.T) {
+	writeAppConfig(t, "keymap = \"emacs\"\n\n[keys]\nrefresh = [\"ctrl+r\", \"R\"]\nmark = \"space\"\n")
+	configFlags = []string{"keys.history=Y"}
+	_, err := loadAppConfig()
+	if err == nil || !strings.Contains(err.Error(), `keys: "R" is bound to both refresh and restore`) {
+		t.Fatalf("expected a conflict with restore, got %v", err)
+	}
+
+	configFlags = []string{"keys.restore=Ctrl+O", "keys.history=Y"}
+	if _, err := loadAppConfig(); err != nil {
+		t.Fatalf("loadAppConfig: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	runConfigCLI([]string{"show"}, &out, &errOut)
+	for _, want := range []string{`keymap = "emacs"  # file`, "[keys]", `refresh = ["ctrl+r", "R"]  # file`, `mark = ["space"]  # file`, `restore = ["ctrl+o"]  # flag`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("config show lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	writeAppConfig(t, "[keys]\nrefersh = \"r\"\n")
+	if _, err := loadAppConfig(); err == nil || !strings.Contains(err.Error(), `unknown action "refersh"`) {
+		t.Fatalf("expected an unknown action error, got %v", err)
+	}
+}
+
+func TestCustomKeysInTUI(t *testing.T) {
+	writeAppConfig(t, "keymap = \"emacs\"\n[keys]\nprofiles = \"P\"\n")
+	m := windowedModel(t)
+	m.isLoading = false
+
+	// The help reflects the configured keys.
+	m.help.ShowAll = true
+	if v := m.View(); !strings.Contains(v, "/, ctrl+s") || strings.Contains(v, "esc/h") || !strings.Contains(v, "esc/ctrl+g") {
+		t.Fatalf("help does not show the custom bindings:\n%s", v)
+	}
+
+	m, _ = press(t, m, runeKey('p'))
+	if m.state != viewNetworksList {
+		t.Fatalf("p is no longer bound")
+	}
+	m, _ = press(t, m, runeKey('P'))
+	if m.state != viewKnownNetworksList {
+		t.Fatalf("P should open the profiles, state %v", m.state)
+	}
+	// h is not back in the emacs keymap, ctrl+g is.
+	m, _ = press(t, m, runeKey('h'))
+	if m.state != viewKnownNetworksList {
+		t.Fatalf("h should not go back")
+	}
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	if m.state != viewNetworksList {
+		t.Fatalf("ctrl+g should go back, state %v", m.state)
+	}
+}
+
+func TestCustomListKeysInCleanup(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	installFakeCommand(t, "nmcli", cleanupFakeNmcli)
+	writeAppConfig(t, "[keys]\nselect_all = \"A\"\nconfirm = \"Y\"\nthreshold_up = \">\"\n")
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	m, _ = press(t, m, runeKey('C'))
+	updated, _ := m.Update(scanStaleProfilesCmd(m.cleanup.age)())
+	m = updated.(model)
+	if v := m.View(); !strings.Contains(v, "A: all/none") || !strings.Contains(v, ">/-: threshold") {
+		t.Fatalf("the hint should show the configured keys:\n%s", v)
+	}
+
+	m, _ = press(t, m, runeKey('a'))
+	if len(m.cleanup.selectedProfiles()) != 0 {
+		t.Fatalf("a is no longer bound")
+	}
+	m, _ = press(t, m, runeKey('A'), enterKey)
+	if !m.cleanup.confirming || !strings.Contains(m.connectionStatusMsg, "Delete 2 profile(s)? Y/n") {
+		t.Fatalf("expected a confirmation, status %q", m.connectionStatusMsg)
+	}
+	m, _ = press(t, m, runeKey('y'))
+	if !m.cleanup.confirming {
+		t.Fatalf("y is no longer the confirm key")
+	}
+	m, cmd := press(t, m, runeKey('Y'))
+	if m.cleanup.confirming || cmd == nil {
+		t.Fatalf("Y should confirm")
+	}
+}
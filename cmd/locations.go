@@ -0,0 +1,819 @@
+// nmtui/cmd/locations.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	locationsFileName     = "locations.toml"
+	locationStateFileName = "location.json"
+)
+
+// --- Config file ---
+
+// locationsConfig is the hand-edited list of location sets, e.g.
+//
+//	auto_detect = true
+//
+//	[[location]]
+//	name = "Office"
+//	bssids = ["AA:BB:CC:DD:EE:FF"]
+//
+//	  [[location.profile]]
+//	  name = "Office WiFi"
+//	  autoconnect = true
+//	  priority = 20
+//	  dns = ["10.1.0.53"]
+//
+//	  [[location.profile]]
+//	  name = "Corp VPN"
+//	  active = true
+type locationsConfig struct {
+	AutoDetect bool          `toml:"auto_detect"`
+	Locations  []locationSet `toml:"location"`
+}
+
+// locationSet is a named group of profile settings applied together.
+type locationSet struct {
+	Name     string            `toml:"name"`
+	BSSIDs   []string          `toml:"bssids"`
+	Profiles []locationProfile `toml:"profile"`
+}
+
+// locationProfile is what a location does to one profile. Unset fields are
+// left alone.
+type locationProfile struct {
+	Name        string   `toml:"name"` // profile name or UUID
+	Autoconnect *bool    `toml:"autoconnect"`
+	Priority    *int     `toml:"priority"`
+	DNS         []string `toml:"dns"`    // IPv4 and IPv6 servers; the profile's own DNS is put back on leaving
+	Active      *bool    `toml:"active"` // true connects, false disconnects
+}
+
+// appConfigDir is $XDG_CONFIG_HOME/nmtui-go. Unlike appDataDir it is not
+// created: the files in it are written by the user.
+func appConfigDir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine config directory: %w", err)
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "nmtui-go"), nil
+}
+
+func locationsPath() (string, error) {
+	dir, err := appConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, locationsFileName), nil
+}
+
+// loadLocations reads the location sets. A missing file is an empty config.
+func loadLocations() (locationsConfig, error) {
+	var cfg locationsConfig
+	path, err := locationsPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("parsing %s: unknown key %q", path, undecoded[0].String())
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *locationsConfig) validate() error {
+	seen := make(map[string]bool)
+	for i := range c.Locations {
+		l := &c.Locations[i]
+		l.Name = strings.TrimSpace(l.Name)
+		if l.Name == "" {
+			return fmt.Errorf("location #%d: name is required", i+1)
+		}
+		if seen[strings.ToLower(l.Name)] {
+			return fmt.Errorf("location %q is defined more than once", l.Name)
+		}
+		seen[strings.ToLower(l.Name)] = true
+		for j, b := range l.BSSIDs {
+			l.BSSIDs[j] = strings.ToUpper(strings.TrimSpace(b))
+		}
+		for j := range l.Profiles {
+			p := &l.Profiles[j]
+			p.Name = strings.TrimSpace(p.Name)
+			if p.Name == "" {
+				return fmt.Errorf("location %q, profile #%d: name is required", l.Name, j+1)
+			}
+			if p.Autoconnect == nil && p.Priority == nil && len(p.DNS) == 0 && p.Active == nil {
+				return fmt.Errorf("location %q, profile %q: nothing to change (set autoconnect, priority, dns or active)", l.Name, p.Name)
+			}
+			for _, d := range p.DNS {
+				if net.ParseIP(d) == nil {
+					return fmt.Errorf("location %q, profile %q: DNS server %q is not an IP address", l.Name, p.Name, d)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c locationsConfig) find(name string) (locationSet, bool) {
+	for _, l := range c.Locations {
+		if strings.EqualFold(l.Name, strings.TrimSpace(name)) {
+			return l, true
+		}
+	}
+	return locationSet{}, false
+}
+
+// settings returns the profile properties the location sets.
+func (p locationProfile) settings() []gonetworkmanager.ConnectionSetting {
+	var s []gonetworkmanager.ConnectionSetting
+	if p.Autoconnect != nil {
+		s = append(s, gonetworkmanager.ConnectionSetting{Key: nmPropAutoconnect, Value: map[bool]string{true: "yes", false: "no"}[*p.Autoconnect]})
+	}
+	if p.Priority != nil {
+		s = append(s, gonetworkmanager.ConnectionSetting{Key: nmPropPriority, Value: strconv.Itoa(*p.Priority)})
+	}
+	return append(s, p.dnsSettings()...)
+}
+
+// locationDNSProps are the properties a location's dns list overrides and
+// that are put back when a location no longer sets them.
+var locationDNSProps = []string{"ipv4.dns", "ipv4.ignore-auto-dns", "ipv6.dns", "ipv6.ignore-auto-dns"}
+
+// dnsSettings sends each server to the address family it belongs to and
+// ignores the DHCP/RA servers of that family.
+func (p locationProfile) dnsSettings() []gonetworkmanager.ConnectionSetting {
+	var v4, v6 []string
+	for _, d := range p.DNS {
+		if ip := net.ParseIP(d); ip != nil && ip.To4() == nil {
+			v6 = append(v6, d)
+		} else {
+			v4 = append(v4, d)
+		}
+	}
+	var s []gonetworkmanager.ConnectionSetting
+	if len(v4) > 0 {
+		s = append(s,
+			gonetworkmanager.ConnectionSetting{Key: "ipv4.dns", Value: strings.Join(v4, ",")},
+			gonetworkmanager.ConnectionSetting{Key: "ipv4.ignore-auto-dns", Value: "yes"})
+	}
+	if len(v6) > 0 {
+		s = append(s,
+			gonetworkmanager.ConnectionSetting{Key: "ipv6.dns", Value: strings.Join(v6, ",")},
+			gonetworkmanager.ConnectionSetting{Key: "ipv6.ignore-auto-dns", Value: "yes"})
+	}
+	return s
+}
+
+// currentDNSSettings reads the locationDNSProps of a profile; unset
+// properties are returned empty so that writing them back clears them.
+func currentDNSSettings(profile string) (map[string]string, error) {
+	props, err := gonetworkmanager.GetProfileProperties([]string{profile}, locationDNSProps...)
+	if err != nil {
+		return nil, err
+	}
+	for _, values := range props {
+		current := make(map[string]string, len(locationDNSProps))
+		for _, k := range locationDNSProps {
+			current[k] = values[k]
+		}
+		return current, nil
+	}
+	return nil, fmt.Errorf("profile %s not found", profile)
+}
+
+func savedDNSSettings(saved map[string]string) []gonetworkmanager.ConnectionSetting {
+	var s []gonetworkmanager.ConnectionSetting
+	for _, k := range locationDNSProps {
+		s = append(s, gonetworkmanager.ConnectionSetting{Key: k, Value: saved[k]})
+	}
+	return s
+}
+
+// describe summarises the changes, e.g. "autoconnect on, priority 20, connect".
+func (p locationProfile) describe() string {
+	var parts []string
+	if p.Autoconnect != nil {
+		parts = append(parts, "autoconnect "+map[bool]string{true: "on", false: "off"}[*p.Autoconnect])
+	}
+	if p.Priority != nil {
+		parts = append(parts, fmt.Sprintf("priority %d", *p.Priority))
+	}
+	if len(p.DNS) > 0 {
+		parts = append(parts, "DNS "+strings.Join(p.DNS, ", "))
+	}
+	if p.Active != nil {
+		parts = append(parts, map[bool]string{true: "connect", false: "disconnect"}[*p.Active])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// detectLocation picks the location with the most of its BSSIDs in range.
+// Ties go to the location listed first.
+func detectLocation(cfg locationsConfig, inRange map[string]bool) (string, bool) {
+	best, bestHits := "", 0
+	for _, l := range cfg.Locations {
+		hits := 0
+		for _, b := range l.BSSIDs {
+			if inRange[b] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = l.Name, hits
+		}
+	}
+	return best, bestHits > 0
+}
+
+// inRangeBSSIDs returns the upper-cased BSSIDs in a scan result.
+func inRangeBSSIDs(aps []gonetworkmanager.WifiAccessPoint) map[string]bool {
+	inRange := make(map[string]bool)
+	for _, ap := range aps {
+		if b := strings.ToUpper(strings.TrimSpace(ap[gonetworkmanager.NmcliFieldWifiBSSID])); b != "" && b != "--" {
+			inRange[b] = true
+		}
+	}
+	return inRange
+}
+
+// --- State ---
+
+// locationState remembers the last switch and the last detection, so that
+// auto-detect only acts when the detected location changes and a manual
+// switch is not undone by the next scan.
+type locationState struct {
+	Current  string    `json:"current,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	Detected string    `json:"detected,omitempty"`
+	// SavedDNS holds, per profile, the DNS settings a location's dns list
+	// replaced, to be written back once a location no longer sets them.
+	SavedDNS map[string]map[string]string `json:"saved_dns,omitempty"`
+}
+
+// locationMu serialises read-modify-write of the state file.
+var locationMu sync.Mutex
+
+func locationStatePath() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, locationStateFileName), nil
+}
+
+// loadLocationState reads the state; a missing file counts as empty. Any
+// other failure is returned, since the file holds the profiles' own DNS
+// settings and must not be written over.
+func loadLocationState() (locationState, error) {
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	return loadLocationStateLocked()
+}
+
+func loadLocationStateLocked() (locationState, error) {
+	var s locationState
+	path, err := locationStatePath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return locationState{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func updateLocationState(fn func(*locationState)) error {
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	s, err := loadLocationStateLocked()
+	if err != nil {
+		return err
+	}
+	fn(&s)
+	path, err := locationStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// --- Switching ---
+
+// switchLocation applies a location: first the profile settings, then the
+// disconnects, then the connects in the order they are listed (so a VPN
+// listed after its Wi-Fi comes up once the Wi-Fi is connected). A profile
+// whose DNS the previous location set and this one does not gets its own DNS
+// settings back. Profiles that stay up get their new DNS servers reapplied.
+// Every step is attempted even if an earlier one fails. The changes are not
+// snapshotted: the locations file already describes them. Nothing is changed
+// when the state file cannot be read.
+func switchLocation(l locationSet, now time.Time) ([]profileResult, error) {
+	var results []profileResult
+	state, err := loadLocationState()
+	if err != nil {
+		return nil, fmt.Errorf("not switching: %w", err)
+	}
+	saved := state.SavedDNS
+	if saved == nil {
+		saved = make(map[string]map[string]string)
+	}
+	setsDNS := make(map[string]bool)
+	for _, p := range l.Profiles {
+		setsDNS[p.Name] = len(p.DNS) > 0
+	}
+	var changedDNS []string
+	var restore []string
+	for name := range saved {
+		if !setsDNS[name] {
+			restore = append(restore, name)
+		}
+	}
+	sort.Strings(restore)
+	for _, name := range restore {
+		_, err := gonetworkmanager.ModifyConnectionUnrecorded(name, savedDNSSettings(saved[name]))
+		results = append(results, profileResult{Name: name, Err: err})
+		if err == nil {
+			delete(saved, name)
+			changedDNS = append(changedDNS, name)
+		}
+	}
+	for _, p := range l.Profiles {
+		s := p.settings()
+		if len(s) == 0 {
+			continue
+		}
+		var previous map[string]string
+		if _, ok := saved[p.Name]; !ok && len(p.DNS) > 0 {
+			var err error
+			if previous, err = currentDNSSettings(p.Name); err != nil {
+				results = append(results, profileResult{Name: p.Name, Err: fmt.Errorf("reading its DNS settings: %w", err)})
+				continue
+			}
+		}
+		_, err := gonetworkmanager.ModifyConnectionUnrecorded(p.Name, s)
+		results = append(results, profileResult{Name: p.Name, Err: err})
+		if err == nil && len(p.DNS) > 0 {
+			if previous != nil {
+				saved[p.Name] = previous
+			}
+			changedDNS = append(changedDNS, p.Name)
+		}
+	}
+
+	active := make(map[string]bool)
+	devices := make(map[string]string)
+	if profiles, err := gonetworkmanager.GetConnectionProfilesList(true); err == nil {
+		for _, p := range profiles {
+			active[p[gonetworkmanager.NmcliFieldConnectionName]] = true
+			active[p[gonetworkmanager.NmcliFieldConnectionUUID]] = true
+			if dev := p[gonetworkmanager.NmcliFieldConnectionDevice]; dev != "--" {
+				devices[p[gonetworkmanager.NmcliFieldConnectionName]] = dev
+				devices[p[gonetworkmanager.NmcliFieldConnectionUUID]] = dev
+			}
+		}
+	}
+	goingDown := make(map[string]bool)
+	for _, p := range l.Profiles {
+		goingDown[p.Name] = p.Active != nil && !*p.Active
+	}
+	// Settings such as DNS only reach a running connection when it is
+	// reapplied; profiles without a device of their own are brought up again.
+	for _, name := range changedDNS {
+		if !active[name] || goingDown[name] {
+			continue
+		}
+		var err error
+		if dev := devices[name]; dev != "" {
+			_, err = gonetworkmanager.DeviceReapply(dev)
+		} else {
+			_, err = gonetworkmanager.ConnectionUp(name)
+		}
+		results = append(results, profileResult{Name: name, Err: err})
+	}
+	for _, p := range l.Profiles {
+		if p.Active != nil && !*p.Active && active[p.Name] {
+			_, err := gonetworkmanager.ConnectionDown(p.Name)
+			results = append(results, profileResult{Name: p.Name, Err: err})
+		}
+	}
+	for _, p := range l.Profiles {
+		if p.Active != nil && *p.Active && !active[p.Name] {
+			_, err := gonetworkmanager.ConnectionUp(p.Name)
+			results = append(results, profileResult{Name: p.Name, Err: err})
+		}
+	}
+
+	if err := updateLocationState(func(s *locationState) {
+		s.Current = l.Name
+		s.Since = now
+		s.SavedDNS = saved
+	}); err != nil {
+		log.Printf("Location: could not save state: %v", err)
+	}
+	return results, nil
+}
+
+// --- CLI ---
+
+// runLocationCLI implements `nmtui-go location [list | switch NAME | detect]`.
+func runLocationCLI(args []string, stdout, stderr io.Writer) int {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("location "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	doSwitch := fs.Bool("switch", false, "switch to the detected location unless it is already current")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loadLocations()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	path, _ := locationsPath()
+	if len(cfg.Locations) == 0 {
+		fmt.Fprintf(stderr, "No locations defined in %s.\n", path)
+		return 1
+	}
+	apply := func(l locationSet) int {
+		results, err := switchLocation(l, time.Now())
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		failed := false
+		for _, r := range results {
+			if r.Err != nil {
+				failed = true
+				fmt.Fprintf(stdout, "  ✗ %s: %v\n", r.Name, r.Err)
+			} else {
+				fmt.Fprintf(stdout, "  ✓ %s\n", r.Name)
+			}
+		}
+		if failed {
+			fmt.Fprintf(stdout, "Switched to %s with errors.\n", l.Name)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Switched to %s.\n", l.Name)
+		return 0
+	}
+
+	switch sub {
+	case "list":
+		state, err := loadLocationState()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		printLocations(stdout, cfg, state)
+		return 0
+	case "switch":
+		if fs.NArg() != 1 {
+			fmt.Fprintf(stderr, "Usage: %s location switch NAME\n", effectiveAppName())
+			return 2
+		}
+		l, ok := cfg.find(fs.Arg(0))
+		if !ok {
+			fmt.Fprintf(stderr, "Error: no location named %q in %s\n", fs.Arg(0), path)
+			return 1
+		}
+		return apply(l)
+	case "detect":
+		aps, err := gonetworkmanager.GetWifiList(true)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		name, ok := detectLocation(cfg, inRangeBSSIDs(aps))
+		if !ok {
+			fmt.Fprintln(stdout, "No location detected (none of the configured BSSIDs is in range).")
+			return 1
+		}
+		fmt.Fprintf(stdout, "Detected %s.\n", name)
+		state, err := loadLocationState()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		_ = updateLocationState(func(s *locationState) { s.Detected = name })
+		if !*doSwitch {
+			return 0
+		}
+		if state.Current == name {
+			fmt.Fprintf(stdout, "%s is already the current location.\n", name)
+			return 0
+		}
+		l, _ := cfg.find(name)
+		return apply(l)
+	default:
+		fmt.Fprintf(stderr, "Unknown location command %q (use list, switch or detect)\n", sub)
+		return 2
+	}
+}
+
+func printLocations(w io.Writer, cfg locationsConfig, state locationState) {
+	for _, l := range cfg.Locations {
+		mark := " "
+		if l.Name == state.Current {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s %s", mark, l.Name)
+		if len(l.BSSIDs) > 0 {
+			fmt.Fprintf(w, " (%d BSSID(s))", len(l.BSSIDs))
+		}
+		fmt.Fprintln(w)
+		for _, p := range l.Profiles {
+			fmt.Fprintf(w, "    %s: %s\n", p.Name, p.describe())
+		}
+	}
+	if state.Current != "" {
+		fmt.Fprintf(w, "Current: %s (since %s)\n", state.Current, state.Since.Format("2006-01-02 15:04"))
+	}
+}
+
+// --- TUI ---
+
+type locationScreen struct {
+	cfg      locationsConfig
+	state    locationState
+	detected string
+	cursor   int
+	returnTo viewState
+}
+
+type locationsLoadedMsg struct {
+	cfg   locationsConfig
+	state locationState
+	err   error
+}
+
+type locationSwitchedMsg struct {
+	name    string
+	auto    bool
+	results []profileResult
+	err     error
+}
+
+func loadLocationsCmd() tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := loadLocations()
+		state, stateErr := loadLocationState()
+		return locationsLoadedMsg{cfg: cfg, state: state, err: errors.Join(err, stateErr)}
+	}
+}
+
+func switchLocationCmd(l locationSet, auto bool) tea.Cmd {
+	return func() tea.Msg {
+		results, err := switchLocation(l, time.Now())
+		return locationSwitchedMsg{name: l.Name, auto: auto, results: results, err: err}
+	}
+}
+
+// autoDetectLocationCmd switches location when auto_detect is on and the
+// location detected from the scan differs from the previous detection.
+func autoDetectLocationCmd(aps []wifiAP) tea.Cmd {
+	raw := make([]gonetworkmanager.WifiAccessPoint, 0, len(aps))
+	for _, ap := range aps {
+		raw = append(raw, ap.WifiAccessPoint)
+	}
+	return func() tea.Msg {
+		cfg, err := loadLocations()
+		if err != nil || !cfg.AutoDetect {
+			return nil
+		}
+		name, ok := detectLocation(cfg, inRangeBSSIDs(raw))
+		if !ok {
+			return nil
+		}
+		state, err := loadLocationState()
+		if err != nil {
+			log.Printf("Location: not switching to %s: %v", name, err)
+			return nil
+		}
+		if name == state.Detected {
+			return nil
+		}
+		_ = updateLocationState(func(s *locationState) { s.Detected = name })
+		if name == state.Current {
+			return nil
+		}
+		l, _ := cfg.find(name)
+		log.Printf("Location: detected %s, switching", name)
+		results, err := switchLocation(l, time.Now())
+		return locationSwitchedMsg{name: name, auto: true, results: results, err: err}
+	}
+}
+
+func (m *model) openLocations() []tea.Cmd {
+	m.locations = &locationScreen{returnTo: m.state}
+	m.state = viewLocations
+	m.isLoading = true
+	m.clearStatus()
+	return []tea.Cmd{loadLocationsCmd(), m.spinner.Tick}
+}
+
+func (m *model) closeLocations() {
+	if m.locations != nil {
+		m.state = m.locations.returnTo
+	}
+	m.locations = nil
+	m.isLoading = false
+	m.clearStatus()
+	m.resizeComponents()
+}
+
+func (m *model) handleLocationsLoaded(msg locationsLoadedMsg) {
+	l := m.locations
+	if l == nil {
+		return
+	}
+	m.isLoading = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not load locations: %v", msg.err), errorStyle)
+	}
+	l.cfg = msg.cfg
+	l.state = msg.state
+	raw := make([]gonetworkmanager.WifiAccessPoint, 0, len(m.allScannedAps))
+	for _, ap := range m.allScannedAps {
+		raw = append(raw, ap.WifiAccessPoint)
+	}
+	l.detected, _ = detectLocation(l.cfg, inRangeBSSIDs(raw))
+	l.cursor = 0
+	for i, loc := range l.cfg.Locations {
+		if loc.Name == l.state.Current {
+			l.cursor = i
+		}
+	}
+}
+
+func (m *model) handleLocationsKeys(msg tea.KeyMsg) []tea.Cmd {
+	l := m.locations
+	if l == nil {
+		return nil
+	}
+	if m.isLoading {
+		return nil
+	}
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.closeLocations()
+	case msg.String() == "up":
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case msg.String() == "down":
+		if l.cursor < len(l.cfg.Locations)-1 {
+			l.cursor++
+		}
+	case key.Matches(msg, m.keys.Refresh):
+		m.isLoading = true
+		return []tea.Cmd{loadLocationsCmd(), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Connect):
+		if len(l.cfg.Locations) == 0 {
+			return nil
+		}
+		loc := l.cfg.Locations[l.cursor]
+		m.isLoading = true
+		m.setStatus(fmt.Sprintf("Switching to %s...", loc.Name), connectingStyle)
+		return []tea.Cmd{switchLocationCmd(loc, false), m.spinner.Tick}
+	}
+	return nil
+}
+
+func (m *model) handleLocationSwitched(msg locationSwitchedMsg) []tea.Cmd {
+	verb := fmt.Sprintf("Switched to %s: updated", msg.name)
+	if msg.auto {
+		verb = fmt.Sprintf("Detected %s and switched: updated", msg.name)
+	}
+	summary, ok := summarizeResults(verb, msg.results)
+	style := successStyle
+	if !ok {
+		style = errorStyle
+	}
+	if msg.err != nil {
+		summary, style = fmt.Sprintf("Could not switch to %s: %v", msg.name, msg.err), errorStyle
+	}
+	if m.locations != nil {
+		m.isLoading = false
+		m.closeLocations()
+	}
+	if msg.auto && m.state != viewNetworksList && m.state != viewKnownNetworksList {
+		return []tea.Cmd{fetchKnownNetworksCmd()}
+	}
+	m.setStatus(summary, style)
+	if m.state == viewKnownNetworksList {
+		m.isLoading = true
+		m.knownWifiList.Title = "Loading Profiles..."
+		return []tea.Cmd{fetchKnownWifiApsCmd(), m.spinner.Tick}
+	}
+	return []tea.Cmd{fetchKnownNetworksCmd()}
+}
+
+func (m model) locationsView(width, height int) string {
+	l := m.locations
+	if l == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	good := lipgloss.NewStyle().Foreground(ansSuccessColor)
+	lines := []string{titleStyle.Render("Locations")}
+	if l.state.Current != "" {
+		lines = append(lines, label.Render("Current: ")+good.Bold(true).Render(l.state.Current)+label.Render(" since "+l.state.Since.Format("Jan 2 15:04")))
+	}
+	lines = append(lines, "")
+
+	switch {
+	case m.isLoading && len(l.cfg.Locations) == 0:
+		lines = append(lines, connectingStyle.Render(m.spinner.View()+" Loading locations..."))
+	case len(l.cfg.Locations) == 0:
+		path, _ := locationsPath()
+		lines = append(lines, label.Render("No locations defined. Add [[location]] tables to "+path+" (see README)."))
+	}
+	for i, loc := range l.cfg.Locations {
+		var notes []string
+		if loc.Name == l.state.Current {
+			notes = append(notes, good.Render("current"))
+		}
+		if loc.Name == l.detected {
+			notes = append(notes, good.Render("detected here"))
+		}
+		line := fmt.Sprintf("%-24s %s %s", truncateRunes(loc.Name, 24), label.Render(fmt.Sprintf("%d profile(s)", len(loc.Profiles))), strings.Join(notes, " "))
+		if i == l.cursor {
+			line = listSelectedItemStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if len(l.cfg.Locations) > 0 {
+		lines = append(lines, "")
+		for _, p := range l.cfg.Locations[l.cursor].Profiles {
+			lines = append(lines, label.Render("  "+truncateRunes(p.Name, 24)+": ")+p.describe())
+		}
+	}
+	if l.cfg.AutoDetect {
+		lines = append(lines, "", label.Render("Auto-detect is on: the location switches when its BSSIDs come into range."))
+	}
+	lines = append(lines, "", label.Render(fmt.Sprintf("↑/↓: select  %s: switch  %s: reload  %s: back", m.keys.Connect.Help().Key, m.keys.Refresh.Help().Key, m.keys.Back.Help().Key)))
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+const testLocations = `
+auto_detect = true
+
+[[location]]
+name = "Home"
+bssids = ["aa:aa:aa:aa:aa:01"]
+
+  [[location.profile]]
+  name = "HomeNet"
+  autoconnect = true
+  priority = 10
+
+  [[location.profile]]
+  name = "Corp VPN"
+  active = false
+
+[[location]]
+name = "Office"
+bssids = ["BB:BB:BB:BB:BB:01", "BB:BB:BB:BB:BB:02"]
+
+  [[location.profile]]
+  name = "HomeNet"
+  autoconnect = false
+
+  [[location.profile]]
+  name = "OfficeNet"
+  autoconnect = true
+  dns = ["10.1.0.53", "10.1.0.54"]
+  active = true
+
+  [[location.profile]]
+  name = "Corp VPN"
+  active = true
+`
+
+func writeLocations(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "nmtui-go"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nmtui-go", locationsFileName), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadLocationsAndDetect(t *testing.T) {
+	writeLocations(t, testLocations)
+	cfg, err := loadLocations()
+	if err != nil {
+		t.Fatalf("loadLocations: %v", err)
+	}
+	if !cfg.AutoDetect || len(cfg.Locations) != 2 || len(cfg.Locations[1].Profiles) != 3 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if d := cfg.Locations[1].Profiles[1].describe(); d != "autoconnect on, DNS 10.1.0.53, 10.1.0.54, connect" {
+		t.Fatalf("unexpected description %q", d)
+	}
+
+	// BSSIDs are compared case-insensitively; the location with most hits wins.
+	inRange := inRangeBSSIDs([]gonetworkmanager.WifiAccessPoint{
+		{gonetworkmanager.NmcliFieldWifiBSSID: "AA:AA:AA:AA:AA:01"},
+		{gonetworkmanager.NmcliFieldWifiBSSID: "bb:bb:bb:bb:bb:01"},
+		{gonetworkmanager.NmcliFieldWifiBSSID: "BB:BB:BB:BB:BB:02"},
+	})
+	if name, ok := detectLocation(cfg, inRange); !ok || name != "Office" {
+		t.Fatalf("expected Office, got %q %v", name, ok)
+	}
+	if _, ok := detectLocation(cfg, map[string]bool{"CC:CC:CC:CC:CC:CC": true}); ok {
+		t.Fatalf("nothing should be detected")
+	}
+
+	for _, bad := range []string{
+		"[[location]]\nname = \"A\"\n[[location]]\nname = \"a\"\n",
+		"[[location]]\nname = \"A\"\n[[location.profile]]\nname = \"X\"\n",
+		"[[location]]\nname = \"A\"\ncolour = \"red\"\n",
+		"[[location]]\nname = \"A\"\n[[location.profile]]\nname = \"X\"\ndns = [\"dns.example\"]\n",
+	} {
+		writeLocations(t, bad)
+		if _, err := loadLocations(); err == nil {
+			t.Errorf("expected an error for:\n%s", bad)
+		}
+	}
+}
+
+const locationsFakeNmcli = `
+case "$*" in
+  "-m multiline connection show --order name --active")
+    printf 'NAME: HomeNet\nUUID: u-home\nTYPE: wifi\nDEVICE: wlan0\n' ;;
+  "-m multiline device wifi list --rescan yes")
+    printf 'BSSID: BB:BB:BB:BB:BB:02\nSSID: OfficeNet\n' ;;
+  "-m multiline -f connection.uuid,ipv4.dns,ipv4.ignore-auto-dns,ipv6.dns,ipv6.ignore-auto-dns connection show "*)
+    printf 'connection.uuid: u-x\nipv4.dns: 192.168.1.1\nipv4.ignore-auto-dns: no\nipv6.dns: --\nipv6.ignore-auto-dns: no\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestLocationSwitchCLI(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	writeLocations(t, testLocations)
+	installFakeCommand(t, "nmcli", locationsFakeNmcli)
+
+	var out, errOut bytes.Buffer
+	if code := runLocationCLI([]string{"switch", "nowhere"}, &out, &errOut); code != 1 {
+		t.Fatalf("expected a failure for an unknown location, got %d", code)
+	}
+	if code := runLocationCLI([]string{"detect", "--switch"}, &out, &errOut); code != 0 {
+		t.Fatalf("detect failed (%d): %s%s", code, out.String(), errOut.String())
+	}
+	if !strings.Contains(out.String(), "Detected Office.") || !strings.Contains(out.String(), "Switched to Office.") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	want := "connection modify HomeNet connection.autoconnect no\n" +
+		"connection modify OfficeNet connection.autoconnect yes ipv4.dns 10.1.0.53,10.1.0.54 ipv4.ignore-auto-dns yes\n" +
+		"connection up OfficeNet\n" +
+		"connection up Corp VPN\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+	if s, err := loadLocationState(); err != nil || s.Current != "Office" || s.Detected != "Office" {
+		t.Fatalf("unexpected state %+v", s)
+	}
+
+	// Switching back disconnects only what is active, and OfficeNet gets
+	// the DNS settings it had before Office set its own.
+	os.Remove(filepath.Join(state, "calls"))
+	out.Reset()
+	if code := runLocationCLI([]string{"switch", "home"}, &out, &errOut); code != 0 {
+		t.Fatalf("switch failed: %s", out.String())
+	}
+	calls, _ = os.ReadFile(filepath.Join(state, "calls"))
+	want = "connection modify OfficeNet ipv4.dns 192.168.1.1 ipv4.ignore-auto-dns no ipv6.dns  ipv6.ignore-auto-dns no\n" +
+		"connection modify HomeNet connection.autoconnect yes connection.autoconnect-priority 10\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+	if s, err := loadLocationState(); err != nil || len(s.SavedDNS) != 0 {
+		t.Fatalf("restored DNS settings should be forgotten: %+v", s.SavedDNS)
+	}
+	out.Reset()
+	runLocationCLI(nil, &out, &errOut)
+	if !strings.Contains(out.String(), "* Home") || !strings.Contains(out.String(), "  Office (2 BSSID(s))") {
+		t.Fatalf("unexpected list:\n%s", out.String())
+	}
+}
+
+func TestLocationSwitchKeepsCorruptState(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	writeLocations(t, testLocations)
+	installFakeCommand(t, "nmcli", locationsFakeNmcli)
+	path, err := locationStatePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := []byte(`{"current": "Office", "saved_dns": {"OfficeNet": `)
+	if err := os.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runLocationCLI([]string{"switch", "home"}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "not switching") {
+		t.Fatalf("expected the switch to be refused, got %d: %s", code, errOut.String())
+	}
+	if calls, _ := os.ReadFile(filepath.Join(state, "calls")); len(calls) != 0 {
+		t.Fatalf("no profile may be changed:\n%s", calls)
+	}
+	if err := updateLocationState(func(s *locationState) { s.Detected = "Home" }); err == nil {
+		t.Fatal("expected the update to fail on a corrupt state file")
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, corrupt) {
+		t.Fatalf("the state file was overwritten:\n%s", data)
+	}
+}
+
+func TestLocationSwitchReappliesActiveProfiles(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	writeLocations(t, `
+[[location]]
+name = "Travel"
+
+  [[location.profile]]
+  name = "HomeNet"
+  dns = ["9.9.9.9", "2620:fe::fe"]
+`)
+	installFakeCommand(t, "nmcli", locationsFakeNmcli)
+	// Location switches take no snapshots.
+	gonetworkmanager.BeforeProfileChange = func(id, op string) error {
+		t.Errorf("unexpected snapshot of %s before %s", id, op)
+		return nil
+	}
+	t.Cleanup(func() { gonetworkmanager.BeforeProfileChange = nil })
+
+	var out, errOut bytes.Buffer
+	if code := runLocationCLI([]string{"switch", "Travel"}, &out, &errOut); code != 0 {
+		t.Fatalf("switch failed: %s", out.String())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	want := "connection modify HomeNet ipv4.dns 9.9.9.9 ipv4.ignore-auto-dns yes ipv6.dns 2620:fe::fe ipv6.ignore-auto-dns yes\n" +
+		"device reapply wlan0\n"
+	if string(calls) != want {
+		t.Fatalf("the active profile should get its DNS reapplied:\n%s", calls)
+	}
+}
+
+func TestLocationPickerAndAutoDetect(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	writeLocations(t, testLocations)
+	installFakeCommand(t, "nmcli", locationsFakeNmcli)
+
+	m := windowedModel(t)
+	m.isLoading = false
+	m.allScannedAps = []wifiAP{{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{gonetworkmanager.NmcliFieldWifiBSSID: "aa:aa:aa:aa:aa:01"}}}
+	m, _ = press(t, m, runeKey('L'))
+	if m.state != viewLocations {
+		t.Fatalf("expected the location picker")
+	}
+	updated, _ := m.Update(loadLocationsCmd()())
+	m = updated.(model)
+	if v := m.View(); !strings.Contains(v, "detected here") || !strings.Contains(v, "HomeNet: autoconnect on, priority 10") {
+		t.Fatalf("unexpected picker:\n%s", v)
+	}
+	m, cmd := press(t, m, enterKey)
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if m.state != viewNetworksList || !strings.Contains(m.connectionStatusMsg, "Switched to Home: updated 1 profile(s).") {
+		t.Fatalf("unexpected status %q", m.connectionStatusMsg)
+	}
+
+	// Office comes into range: auto-detect switches once, and not again
+	// while it stays detected.
+	office := []wifiAP{{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{gonetworkmanager.NmcliFieldWifiBSSID: "BB:BB:BB:BB:BB:01"}}}
+	msg, ok := autoDetectLocationCmd(office)().(locationSwitchedMsg)
+	if !ok || msg.name != "Office" || !msg.auto {
+		t.Fatalf("expected an automatic switch to Office, got %+v", msg)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(model)
+	if !strings.Contains(m.connectionStatusMsg, "Detected Office and switched") {
+		t.Fatalf("unexpected status %q", m.connectionStatusMsg)
+	}
+	if err := updateLocationState(func(s *locationState) { s.Current = "Home" }); err != nil {
+		t.Fatal(err)
+	}
+	if msg := autoDetectLocationCmd(office)(); msg != nil {
+		t.Fatalf("a manual switch must not be undone while the detection is unchanged: %+v", msg)
+	}
+}
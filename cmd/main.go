@@ -12,10 +12,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -35,46 +37,66 @@ const (
 	cacheFileName           = "nmtui-cache.json"
 	helpBarMaxWidth         = 80
 	helpBarWidthPercent     = 0.80
-	networkListFixedWidth   = 100
 	networkListWidthPercent = 0.85
 )
 
+// networkListFixedWidth caps the list width; set from network_list_width.
+var networkListFixedWidth = 100
+
 // --- Styles Definition ---
+
+// Colors of the active theme; see theme.go. The styles below are built from
+// them by buildStyles whenever the theme changes.
+var (
+	ansPrimaryColor   lipgloss.TerminalColor
+	ansSecondaryColor lipgloss.TerminalColor
+	ansAccentColor    lipgloss.TerminalColor
+	ansSuccessColor   lipgloss.TerminalColor
+	ansErrorColor     lipgloss.TerminalColor
+	ansWarningColor   lipgloss.TerminalColor
+	ansFaintTextColor lipgloss.TerminalColor
+	ansTextColor      lipgloss.TerminalColor
+	ansBorderColor    lipgloss.TerminalColor
+)
+
 var (
 	appStyle = lipgloss.NewStyle().Margin(1, 1)
 
-	ansPrimaryColor   = lipgloss.Color("5")
-	ansSecondaryColor = lipgloss.Color("4")
-	ansAccentColor    = lipgloss.Color("6")
-	ansSuccessColor   = lipgloss.Color("2")
-	ansErrorColor     = lipgloss.Color("1")
-	ansFaintTextColor = lipgloss.Color("8")
-	ansTextColor      = lipgloss.Color("7")
-
-	titleStyle            = lipgloss.NewStyle().Bold(true).Foreground(ansPrimaryColor).Padding(0, 1).MarginBottom(1)
-	listTitleStyle        = lipgloss.NewStyle().Foreground(ansSecondaryColor).Padding(0, 1).Bold(true)
-	listItemStyle         = lipgloss.NewStyle().PaddingLeft(2).Foreground(ansTextColor)
+	titleStyle, listTitleStyle, listItemStyle, listSelectedItemStyle            lipgloss.Style
+	listDescStyle, listSelectedDescStyle, listNoItemsStyle                      lipgloss.Style
+	statusMessageBaseStyle, errorStyle, connectingStyle, successStyle           lipgloss.Style
+	infoBoxStyle, toggleHiddenStatusMsgStyle                                    lipgloss.Style
+	passwordPromptStyle, passwordInputContainerStyle, helpGlobalStyle           lipgloss.Style
+	dialogButtonStyle                                                           lipgloss.Style
+	wifiStatusStyleEnabled, wifiStatusStyleDisabled, listTitleHiddenStatusStyle lipgloss.Style
+)
+
+func buildStyles() {
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(ansPrimaryColor).Padding(0, 1).MarginBottom(1)
+	listTitleStyle = lipgloss.NewStyle().Foreground(ansSecondaryColor).Padding(0, 1).Bold(true)
+	listItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(ansTextColor)
 	listSelectedItemStyle = lipgloss.NewStyle().PaddingLeft(1).Foreground(ansPrimaryColor).Bold(true)
-	listDescStyle         = lipgloss.NewStyle().PaddingLeft(2).Foreground(ansFaintTextColor)
+	listDescStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(ansFaintTextColor)
 	listSelectedDescStyle = lipgloss.NewStyle().PaddingLeft(1).Foreground(ansPrimaryColor)
-	listNoItemsStyle      = lipgloss.NewStyle().Faint(true).Margin(1, 0).Align(lipgloss.Center).Foreground(ansFaintTextColor)
+	listNoItemsStyle = lipgloss.NewStyle().Faint(true).Margin(1, 0).Align(lipgloss.Center).Foreground(ansFaintTextColor)
 
-	statusMessageBaseStyle     = lipgloss.NewStyle().MarginTop(1)
-	errorStyle                 = statusMessageBaseStyle.Copy().Foreground(ansErrorColor).Bold(true)
-	connectingStyle            = lipgloss.NewStyle().Foreground(ansAccentColor)
-	successStyle               = statusMessageBaseStyle.Copy().Foreground(ansSuccessColor).Bold(true)
-	infoBoxStyle               = lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).BorderForeground(ansAccentColor).Padding(1, 2).MarginTop(1)
+	statusMessageBaseStyle = lipgloss.NewStyle().MarginTop(1)
+	errorStyle = statusMessageBaseStyle.Copy().Foreground(ansErrorColor).Bold(true)
+	connectingStyle = lipgloss.NewStyle().Foreground(ansAccentColor)
+	successStyle = statusMessageBaseStyle.Copy().Foreground(ansSuccessColor).Bold(true)
+	infoBoxStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).BorderForeground(ansAccentColor).Padding(1, 2).MarginTop(1)
 	toggleHiddenStatusMsgStyle = statusMessageBaseStyle.Copy().Foreground(ansFaintTextColor)
 
-	passwordPromptStyle         = lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	passwordPromptStyle = lipgloss.NewStyle().Foreground(ansFaintTextColor)
 	passwordInputContainerStyle = lipgloss.NewStyle().Padding(1).MarginTop(1).Border(lipgloss.NormalBorder(), true).BorderForeground(ansFaintTextColor)
 
 	helpGlobalStyle = lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	dialogButtonStyle = lipgloss.NewStyle().Foreground(ansAccentColor).Bold(true)
 
-	wifiStatusStyleEnabled     = lipgloss.NewStyle().Foreground(ansSuccessColor)
-	wifiStatusStyleDisabled    = lipgloss.NewStyle().Foreground(ansErrorColor)
+	wifiStatusStyleEnabled = lipgloss.NewStyle().Foreground(ansSuccessColor)
+	wifiStatusStyleDisabled = lipgloss.NewStyle().Foreground(ansErrorColor)
 	listTitleHiddenStatusStyle = lipgloss.NewStyle().Foreground(ansFaintTextColor).Italic(true)
-)
+}
 
 type viewState int
 
@@ -91,9 +113,23 @@ const (
 	viewProfileCreate
 	viewProfileEdit
 	viewUpdating
+	viewSurvey
+	viewDiagnostics
+	viewRestore
+	viewHistory
+	viewCleanup
+	viewBulk
+	viewJoinOrder
+	viewConnectFor
+	viewLocations
+	viewPalette
 )
 
-type itemDelegate struct{}
+// itemDelegate renders both network lists; marks are the profiles picked
+// for a bulk action.
+type itemDelegate struct {
+	marks profileMarks
+}
 
 func (d itemDelegate) Height() int                             { return 2 }
 func (d itemDelegate) Spacing() int                            { return 1 }
@@ -104,11 +140,15 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		return
 	}
 	var title, desc string
+	styledTitle := i.StyledTitle()
+	if _, marked := d.marks[i.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID]]; marked {
+		styledTitle = lipgloss.NewStyle().Foreground(ansAccentColor).Render("● ") + styledTitle
+	}
 	if index == m.Index() {
-		title = listSelectedItemStyle.Render("▸ " + i.StyledTitle())
+		title = listSelectedItemStyle.Render("▸ " + styledTitle)
 		desc = listSelectedDescStyle.Render("  " + i.Description())
 	} else {
-		title = listItemStyle.Render("  " + i.StyledTitle())
+		title = listItemStyle.Render("  " + styledTitle)
 		desc = listDescStyle.Render("  " + i.Description())
 	}
 	fmt.Fprintf(w, "%s\n%s", title, desc)
@@ -162,7 +202,7 @@ func (ap wifiAP) Description() string {
 		case signalVal > 70:
 			sStyle = lipgloss.NewStyle().Foreground(ansSuccessColor)
 		case signalVal > 40:
-			sStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+			sStyle = lipgloss.NewStyle().Foreground(ansWarningColor)
 		default:
 			sStyle = lipgloss.NewStyle().Foreground(ansErrorColor)
 		}
@@ -173,6 +213,9 @@ func (ap wifiAP) Description() string {
 		security = "Open"
 	}
 	descParts = append(descParts, fmt.Sprintf("%s %s", labelStyle.Render("Security:"), labelStyle.Render(security)))
+	if ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionMetered] == "yes" {
+		descParts = append(descParts, lipgloss.NewStyle().Foreground(ansWarningColor).Render("Metered"))
+	}
 	return strings.Join(descParts, labelStyle.Render(" | "))
 }
 func (ap wifiAP) FilterValue() string {
@@ -216,6 +259,7 @@ type forgetNetworkResultMsg struct {
 	ssid    string
 	success bool
 	err     error
+	undo    *historyEntry
 }
 
 type knownWifiApsListMsg struct {
@@ -225,6 +269,7 @@ type knownWifiApsListMsg struct {
 
 type profileLoadedMsg struct {
 	profile gonetworkmanager.ConnectionProfile
+	usage   *profileUsage
 	err     error
 	forEdit bool
 }
@@ -234,6 +279,7 @@ type profileSaveResultMsg struct {
 	err        error
 	action     string
 	profileRef string
+	undo       *historyEntry
 }
 
 type updateCheckMsg struct {
@@ -253,8 +299,23 @@ type updateCompleteMsg struct {
 }
 
 type keyMap struct {
-	Connect, Refresh, Quit, Back, Help, Filter, ToggleWifi, Disconnect, Info, ToggleHidden, Forget, Profiles, NewProfile, EditProfile, ClearSecret, Update key.Binding
-	currentState                                                                                                                                          viewState
+	// Moving around, on every screen.
+	Connect, Back, Up, Down, Quit, Help, Palette key.Binding
+
+	// The networks list.
+	Refresh, Filter, ToggleWifi, ToggleHidden, Disconnect, Info, ConnectFor, Update, Survey, Diagnose, Portal, Watchdog, Locations key.Binding
+
+	// Profiles and their details.
+	Profiles, NewProfile, EditProfile, Forget, ClearSecret, RevealPassword, CopyPassword, Share key.Binding
+
+	// Export, backup and history.
+	Export, ExportSecrets, Import, Restore, History, Undo key.Binding
+
+	// Marking, bulk actions, cleanup and the join order.
+	Mark, SelectAll, Bulk, Confirm, Cleanup, ThresholdUp, ThresholdDown, JoinOrder, MoveUp, MoveDown key.Binding
+
+	currentState viewState
+	aliases      map[string]tea.KeyType // other keys of Connect, Back, Up and Down, translated outside text inputs
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -265,13 +326,17 @@ func (k keyMap) ShortHelp() []key.Binding {
 	case viewPasswordInput, viewConnectionResult, viewConfirmDisconnect, viewConfirmForget:
 		b = append(b, k.Connect, k.Back)
 	case viewKnownNetworksList:
-		b = append(b, k.Connect, k.NewProfile, k.EditProfile, k.Forget)
+		b = append(b, k.Connect, k.NewProfile, k.EditProfile, k.Forget, k.Export)
 	case viewActiveConnectionInfo:
 		b = append(b, k.Back)
+	case viewDiagnostics:
+		b = append(b, k.Refresh, k.Back)
 	case viewProfileDetails:
-		b = append(b, k.Back, k.EditProfile, k.Forget)
+		b = append(b, k.Back, k.EditProfile, k.Forget, k.RevealPassword, k.CopyPassword)
 	case viewProfileCreate, viewProfileEdit:
 		b = append(b, k.Connect, k.Back, k.ClearSecret)
+	case viewSurvey:
+		b = append(b, k.Refresh, k.Export, k.Back)
 	}
 	return append(b, k.Quit)
 }
@@ -279,38 +344,21 @@ func (k keyMap) FullHelp() [][]key.Binding {
 	switch k.currentState {
 	default: // viewNetworksList
 		return [][]key.Binding{
-			{k.Help, k.Connect, k.Back, k.Quit},
+			{k.Help, k.Palette, k.Connect, k.Back, k.Quit},
 			{k.Refresh, k.Filter, k.ToggleHidden, k.ToggleWifi},
-			{k.Disconnect, k.Forget, k.Info, k.Profiles, k.Update},
+			{k.Mark, k.Bulk, k.ConnectFor, k.Locations, k.Disconnect, k.Forget, k.Info, k.Profiles, k.Survey, k.Diagnose, k.Watchdog, k.Portal, k.Undo, k.Update},
 		}
 	case viewKnownNetworksList:
-		return [][]key.Binding{{k.Connect, k.NewProfile, k.EditProfile, k.Forget}, {k.Refresh, k.Back, k.Quit}}
+		return [][]key.Binding{{k.Connect, k.NewProfile, k.EditProfile, k.Forget, k.Mark, k.Bulk}, {k.Export, k.ExportSecrets, k.Import, k.Restore, k.History, k.Undo}, {k.Cleanup, k.JoinOrder, k.ConnectFor, k.Locations, k.Palette, k.Refresh, k.Back, k.Quit}}
 	case viewProfileDetails:
-		return [][]key.Binding{{k.Back, k.EditProfile, k.Forget, k.Quit}}
+		return [][]key.Binding{{k.Back, k.EditProfile, k.Forget, k.Quit}, {k.RevealPassword, k.CopyPassword}}
 	case viewProfileCreate, viewProfileEdit:
 		return [][]key.Binding{{k.Connect, k.Back, k.ClearSecret, k.Quit}}
+	case viewSurvey:
+		return [][]key.Binding{{k.Connect, k.Refresh, k.Export}, {k.Back, k.Quit}}
 	}
 }
 
-var defaultKeyBindings = keyMap{
-	Connect:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter/l", "select/conn/confirm")),
-	Refresh:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
-	Quit:         key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
-	Back:         key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc/h", "back/cancel")),
-	Help:         key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
-	Filter:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
-	ToggleWifi:   key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "toggle Wi-Fi")),
-	Disconnect:   key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "disconnect")),
-	Forget:       key.NewBinding(key.WithKeys("ctrl+f"), key.WithHelp("ctrl+f", "forget")),
-	Info:         key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "info")),
-	ToggleHidden: key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "unnamed nets")),
-	Profiles:     key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "profiles")),
-	NewProfile:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new profile")),
-	EditProfile:  key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit profile")),
-	ClearSecret:  key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "clear password")),
-	Update:       key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "update")),
-}
-
 type model struct {
 	state                       viewState
 	previousState               viewState
@@ -328,6 +376,7 @@ type model struct {
 	activeWifiConnection        *gonetworkmanager.ConnectionProfile
 	activeWifiDevice            string
 	allScannedAps               []wifiAP
+	signalHistory               map[string][]float64 // SSID -> strongest signal per scan
 	showHiddenNetworks          bool
 	isLoading                   bool
 	isScanning                  bool
@@ -339,6 +388,12 @@ type model struct {
 	help                        help.Model
 	profileForm                 profileFormState
 	profileDetailsID            string
+	profileDetailsText          string // details without the password line
+	psk                         savedPSK
+	clipboardGen                int
+	clipboardClear              time.Duration
+	secretCommand               string // secret provider; see secrets.go
+	agentOwnedSecrets           bool
 	updateAvailable             bool
 	updateLatestVersion         string
 	updateStatusMsg             string
@@ -347,7 +402,33 @@ type model struct {
 	isUpdating                  bool
 	wantsRestart                bool
 	allowPrerelease             bool
+	sortOrder                   string
+	scanOnStart                 bool
+	rescanInterval              time.Duration
 	updateCancelFn              context.CancelFunc
+	survey                      *surveySession
+	surveyGen                   int
+	liveStats                   *liveStats
+	statsGen                    int
+	activeConnInfoText          string
+	surveyLocationInput         textinput.Model
+	importPathInput             textinput.Model
+	restore                     *restoreScreen
+	undo                        *undoToast
+	historyEntries              []historyEntry
+	historyCursor               int
+	cleanup                     *cleanupScreen
+	marks                       profileMarks
+	bulk                        *bulkScreen
+	joinOrder                   *joinOrderScreen
+	portal                      *portalState
+	watchdog                    *watchdog
+	quotaWarnings               []quotaWarning
+	connectFor                  *connectForPrompt
+	locations                   *locationScreen
+	timers                      []connectTimer
+	click                       mouseClick
+	palette                     *paletteScreen
 }
 
 type profileFormMode int
@@ -362,13 +443,16 @@ const (
 	profileFieldSSID
 	profileFieldSecurity
 	profileFieldPassword
+	profileFieldPSKStorage
 	profileFieldAutoconnect
 	profileFieldHidden
 	profileFieldPriority
+	profileFieldMetered
+	profileFieldQuota
 	profileFieldCount
 )
 
-var profileFieldLabels = []string{"Name", "SSID", "Security (open|wpa-psk)", "Password", "Autoconnect (yes|no)", "Hidden (yes|no)", "Priority (blank or integer)"}
+var profileFieldLabels = []string{"Name", "SSID", "Security (open|wpa-psk)", "Password", "Password storage (all-users|this-user|ask)", "Autoconnect (yes|no)", "Hidden (yes|no)", "Priority (blank or integer)", "Metered (yes|no|auto)", "Monthly data quota (e.g. 5G, blank = none)"}
 
 type profileFormState struct {
 	mode          profileFormMode
@@ -382,7 +466,11 @@ type profileFormState struct {
 }
 
 func initialModel() model {
-	delegate := itemDelegate{}
+	cfg := currentConfig()
+	// Conflicts were reported at startup; the first action keeps the key.
+	keys, _ := buildKeyMap(cfg.Keymap, cfg.Keys)
+	marks := profileMarks{}
+	delegate := itemDelegate{marks: marks}
 	l := list.New([]list.Item{}, delegate, 0, 0)
 	l.Title = "Scanning for Wi-Fi Networks..."
 	l.Styles.Title = listTitleStyle
@@ -390,18 +478,19 @@ func initialModel() model {
 	l.SetStatusBarItemName("network", "networks")
 	l.SetShowHelp(false)
 	l.DisableQuitKeybindings()
+	listCursorKeys(&l.KeyMap)
 	l.Styles.NoItems = listNoItemsStyle.Copy().SetString("No Wi-Fi. Try (r)efresh, (t)oggle Wi-Fi, (u)nnamed.")
 	l.Styles.FilterPrompt = lipgloss.NewStyle().Foreground(ansPrimaryColor)
 	l.Styles.FilterCursor = lipgloss.NewStyle().Foreground(ansPrimaryColor)
 	l.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{defaultKeyBindings.Filter, defaultKeyBindings.Refresh, defaultKeyBindings.ToggleHidden}
+		return []key.Binding{keys.Filter, keys.Refresh, keys.ToggleHidden}
 	}
 	l.AdditionalFullHelpKeys = l.AdditionalShortHelpKeys
 
 	ti := textinput.New()
 	ti.Placeholder = "Network Password"
 	ti.EchoMode = textinput.EchoPassword
-	ti.CharLimit = 63
+	ti.CharLimit = 64 // 63 for a passphrase, 64 for a raw hex key
 	ti.Prompt = passwordPromptStyle.Render("🔑 Password: ")
 	ti.EchoCharacter = '•'
 	ti.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
@@ -427,6 +516,7 @@ func initialModel() model {
 	pl.SetShowStatusBar(false)
 	pl.SetShowHelp(false)
 	pl.DisableQuitKeybindings()
+	listCursorKeys(&pl.KeyMap)
 	pl.Styles.NoItems = listNoItemsStyle.Copy().SetString("No known Wi-Fi profiles found.")
 
 	profileInputs := make([]textinput.Model, profileFieldCount)
@@ -440,10 +530,12 @@ func initialModel() model {
 	profileInputs[profileFieldSecurity].SetValue("wpa-psk")
 	profileInputs[profileFieldAutoconnect].SetValue("yes")
 	profileInputs[profileFieldHidden].SetValue("no")
+	profileInputs[profileFieldMetered].SetValue("auto")
+	profileInputs[profileFieldPSKStorage].SetValue(defaultPSKStorage(cfg.SecretAgentOwned))
 	profileInputs[profileFieldPassword].Placeholder = "leave blank"
 	profileInputs[profileFieldPassword].EchoMode = textinput.EchoPassword
 	profileInputs[profileFieldPassword].EchoCharacter = '•'
-	profileInputs[profileFieldPassword].CharLimit = 63
+	profileInputs[profileFieldPassword].CharLimit = 64
 
 	m := model{
 		state:                  viewNetworksList,
@@ -457,18 +549,30 @@ func initialModel() model {
 		isScanning:             true,
 		isFiltering:            false,
 		filterQuery:            "",
-		keys:                   defaultKeyBindings,
+		keys:                   keys,
 		help:                   h,
 		profileForm: profileFormState{
 			mode:       profileFormCreate,
 			inputs:     profileInputs,
 			focusIndex: 0,
 		},
-		knownProfiles:      make(map[string]gonetworkmanager.ConnectionProfile),
-		showHiddenNetworks: false,
-		allowPrerelease:    getAllowPrereleaseConfig(),
+		knownProfiles:       make(map[string]gonetworkmanager.ConnectionProfile),
+		showHiddenNetworks:  cfg.ShowHidden,
+		allowPrerelease:     cfg.UpdatePrerelease,
+		sortOrder:           cfg.SortOrder,
+		scanOnStart:         cfg.ScanOnStart,
+		rescanInterval:      cfg.RescanInterval,
+		clipboardClear:      cfg.ClipboardClear,
+		secretCommand:       cfg.SecretCommand,
+		agentOwnedSecrets:   cfg.SecretAgentOwned,
+		surveyLocationInput: newSurveyLocationInput(),
+		importPathInput:     newImportPathInput(),
+		marks:               marks,
 	}
 	m.keys.currentState = m.state
+	if cfg.Watchdog {
+		m.watchdog = newWatchdog(defaultWatchdogConfig())
+	}
 
 	// Load cached networks if available
 	if cachedAps := loadCachedNetworks(); cachedAps != nil {
@@ -480,7 +584,12 @@ func initialModel() model {
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(getWifiStatusInternalCmd(), fetchKnownNetworksCmd(), fetchWifiNetworksCmd(true), m.spinner.Tick, checkForUpdateCmd())
+	cmds := []tea.Cmd{getWifiStatusInternalCmd(), fetchKnownNetworksCmd(), fetchWifiNetworksCmd(m.scanOnStart), m.spinner.Tick, checkForUpdateCmd(), rescanTickCmd(m.rescanInterval)}
+	if m.watchdog != nil {
+		cmds = append(cmds, watchdogObserveCmd(m.watchdog))
+	}
+	cmds = append(cmds, m.sampleUsageCmd(), scheduleRunCmd())
+	return tea.Batch(cmds...)
 }
 
 func checkForUpdateCmd() tea.Cmd {
@@ -622,6 +731,8 @@ func (m *model) initProfileForm(mode profileFormMode, p gonetworkmanager.Connect
 	m.profileForm.inputs[profileFieldAutoconnect].SetValue("yes")
 	m.profileForm.inputs[profileFieldHidden].SetValue("no")
 	m.profileForm.inputs[profileFieldPriority].SetValue("")
+	m.profileForm.inputs[profileFieldMetered].SetValue("auto")
+	m.profileForm.inputs[profileFieldPSKStorage].SetValue(defaultPSKStorage(m.agentOwnedSecrets))
 
 	if p != nil {
 		m.profileForm.profileID = p[gonetworkmanager.NmcliFieldConnectionUUID]
@@ -638,6 +749,8 @@ func (m *model) initProfileForm(mode profileFormMode, p gonetworkmanager.Connect
 		}
 		m.profileForm.inputs[profileFieldSecurity].SetValue(sec)
 		m.profileForm.inputs[profileFieldPassword].SetValue("")
+		storage, _ := pskStorageFor(p[gonetworkmanager.NmcliFieldWifiPSKFlags])
+		m.profileForm.inputs[profileFieldPSKStorage].SetValue(storage)
 		if ac, ok := p["connection.autoconnect"]; ok && strings.TrimSpace(ac) != "" {
 			if b, err := parseYesNo(ac); err == nil {
 				if b {
@@ -659,6 +772,12 @@ func (m *model) initProfileForm(mode profileFormMode, p gonetworkmanager.Connect
 		if pri, ok := p["connection.autoconnect-priority"]; ok {
 			m.profileForm.inputs[profileFieldPriority].SetValue(strings.TrimSpace(pri))
 		}
+		switch strings.TrimSpace(p[gonetworkmanager.NmcliFieldConnectionMetered]) {
+		case "yes":
+			m.profileForm.inputs[profileFieldMetered].SetValue("yes")
+		case "no":
+			m.profileForm.inputs[profileFieldMetered].SetValue("no")
+		}
 	}
 
 	m.focusProfileInput(0)
@@ -702,13 +821,38 @@ func (m *model) validateProfileForm() (gonetworkmanager.WifiProfileSpec, bool, *
 	if ssid == "" {
 		return gonetworkmanager.WifiProfileSpec{}, false, nil, fmt.Errorf("ssid is required")
 	}
+	pskFlags, err := parsePSKStorage(m.profileForm.inputs[profileFieldPSKStorage].Value())
+	if err != nil {
+		return gonetworkmanager.WifiProfileSpec{}, false, nil, err
+	}
 	passwordProvided := strings.TrimSpace(password) != ""
-	if m.profileForm.mode == profileFormCreate && security == "wpa-psk" && !passwordProvided {
+	// Passwords that are not stored for all users are not put in the
+	// profile at all.
+	stored := pskFlags == gonetworkmanager.PSKFlagsSystem
+	if !stored {
+		password, passwordProvided = "", false
+	}
+	storageChanged := strings.ToLower(strings.TrimSpace(m.profileForm.inputs[profileFieldPSKStorage].Value())) != m.profileForm.initialValues[profileFieldPSKStorage]
+	if security == "wpa-psk" && stored && !passwordProvided && (m.profileForm.mode == profileFormCreate || storageChanged) {
 		return gonetworkmanager.WifiProfileSpec{}, false, nil, fmt.Errorf("password is required for wpa-psk profiles")
 	}
 	if security == "wpa-psk" && passwordProvided {
-		if len(password) < 8 || len(password) > 63 {
-			return gonetworkmanager.WifiProfileSpec{}, false, nil, fmt.Errorf("password must be 8-63 characters")
+		if err := gonetworkmanager.ValidateWifiPSK(password); err != nil {
+			return gonetworkmanager.WifiProfileSpec{}, false, nil, err
+		}
+	}
+
+	// Metered is only written when changed, so profiles NetworkManager
+	// manages on its own keep their setting.
+	metered := ""
+	if raw := strings.ToLower(strings.TrimSpace(m.profileForm.inputs[profileFieldMetered].Value())); raw != m.profileForm.initialValues[profileFieldMetered] {
+		switch raw {
+		case "yes", "no":
+			metered = raw
+		case "auto", "":
+			metered = "unknown"
+		default:
+			return gonetworkmanager.WifiProfileSpec{}, false, nil, fmt.Errorf("metered must be yes, no or auto")
 		}
 	}
 
@@ -730,14 +874,76 @@ func (m *model) validateProfileForm() (gonetworkmanager.WifiProfileSpec, bool, *
 		Hidden:      hidden,
 		Autoconnect: autoconnect,
 		Priority:    priorityPtr,
+		Metered:     metered,
+	}
+	// Like metered, the flags of an edited profile are only written when
+	// changed.
+	if security == "wpa-psk" && (storageChanged || m.profileForm.mode == profileFormCreate && !stored) {
+		spec.PSKFlags = pskFlags
 	}
 	return spec, passwordProvided, priorityPtr, nil
 }
 
+// saveProfileForm validates the profile form and saves it, asking the
+// secret provider first for a password left empty.
+func (m *model) saveProfileForm() []tea.Cmd {
+	m.profileForm.discardArmed = false
+	if m.profileFormNeedsSecret() {
+		ssid := strings.TrimSpace(m.profileForm.inputs[profileFieldSSID].Value())
+		m.isLoading = true
+		m.profileForm.statusMsg = connectingStyle.Render(fmt.Sprintf("Looking up the password for %s...", ssid))
+		return []tea.Cmd{secretLookupCmd(m.secretCommand, ssid, true), m.spinner.Tick}
+	}
+	spec, passwordProvided, _, err := m.validateProfileForm()
+	var quota uint64
+	var quotaChanged bool
+	if err == nil {
+		quota, quotaChanged, err = m.profileFormQuota()
+	}
+	if err != nil {
+		m.profileForm.statusMsg = errorStyle.Render(err.Error())
+		return nil
+	}
+	m.profileForm.statusMsg = ""
+	m.isLoading = true
+	save := createProfileCmd(spec)
+	if m.state == viewProfileEdit {
+		save = updateProfileCmd(m.profileForm.profileID, spec, passwordProvided, m.profileForm.clearPassword)
+	}
+	if quotaChanged {
+		save = withUsageQuota(save, m.profileForm.profileID, quota)
+	}
+	return []tea.Cmd{save, m.spinner.Tick}
+}
+
+// profileFormQuota returns the data quota entered in the form and whether it
+// differs from the stored one.
+func (m *model) profileFormQuota() (quota uint64, changed bool, err error) {
+	raw := strings.TrimSpace(m.profileForm.inputs[profileFieldQuota].Value())
+	if raw == strings.TrimSpace(m.profileForm.initialValues[profileFieldQuota]) {
+		return 0, false, nil
+	}
+	if raw == "" {
+		return 0, true, nil
+	}
+	quota, err = parseByteSize(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("quota: %w", err)
+	}
+	return quota, true, nil
+}
+
 func fetchProfileByIDCmd(profileID string, forEdit bool) tea.Cmd {
 	return func() tea.Msg {
 		p, err := gonetworkmanager.GetConnectionProfileByID(profileID)
-		return profileLoadedMsg{profile: p, err: err, forEdit: forEdit}
+		msg := profileLoadedMsg{profile: p, err: err, forEdit: forEdit}
+		if p != nil {
+			if s, err := loadUsage(); err == nil {
+				uuid, _ := profileIdentity(p)
+				msg.usage = s.Profiles[uuid]
+			}
+		}
+		return msg
 	}
 }
 
@@ -750,8 +956,13 @@ func createProfileCmd(spec gonetworkmanager.WifiProfileSpec) tea.Cmd {
 
 func updateProfileCmd(profileID string, spec gonetworkmanager.WifiProfileSpec, passwordProvided bool, clearPassword bool) tea.Cmd {
 	return func() tea.Msg {
+		start := time.Now()
 		_, err := gonetworkmanager.UpdateWifiProfile(profileID, spec, passwordProvided, clearPassword)
-		return profileSaveResultMsg{success: err == nil, err: err, action: "updated", profileRef: spec.Name}
+		msg := profileSaveResultMsg{success: err == nil, err: err, action: "updated", profileRef: spec.Name}
+		if err == nil {
+			msg.undo = latestSnapshot(profileID, start)
+		}
+		return msg
 	}
 }
 
@@ -874,6 +1085,26 @@ func fetchKnownNetworksCmd() tea.Cmd {
 			}
 		}
 
+		uuids := make([]string, 0, len(known))
+		for _, p := range known {
+			uuids = append(uuids, p[gonetworkmanager.NmcliFieldConnectionUUID])
+		}
+		// The psk-flags tell which profiles ask for their password on
+		// every connect.
+		props, err := gonetworkmanager.GetProfileProperties(uuids, gonetworkmanager.NmcliFieldConnectionMetered, gonetworkmanager.NmcliFieldWifiPSKFlags)
+		if err != nil {
+			log.Printf("Cmd: Error reading profile properties: %v", err)
+		}
+		for _, p := range known {
+			values := props[p[gonetworkmanager.NmcliFieldConnectionUUID]]
+			if v := values[gonetworkmanager.NmcliFieldConnectionMetered]; v != "" {
+				p[gonetworkmanager.NmcliFieldConnectionMetered] = v
+			}
+			if flags := values[gonetworkmanager.NmcliFieldWifiPSKFlags]; flags != "" {
+				p[gonetworkmanager.NmcliFieldWifiPSKFlags] = flags
+			}
+		}
+
 		log.Printf("Cmd: Found %d known Wi-Fi profiles. Active: %v", len(known), activeConn != nil)
 		return knownNetworksMsg{knownProfiles: known, activeWifiConnection: activeConn, activeWifiDevice: activeDev}
 	}
@@ -892,6 +1123,16 @@ func fetchKnownWifiApsCmd() tea.Cmd {
 				aps = append(aps, connectionProfileToWifiAP(p))
 			}
 		}
+		uuids := make([]string, 0, len(aps))
+		for _, ap := range aps {
+			uuids = append(uuids, ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID])
+		}
+		metered := meteredProfiles(uuids)
+		for _, ap := range aps {
+			if metered[ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID]] {
+				ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionMetered] = "yes"
+			}
+		}
 		// Sort alphabetically
 		sort.Slice(aps, func(i, j int) bool {
 			return strings.ToLower(aps[i].getSSIDFromScannedAP()) < strings.ToLower(aps[j].getSSIDFromScannedAP())
@@ -945,11 +1186,13 @@ func disconnectWifiCmd(profileID string) tea.Cmd { /* Same */
 func forgetNetworkCmd(profileID, ssidForMsg string) tea.Cmd { /* Same */
 	return func() tea.Msg {
 		log.Printf("Cmd: Attempting to forget profile ID: '%s' (SSID: '%s')", profileID, ssidForMsg)
+		start := time.Now()
 		_, err := gonetworkmanager.ConnectionDelete(profileID)
 		if err != nil {
 			log.Printf("Cmd: Error forgetting profile '%s': %v", profileID, err)
+			return forgetNetworkResultMsg{ssid: ssidForMsg, err: err}
 		}
-		return forgetNetworkResultMsg{ssid: ssidForMsg, success: err == nil, err: err}
+		return forgetNetworkResultMsg{ssid: ssidForMsg, success: true, undo: latestSnapshot(profileID, start)}
 	}
 }
 
@@ -1051,6 +1294,7 @@ func (m *model) getAllWifiItems() []list.Item {
 			apMap[gonetworkmanager.NmcliFieldConnectionUUID] = profile[gonetworkmanager.NmcliFieldConnectionUUID]
 			apMap[gonetworkmanager.NmcliFieldWifiSignal] = "0" // No signal since not in range
 			apMap[gonetworkmanager.NmcliFieldWifiSecurity] = "--"
+			apMap[gonetworkmanager.NmcliFieldConnectionMetered] = profile[gonetworkmanager.NmcliFieldConnectionMetered]
 
 			isActive := false
 			if m.activeWifiConnection != nil && profile[gonetworkmanager.NmcliFieldConnectionUUID] == (*m.activeWifiConnection)[gonetworkmanager.NmcliFieldConnectionUUID] {
@@ -1092,6 +1336,7 @@ func (m *model) getAllWifiItems() []list.Item {
 				// Store profile info in the AP for later use (e.g., forgetting)
 				pAP.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID] = profile[gonetworkmanager.NmcliFieldConnectionUUID]
 				pAP.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionName] = profile[gonetworkmanager.NmcliFieldConnectionName]
+				pAP.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionMetered] = profile[gonetworkmanager.NmcliFieldConnectionMetered]
 
 				if m.activeWifiConnection != nil && profile[gonetworkmanager.NmcliFieldConnectionUUID] == (*m.activeWifiConnection)[gonetworkmanager.NmcliFieldConnectionUUID] {
 					pAP.IsActive = true
@@ -1143,8 +1388,8 @@ func (m *model) getAllWifiItems() []list.Item {
 			}
 		}
 
-		// Sort by signal strength
-		if sigi != sigj {
+		// Sort by signal strength, unless sort_order is name
+		if m.sortOrder != sortByName && sigi != sigj {
 			return sigi > sigj
 		}
 
@@ -1197,7 +1442,7 @@ func (m *model) resizeComponents() {
 	}
 
 	listContentHeight := contentAreaHeight
-	if m.isFiltering {
+	if m.isFiltering || m.importPathInput.Focused() {
 		listContentHeight -= 4
 		if listContentHeight < 5 {
 			listContentHeight = 5
@@ -1228,26 +1473,42 @@ func (m *model) resizeComponents() {
 
 func (m *model) handleKnownNetworksListKeys(msg tea.KeyMsg) []tea.Cmd {
 	var cmds []tea.Cmd
+	if m.importPathInput.Focused() {
+		return m.handleImportPathKeys(msg)
+	}
 	if m.isLoading {
 		switch {
-		case key.Matches(msg, m.keys.Back) || msg.String() == "h":
+		case key.Matches(msg, m.keys.Back) && len(m.marks) > 0:
+			m.clearMarks()
+			return nil
+		case key.Matches(msg, m.keys.Back):
 			m.state = viewNetworksList
 			m.clearStatus()
 			m.resizeComponents()
 			return nil
 		case key.Matches(msg, m.keys.Quit):
-			return []tea.Cmd{tea.Quit}
+			return []tea.Cmd{m.quit()}
 		default:
 			return nil
 		}
 	}
 	switch {
-	case key.Matches(msg, m.keys.Back) || msg.String() == "h":
+	case key.Matches(msg, m.keys.Back):
+		if len(m.marks) > 0 {
+			m.clearMarks()
+			return nil
+		}
 		m.state = viewNetworksList
 		m.clearStatus()
 		m.resizeComponents()
 		return nil
-	case key.Matches(msg, m.keys.Connect) || msg.String() == "l":
+	case key.Matches(msg, m.keys.Mark) && m.knownWifiList.FilterState() != list.Filtering:
+		m.toggleMark(&m.knownWifiList)
+		return nil
+	case key.Matches(msg, m.keys.Bulk) && m.knownWifiList.FilterState() != list.Filtering:
+		m.openBulk()
+		return nil
+	case key.Matches(msg, m.keys.Connect):
 		if i, ok := m.knownWifiList.SelectedItem().(wifiAP); ok {
 			profileID := i.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID]
 			if profileID == "" {
@@ -1287,8 +1548,22 @@ func (m *model) handleKnownNetworksListKeys(msg tea.KeyMsg) []tea.Cmd {
 		m.knownWifiList.Title = "Loading Profiles..."
 		m.clearStatus()
 		return []tea.Cmd{fetchKnownWifiApsCmd(), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Export), key.Matches(msg, m.keys.ExportSecrets), key.Matches(msg, m.keys.Import):
+		return m.handleProfileIOKeys(msg)
+	case key.Matches(msg, m.keys.Restore):
+		return []tea.Cmd{m.startRestore()}
+	case key.Matches(msg, m.keys.History):
+		return m.openHistory()
+	case key.Matches(msg, m.keys.Cleanup):
+		return m.openCleanup()
+	case key.Matches(msg, m.keys.JoinOrder):
+		return m.openJoinOrder()
+	case key.Matches(msg, m.keys.ConnectFor):
+		return m.openConnectFor(&m.knownWifiList)
+	case key.Matches(msg, m.keys.Locations):
+		return m.openLocations()
 	case key.Matches(msg, m.keys.Quit):
-		return []tea.Cmd{tea.Quit}
+		return []tea.Cmd{m.quit()}
 	case key.Matches(msg, m.keys.Forget):
 		if i, ok := m.knownWifiList.SelectedItem().(wifiAP); ok {
 			m.selectedAP = i
@@ -1305,19 +1580,23 @@ func (m *model) handleKnownNetworksListKeys(msg tea.KeyMsg) []tea.Cmd {
 }
 
 func (m model) isTextInputActive() bool {
-	return m.state == viewPasswordInput || m.state == viewProfileCreate || m.state == viewProfileEdit || (m.state == viewNetworksList && m.isFiltering)
-}
-
-// remapVimKeys converts j/k to down/up arrow keys when not in a text input context.
-func (m model) remapVimKeys(msg tea.KeyMsg) tea.KeyMsg {
-	if m.isTextInputActive() {
+	return m.state == viewPasswordInput || m.state == viewProfileCreate || m.state == viewProfileEdit || (m.state == viewNetworksList && m.isFiltering) ||
+		(m.state == viewSurvey && m.surveyLocationInput.Focused()) ||
+		(m.state == viewKnownNetworksList && m.importPathInput.Focused()) ||
+		(m.state == viewRestore && m.restore != nil && m.restore.input.Focused()) ||
+		(m.state == viewKnownNetworksList && m.knownWifiList.FilterState() == list.Filtering) ||
+		(m.state == viewBulk && m.bulk != nil && m.bulk.priority.Focused()) ||
+		m.state == viewConnectFor || m.state == viewPalette
+}
+
+// remapKeys converts the configured aliases of enter, esc and the arrow
+// keys (l/h/k/j in the vim keymap), except typed characters in a text input.
+func (m model) remapKeys(msg tea.KeyMsg) tea.KeyMsg {
+	if (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && m.isTextInputActive() {
 		return msg
 	}
-	switch msg.String() {
-	case "j":
-		return tea.KeyMsg{Type: tea.KeyDown}
-	case "k":
-		return tea.KeyMsg{Type: tea.KeyUp}
+	if t, ok := m.keys.aliases[msg.String()]; ok {
+		return tea.KeyMsg{Type: t}
 	}
 	return msg
 }
@@ -1353,7 +1632,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Reserve space for filter input if filtering (border + padding + content = ~3 lines)
 		listContentHeight := contentAreaHeight
-		if m.isFiltering {
+		if m.isFiltering || m.importPathInput.Focused() {
 			listContentHeight -= 4 // Reserve space for filter input
 			if listContentHeight < 5 {
 				listContentHeight = 5 // Minimum height for list
@@ -1397,9 +1676,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i := range m.profileForm.inputs {
 			m.profileForm.inputs[i].Width = profileInputWidth
 		}
+		m.surveyLocationInput.Width = pwInputContentWidth - lipgloss.Width(m.surveyLocationInput.Prompt)
 
 	case spinner.TickMsg:
-		if m.isLoading || m.isUpdating {
+		if m.isLoading || m.isUpdating || m.isScanning {
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
@@ -1472,10 +1752,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Error fetching Wi-Fi: %v", msg.err))
 			}
 			m.wifiList.Title = "Error Loading Networks"
+			if m.survey != nil {
+				cmds = append(cmds, m.scheduleSurveyTick())
+			}
 		} else {
 			m.isLoading = false
 			m.isScanning = false
 			m.allScannedAps = msg.allAps
+			m.recordSignalHistory(msg.allAps)
 			if len(msg.allAps) > 0 {
 				m.processAndSetWifiList(m.allScannedAps)
 				saveCachedNetworks(cloneWifiAps(msg.allAps))
@@ -1486,21 +1770,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render("No networks found.")
 				}
 			}
+			if m.survey != nil {
+				cmds = append(cmds, m.recordSurveyScan(msg.allAps))
+			}
+			cmds = append(cmds, autoDetectLocationCmd(msg.allAps))
 		}
+	case surveyTickMsg:
+		cmds = append(cmds, m.handleSurveyTick(msg)...)
 	case connectionAttemptMsg:
 		m.isLoading = false
 		if msg.success {
 			m.state = viewConnectionResult
 			m.lastConnectionWasSuccessful = true
 			m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Connected to %s!", m.selectedAP.StyledTitle()))
+			cmds = append(cmds, checkConnectivityCmd())
 		} else {
 			if msg.WasKnownAttemptNoPsk && m.selectedAP.getSSIDFromScannedAP() == msg.ssid {
-				log.Printf("Known net '%s' connect failed. Prompting for PSK.", msg.ssid)
-				m.state = viewPasswordInput
-				m.passwordInput.SetValue("")
-				m.passwordInput.Focus()
-				m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Stored creds for %s failed. Enter password:", m.selectedAP.StyledTitle()))
-				cmds = append(cmds, textinput.Blink)
+				log.Printf("Known net '%s' connect failed. Asking for the PSK.", msg.ssid)
+				cmds = append(cmds, m.askForPassword(errorStyle.Render(fmt.Sprintf("Stored creds for %s failed. Enter password:", m.selectedAP.StyledTitle())))...)
 				return m, tea.Batch(cmds...)
 			} else {
 				m.state = viewConnectionResult
@@ -1516,22 +1803,99 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case activeConnInfoMsg: /* Same */
 		m.isLoading = false
 		if msg.err != nil {
-			m.activeConnInfoViewport.SetContent(errorStyle.Render(fmt.Sprintf("Error active info: %v", msg.err)))
+			m.activeConnInfoText = errorStyle.Render(fmt.Sprintf("Error active info: %v", msg.err))
 		} else if msg.details == nil {
-			m.activeConnInfoViewport.SetContent(toggleHiddenStatusMsgStyle.Render("No IP details for active connection."))
+			m.activeConnInfoText = toggleHiddenStatusMsgStyle.Render("No IP details for active connection.")
 		} else {
 			info := []string{fmt.Sprintf("Device: %s (%s)", msg.details.Device, msg.details.Type), fmt.Sprintf("State: %s", msg.details.State), fmt.Sprintf("Connection: %s", msg.details.Connection), fmt.Sprintf("MAC: %s", msg.details.Mac), fmt.Sprintf("IPv4: %s (%s)", msg.details.IPv4, msg.details.NetV4), fmt.Sprintf("Gateway v4: %s", msg.details.GatewayV4), fmt.Sprintf("DNS: %s", strings.Join(msg.details.DNS, ", "))}
 			if msg.details.IPv6 != "" {
 				info = append(info, fmt.Sprintf("IPv6: %s (%s)", msg.details.IPv6, msg.details.NetV6), fmt.Sprintf("Gateway v6: %s", msg.details.GatewayV6))
 			}
-			m.activeConnInfoViewport.SetContent(strings.Join(info, "\n"))
+			m.activeConnInfoText = strings.Join(info, "\n")
+		}
+		m.refreshActiveConnInfoContent()
+	case diagnosticsResultMsg:
+		m.isLoading = false
+		if m.state == viewDiagnostics {
+			m.activeConnInfoViewport.SetContent(renderDiagnosticsReport(msg.report, msg.err, m.activeConnInfoViewport.Width-3))
+			m.activeConnInfoViewport.GotoTop()
 		}
+	case profileExportedMsg:
+		m.handleProfileExported(msg)
+	case profileImportedMsg:
+		cmds = append(cmds, m.handleProfileImported(msg)...)
+	case undoExpiredMsg:
+		cmds = append(cmds, m.handleUndoExpired(msg))
+	case snapshotRestoredMsg:
+		cmds = append(cmds, m.handleSnapshotRestored(msg)...)
+	case historyLoadedMsg:
+		m.isLoading = false
+		m.historyEntries = msg.entries
+		if m.historyCursor >= len(m.historyEntries) {
+			m.historyCursor = 0
+		}
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Could not read history: %v", msg.err), errorStyle)
+		}
+	case backupOpenedMsg:
+		cmds = append(cmds, m.handleBackupOpened(msg)...)
+	case restoreDoneMsg:
+		cmds = append(cmds, m.handleRestoreDone(msg)...)
+	case staleProfilesMsg:
+		m.handleStaleProfiles(msg)
+	case cleanupDoneMsg:
+		cmds = append(cmds, m.handleCleanupDone(msg)...)
+	case bulkDoneMsg:
+		cmds = append(cmds, m.handleBulkDone(msg)...)
+	case joinOrderLoadedMsg:
+		m.handleJoinOrderLoaded(msg)
+	case joinOrderSavedMsg:
+		cmds = append(cmds, m.handleJoinOrderSaved(msg)...)
+	case scheduleTickMsg:
+		cmds = append(cmds, scheduleRunCmd())
+	case rescanTickMsg:
+		cmds = append(cmds, m.handleRescanTick()...)
+	case scheduleRanMsg:
+		cmds = append(cmds, m.handleScheduleRan(msg)...)
+	case connectForDoneMsg:
+		cmds = append(cmds, m.handleConnectForDone(msg)...)
+	case connectForCancelledMsg:
+		m.handleConnectForCancelled(msg)
+	case locationsLoadedMsg:
+		m.handleLocationsLoaded(msg)
+	case locationSwitchedMsg:
+		cmds = append(cmds, m.handleLocationSwitched(msg)...)
+	case connectivityCheckMsg:
+		cmds = append(cmds, m.handleConnectivityCheck(msg))
+	case portalRecheckMsg:
+		cmds = append(cmds, m.handlePortalRecheck())
+	case portalOpenedMsg:
+		if msg.err != nil {
+			m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Could not open %s: %v", msg.url, msg.err))
+		}
+	case watchdogTickMsg:
+		cmds = append(cmds, m.handleWatchdogTick(msg))
+	case watchdogObservedMsg:
+		cmds = append(cmds, m.handleWatchdogObserved(msg))
+	case watchdogRecoveredMsg:
+		cmds = append(cmds, m.handleWatchdogRecovered(msg))
+	case statsTickMsg:
+		cmds = append(cmds, m.handleStatsTick(msg))
+	case statsSampleMsg:
+		cmds = append(cmds, m.handleStatsSample(msg))
 	case disconnectResultMsg: /* Same */
 		m.isLoading = false
 		if msg.success {
 			m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Disconnected from %s.", msg.ssid))
 			m.activeWifiConnection = nil
 			m.activeWifiDevice = ""
+			if m.watchdog != nil {
+				m.watchdog.forget("user")
+			}
+			if m.portal != nil {
+				m.portal = nil
+				cmds = append(cmds, m.relayoutCmd())
+			}
 		} else {
 			m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Error disconnecting from %s: %v", msg.ssid, msg.err))
 		}
@@ -1542,6 +1906,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.success {
 			m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Network profile for %s forgotten.", msg.ssid))
 			delete(m.knownProfiles, msg.ssid)
+			cmds = append(cmds, m.offerUndo(msg.undo))
 		} else {
 			m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Error forgetting profile for %s: %v", msg.ssid, msg.err))
 		}
@@ -1585,6 +1950,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if msg.forEdit {
 			m.initProfileForm(profileFormEdit, msg.profile)
+			if msg.usage != nil && msg.usage.Quota > 0 {
+				quota := formatBytes(msg.usage.Quota)
+				m.profileForm.inputs[profileFieldQuota].SetValue(quota)
+				m.profileForm.initialValues[profileFieldQuota] = quota
+			}
 			m.state = viewProfileEdit
 			cmds = append(cmds, textinput.Blink)
 		} else {
@@ -1610,6 +1980,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if strings.TrimSpace(priority) == "" {
 				priority = "(default)"
 			}
+			metered := meteredLabel(msg.profile[gonetworkmanager.NmcliFieldConnectionMetered])
 			details := []string{
 				fmt.Sprintf("Name: %s", name),
 				fmt.Sprintf("UUID: %s", uuid),
@@ -1618,18 +1989,49 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				fmt.Sprintf("Autoconnect: %s", autoconnect),
 				fmt.Sprintf("Hidden: %s", hidden),
 				fmt.Sprintf("Priority: %s", priority),
+				fmt.Sprintf("Metered: %s", metered),
+			}
+			pskSecured := security == "wpa-psk" || security == "sae"
+			pskStored := gonetworkmanager.PSKStoredBySystem(msg.profile[gonetworkmanager.NmcliFieldWifiPSKFlags])
+			if pskSecured {
+				_, storage := pskStorageFor(msg.profile[gonetworkmanager.NmcliFieldWifiPSKFlags])
+				details = append(details, fmt.Sprintf("Password storage: %s", storage))
 			}
-			m.activeConnInfoViewport.SetContent(strings.Join(details, "\n"))
+			details = append(details, profileUsageDetails(msg.usage, time.Now())...)
+			m.profileDetailsText = strings.Join(details, "\n")
+			if m.psk.uuid != uuid {
+				m.psk = savedPSK{uuid: uuid}
+			}
+			m.psk.applies = pskSecured && pskStored
+			m.refreshProfileDetails()
 			m.activeConnInfoViewport.GotoTop()
 			m.state = viewProfileDetails
 		}
 
+	case secretLookupMsg:
+		cmds = append(cmds, m.handleSecretLookup(msg)...)
+
+	case pskLoadedMsg:
+		cmds = append(cmds, m.handlePSKLoaded(msg)...)
+
+	case clipboardMsg, clipboardClearMsg:
+		cmds = append(cmds, m.handleClipboard(msg)...)
+
+	case usageTickMsg:
+		cmds = append(cmds, m.sampleUsageCmd())
+
+	case usageSampledMsg:
+		if msg.err == nil || msg.warnings != nil {
+			m.quotaWarnings = msg.warnings
+		}
+		cmds = append(cmds, usageTickCmd())
+
 	case profileSaveResultMsg:
 		m.isLoading = false
 		if msg.success {
 			m.state = viewKnownNetworksList
 			m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Profile %s %s.", msg.profileRef, msg.action))
-			cmds = append(cmds, fetchKnownNetworksCmd(), fetchKnownWifiApsCmd())
+			cmds = append(cmds, fetchKnownNetworksCmd(), fetchKnownWifiApsCmd(), m.offerUndo(msg.undo))
 		} else {
 			m.profileForm.statusMsg = errorStyle.Render(fmt.Sprintf("Failed to save profile: %v", msg.err))
 			if m.profileForm.mode == profileFormCreate {
@@ -1672,21 +2074,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.updateError = nil
 		}
 
+	case tea.MouseMsg:
+		if k, ok := m.mouseKey(msg); ok {
+			return m.Update(k)
+		}
+		return m, nil
+
 	case tea.KeyMsg:
-		// Remap vim j/k to arrow keys when not typing in a text field
-		msg = m.remapVimKeys(msg)
+		// Remap h/j/k/l (or the keymap's equivalents) when not typing in a text field
+		msg = m.remapKeys(msg)
 
-		if key.Matches(msg, m.keys.Quit) && !(msg.String() == "q" && m.isTextInputActive()) {
+		if key.Matches(msg, m.keys.Quit) && !(msg.Type == tea.KeyRunes && m.isTextInputActive()) {
 			if m.updateCancelFn != nil {
 				m.updateCancelFn()
 				m.updateCancelFn = nil
 			}
-			return m, tea.Quit
+			return m, m.quit()
 		}
 		if key.Matches(msg, m.keys.Help) {
 			if !m.isTextInputActive() {
 				m.help.ShowAll = !m.help.ShowAll
-				if m.state == viewNetworksList || m.state == viewActiveConnectionInfo || m.state == viewProfileDetails {
+				if m.state == viewNetworksList || m.state == viewActiveConnectionInfo || m.state == viewProfileDetails || m.state == viewDiagnostics {
 					avW := m.width - appStyle.GetHorizontalFrameSize()
 					hH := lipgloss.Height(m.headerView(avW))
 					tk := m.keys
@@ -1700,7 +2108,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					switch m.state {
 					case viewNetworksList:
 						m.wifiList.SetSize(m.listDisplayWidth, nCAH)
-					case viewActiveConnectionInfo, viewProfileDetails:
+					case viewActiveConnectionInfo, viewProfileDetails, viewDiagnostics:
 						m.activeConnInfoViewport.Height = nCAH - infoBoxStyle.GetVerticalFrameSize()
 						if m.activeConnInfoViewport.Height < 0 {
 							m.activeConnInfoViewport.Height = 0
@@ -1711,8 +2119,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if key.Matches(msg, m.keys.Undo) && m.undo != nil && !m.isTextInputActive() {
+			return m, tea.Batch(m.undoLast()...)
+		}
+		if !m.isTextInputActive() && m.state != viewConnecting && m.state != viewUpdating {
+			if key.Matches(msg, m.keys.Palette) {
+				m.openPalette()
+				return m, nil
+			}
+			if a, ok := m.keyRunAction(msg); ok {
+				return m, tea.Batch(m.runAction(a)...)
+			}
+		}
+
 		// Shift+U: trigger in-TUI update
-		if msg.String() == "U" && m.updateAvailable && !m.isTextInputActive() && !m.isUpdating {
+		if key.Matches(msg, m.keys.Update) && m.updateAvailable && !m.isTextInputActive() && !m.isUpdating {
 			if m.state != viewConnecting && m.state != viewUpdating {
 				ctx, cancel := context.WithCancel(context.Background())
 				m.updateCancelFn = cancel
@@ -1733,11 +2154,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.state {
 		case viewKnownNetworksList:
 			cmds = append(cmds, m.handleKnownNetworksListKeys(msg)...)
+		case viewSurvey:
+			cmds = append(cmds, m.handleSurveyKeys(msg)...)
+		case viewRestore:
+			cmds = append(cmds, m.handleRestoreKeys(msg)...)
+		case viewHistory:
+			cmds = append(cmds, m.handleHistoryKeys(msg)...)
+		case viewCleanup:
+			cmds = append(cmds, m.handleCleanupKeys(msg)...)
+		case viewBulk:
+			cmds = append(cmds, m.handleBulkKeys(msg)...)
+		case viewJoinOrder:
+			cmds = append(cmds, m.handleJoinOrderKeys(msg)...)
+		case viewConnectFor:
+			cmds = append(cmds, m.handleConnectForKeys(msg)...)
+		case viewLocations:
+			cmds = append(cmds, m.handleLocationsKeys(msg)...)
+		case viewPalette:
+			cmds = append(cmds, m.handlePaletteKeys(msg)...)
 		case viewProfileDetails:
 			switch {
-			case key.Matches(msg, m.keys.Back) || msg.String() == "h":
+			case key.Matches(msg, m.keys.Back):
 				m.state = viewKnownNetworksList
+				m.psk = savedPSK{}
 				m.clearStatus()
+			case key.Matches(msg, m.keys.RevealPassword):
+				cmds = append(cmds, m.revealPassword()...)
+			case key.Matches(msg, m.keys.CopyPassword):
+				cmds = append(cmds, m.copyPassword()...)
 			case key.Matches(msg, m.keys.EditProfile):
 				if m.profileDetailsID == "" {
 					m.connectionStatusMsg = errorStyle.Render("Cannot edit profile without UUID.")
@@ -1772,20 +2216,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.profileForm.discardArmed = false
 				m.profileForm.statusMsg = toggleHiddenStatusMsgStyle.Render("Password will be cleared on save.")
 			case key.Matches(msg, m.keys.Connect):
-				spec, passwordProvided, _, err := m.validateProfileForm()
-				if err != nil {
-					m.profileForm.discardArmed = false
-					m.profileForm.statusMsg = errorStyle.Render(err.Error())
-					break
-				}
-				m.profileForm.discardArmed = false
-				m.profileForm.statusMsg = ""
-				m.isLoading = true
-				if m.state == viewProfileCreate {
-					cmds = append(cmds, createProfileCmd(spec), m.spinner.Tick)
-				} else {
-					cmds = append(cmds, updateProfileCmd(m.profileForm.profileID, spec, passwordProvided, m.profileForm.clearPassword), m.spinner.Tick)
-				}
+				cmds = append(cmds, m.saveProfileForm()...)
 			case msg.String() == "tab" || msg.String() == "down":
 				m.profileForm.discardArmed = false
 				m.focusProfileInput(m.profileForm.focusIndex + 1)
@@ -1808,7 +2239,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// If we're filtering, handle filter input
 			if m.isFiltering {
 				switch {
-				case key.Matches(msg, m.keys.Back) || msg.String() == "esc":
+				case key.Matches(msg, m.keys.Back):
 					// Cancel filtering and clear filter - return to default view
 					m.isFiltering = false
 					m.filterQuery = ""
@@ -1880,7 +2311,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Handle custom key bindings
 			switch {
-			case key.Matches(msg, m.keys.Back) || msg.String() == "esc" || msg.String() == "h":
+			case key.Matches(msg, m.keys.Back):
+				if len(m.marks) > 0 {
+					m.clearMarks()
+					break
+				}
 				// If a filter is active (but not currently editing), clear it
 				if m.filterQuery != "" {
 					m.filterQuery = ""
@@ -1893,6 +2328,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.wifiList, cmd = m.wifiList.Update(msg)
 				cmds = append(cmds, cmd)
 
+			case key.Matches(msg, m.keys.Mark):
+				m.toggleMark(&m.wifiList)
+
+			case key.Matches(msg, m.keys.Bulk):
+				m.openBulk()
+
+			case key.Matches(msg, m.keys.ConnectFor):
+				cmds = append(cmds, m.openConnectFor(&m.wifiList)...)
+
+			case key.Matches(msg, m.keys.Locations):
+				cmds = append(cmds, m.openLocations()...)
+
 			case key.Matches(msg, m.keys.ToggleHidden):
 				m.showHiddenNetworks = !m.showHiddenNetworks
 				m.applyFilterAndUpdateList()
@@ -1983,18 +2430,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.resizeComponents()
 				cmds = append(cmds, fetchKnownWifiApsCmd(), m.spinner.Tick)
 
+			case key.Matches(msg, m.keys.Survey):
+				if !m.wifiEnabled {
+					m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render("Enable Wi-Fi to start a survey.")
+					break
+				}
+				cmds = append(cmds, m.startSurvey()...)
+
+			case key.Matches(msg, m.keys.Diagnose):
+				m.clearStatus()
+				cmds = append(cmds, m.startDiagnostics())
+
+			case key.Matches(msg, m.keys.Portal) && m.portal != nil:
+				cmds = append(cmds, m.openPortalLogin())
+
+			case key.Matches(msg, m.keys.Watchdog):
+				cmds = append(cmds, m.toggleWatchdog())
+
 			case key.Matches(msg, m.keys.Info):
 				if m.activeWifiConnection != nil && m.activeWifiDevice != "" {
 					m.state = viewActiveConnectionInfo
 					m.isLoading = true
+					m.activeConnInfoText = ""
 					m.activeConnInfoViewport.SetContent("Loading...")
 					m.activeConnInfoViewport.GotoTop()
-					cmds = append(cmds, fetchActiveConnInfoCmd(m.activeWifiDevice), m.spinner.Tick)
+					cmds = append(cmds, fetchActiveConnInfoCmd(m.activeWifiDevice), m.startLiveStats(m.activeWifiDevice), m.spinner.Tick)
 					m.connectionStatusMsg = ""
 				} else {
 					m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render("No active connection.")
 				}
-			case key.Matches(msg, m.keys.Connect) || msg.String() == "l":
+			case key.Matches(msg, m.keys.Connect):
 				if item, ok := m.wifiList.SelectedItem().(wifiAP); ok {
 					m.selectedAP = item
 					ssid := item.getSSIDFromScannedAP()
@@ -2013,17 +2478,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					isOpen := sec == "" || sec == "open" || sec == "--"
 					log.Printf("Connect: SSID '%s', Known: %t, Open: %t", ssid, item.IsKnown, isOpen)
-					if isOpen || item.IsKnown {
+					if _, unsaved := m.unsavedPSKProfile(ssid); unsaved && !isOpen {
+						cmds = append(cmds, m.askForPassword(toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("%s does not store its password. Enter it:", item.StyledTitle())))...)
+					} else if isOpen || item.IsKnown {
 						m.isLoading = true
 						m.state = viewConnecting
 						m.connectionStatusMsg = fmt.Sprintf("Connecting to %s...", item.StyledTitle())
 						cmds = append(cmds, connectToWifiCmd(ssid, "", item.IsKnown), m.spinner.Tick)
 					} else {
-						m.state = viewPasswordInput
-						m.passwordInput.SetValue("")
-						m.passwordInput.Focus()
-						m.connectionStatusMsg = ""
-						cmds = append(cmds, textinput.Blink)
+						cmds = append(cmds, m.askForPassword("")...)
 					}
 				}
 			default:
@@ -2035,11 +2498,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			passthrough := true
 			switch {
 			case key.Matches(msg, m.keys.Connect):
+				passthrough = false
+				if m.selectedAP.usesWPAPSK() {
+					if err := gonetworkmanager.ValidateWifiPSK(m.passwordInput.Value()); err != nil {
+						m.passwordInput.Err = err
+						break
+					}
+				}
 				m.isLoading = true
 				m.state = viewConnecting
 				m.connectionStatusMsg = fmt.Sprintf("Connecting to %s...", m.selectedAP.StyledTitle())
-				cmds = append(cmds, connectToWifiCmd(m.selectedAP.getSSIDFromScannedAP(), m.passwordInput.Value(), false), m.spinner.Tick)
-				passthrough = false
+				cmds = append(cmds, m.connectWithPasswordCmd(m.selectedAP.getSSIDFromScannedAP(), m.passwordInput.Value()), m.spinner.Tick)
 			case key.Matches(msg, m.keys.Back):
 				m.state = viewNetworksList
 				m.passwordInput.Blur()
@@ -2048,21 +2517,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if passthrough {
 				m.passwordInput, cmd = m.passwordInput.Update(msg)
+				m.passwordInput.Err = nil
 				cmds = append(cmds, cmd)
 			}
 		case viewConnectionResult:
-			if key.Matches(msg, m.keys.Connect) || key.Matches(msg, m.keys.Back) {
+			if key.Matches(msg, m.keys.Portal) && m.portal != nil {
+				cmds = append(cmds, m.openPortalLogin())
+			} else if key.Matches(msg, m.keys.Connect) || key.Matches(msg, m.keys.Back) {
 				m.state = viewNetworksList
 				m.connectionStatusMsg = ""
 			}
 		case viewActiveConnectionInfo:
-			if key.Matches(msg, m.keys.Back) || msg.String() == "h" {
+			if key.Matches(msg, m.keys.Back) {
 				m.state = viewNetworksList
+				m.liveStats = nil
 				m.connectionStatusMsg = ""
 			} else {
 				m.activeConnInfoViewport, cmd = m.activeConnInfoViewport.Update(msg)
 				cmds = append(cmds, cmd)
 			}
+		case viewDiagnostics:
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				if !m.isLoading {
+					m.state = m.previousState
+					m.previousState = viewNetworksList
+					m.clearStatus()
+				}
+			case key.Matches(msg, m.keys.Refresh):
+				if !m.isLoading {
+					cmds = append(cmds, m.startDiagnostics())
+				}
+			default:
+				m.activeConnInfoViewport, cmd = m.activeConnInfoViewport.Update(msg)
+				cmds = append(cmds, cmd)
+			}
 		case viewConfirmDisconnect: /* Same */
 			switch {
 			case key.Matches(msg, m.keys.Connect):
@@ -2143,7 +2632,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, m.keys.Connect):
 				if !m.isUpdating && m.updateNewVersion != "" {
 					m.wantsRestart = true
-					return m, tea.Quit
+					return m, m.quit()
 				}
 			}
 		}
@@ -2155,9 +2644,7 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 	avW := m.width - appStyle.GetHorizontalFrameSize()
 	var mainSb strings.Builder
 	hView := m.headerView(avW)
-	m.keys.currentState = m.state
-	m.keys.Update.SetEnabled(m.updateAvailable && !m.isUpdating)
-	helpR := m.help.View(m.keys)
+	helpR := m.help.View(m.activeKeys())
 	fView := m.footerView(avW, helpR)
 	hH := lipgloss.Height(hView)
 	fH := lipgloss.Height(fView)
@@ -2175,7 +2662,7 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 		if m.isFiltering {
 			filterStyle := lipgloss.NewStyle().
 				BorderStyle(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("62")).
+				BorderForeground(ansBorderColor).
 				Padding(0, 1)
 			filterR := filterStyle.Render(m.filterInput.View())
 
@@ -2183,13 +2670,13 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 			combined := lipgloss.JoinVertical(lipgloss.Top, listR, "", filterR)
 
 			if networkListWidthPercent > 0 || networkListFixedWidth > 0 {
-				currMainS = lipgloss.PlaceHorizontal(avW, lipgloss.Center, combined)
+				currMainS = m.withDetailPane(avW, cdh, combined, m.wifiList)
 			} else {
 				currMainS = combined
 			}
 		} else {
 			if networkListWidthPercent > 0 || networkListFixedWidth > 0 {
-				currMainS = lipgloss.PlaceHorizontal(avW, lipgloss.Center, listR)
+				currMainS = m.withDetailPane(avW, cdh, listR, m.wifiList)
 			} else {
 				currMainS = listR
 			}
@@ -2232,6 +2719,8 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 		pwBlock := lipgloss.JoinVertical(lipgloss.Top, cP, inputR)
 		if m.passwordInput.Err != nil {
 			pwBlock = lipgloss.JoinVertical(lipgloss.Top, pwBlock, errorStyle.Render(m.passwordInput.Err.Error()))
+		} else if hint := pskStrengthHint(m.passwordInput.Value()); hint != "" && m.selectedAP.usesWPAPSK() {
+			pwBlock = lipgloss.JoinVertical(lipgloss.Top, pwBlock, lipgloss.NewStyle().Foreground(ansFaintTextColor).Render(hint))
 		}
 		currMainS = passwordInputContainerStyle.Render(pwBlock)
 	case viewConnecting:
@@ -2247,15 +2736,21 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 		}
 		wrapMsg := lipgloss.NewStyle().Width(msgBW).Align(lipgloss.Center).Render(msgR)
 		hint := lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Enter/Esc to return)")
-		currMainS = lipgloss.JoinVertical(lipgloss.Center, wrapMsg, "", hint)
+		currMainS = lipgloss.JoinVertical(lipgloss.Center, wrapMsg, "", renderDialogButtons(m.dialogButtons()), hint)
 	case viewActiveConnectionInfo:
 		currMainS = m.activeConnInfoViewport.View()
 	case viewProfileDetails:
 		currMainS = m.activeConnInfoViewport.View()
+	case viewDiagnostics:
+		if m.isLoading {
+			currMainS = connectingStyle.Render(fmt.Sprintf("\n%s Running diagnostics...\n", m.spinner.View()))
+		} else {
+			currMainS = m.activeConnInfoViewport.View()
+		}
 	case viewConfirmDisconnect:
-		currMainS = lipgloss.JoinVertical(lipgloss.Center, fmt.Sprintf("Disconnect from %s ?", m.selectedAP.StyledTitle()), "\n", lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Enter to confirm, Esc to cancel)"))
+		currMainS = lipgloss.JoinVertical(lipgloss.Center, fmt.Sprintf("Disconnect from %s ?", m.selectedAP.StyledTitle()), "\n", renderDialogButtons(m.dialogButtons()), lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Enter to confirm, Esc to cancel)"))
 	case viewConfirmForget:
-		currMainS = lipgloss.JoinVertical(lipgloss.Center, fmt.Sprintf("Forget profile for\n%s ?", m.selectedAP.StyledTitle()), "\n", lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Enter to confirm, Esc to cancel)"))
+		currMainS = lipgloss.JoinVertical(lipgloss.Center, fmt.Sprintf("Forget profile for\n%s ?", m.selectedAP.StyledTitle()), "\n", renderDialogButtons(m.dialogButtons()), lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Enter to confirm, Esc to cancel)"))
 	case viewUpdating:
 		msgW := avW * 3 / 4
 		if msgW > 80 {
@@ -2282,10 +2777,31 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 			hint := lipgloss.NewStyle().Foreground(ansFaintTextColor).Render("(Esc to go back)")
 			currMainS = lipgloss.JoinVertical(lipgloss.Center, "", statusMsg, "", hint)
 		}
+	case viewSurvey:
+		currMainS = m.surveyView(avW, cdh)
+	case viewRestore:
+		currMainS = m.restoreView(avW, cdh)
+	case viewHistory:
+		currMainS = m.historyView(avW, cdh)
+	case viewCleanup:
+		currMainS = m.cleanupView(avW, cdh)
+	case viewBulk:
+		currMainS = m.bulkView(avW, cdh)
+	case viewJoinOrder:
+		currMainS = m.joinOrderView(avW, cdh)
+	case viewConnectFor:
+		currMainS = m.connectForView(avW, cdh)
+	case viewLocations:
+		currMainS = m.locationsView(avW, cdh)
+	case viewPalette:
+		currMainS = m.paletteView(avW, cdh)
 	case viewKnownNetworksList:
 		listR := m.knownWifiList.View()
+		if m.importPathInput.Focused() {
+			listR = lipgloss.JoinVertical(lipgloss.Top, listR, "", m.importPathView())
+		}
 		if networkListWidthPercent > 0 || networkListFixedWidth > 0 {
-			currMainS = lipgloss.PlaceHorizontal(avW, lipgloss.Center, listR)
+			currMainS = m.withDetailPane(avW, cdh, listR, m.knownWifiList)
 		} else {
 			currMainS = listR
 		}
@@ -2301,13 +2817,19 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 			if i != m.profileForm.focusIndex {
 				v := m.profileForm.inputs[i].Value()
 				if i == profileFieldPassword {
-					if v != "" {
+					if flags, err := parsePSKStorage(m.profileForm.inputs[profileFieldPSKStorage].Value()); err == nil && flags != gonetworkmanager.PSKFlagsSystem {
+						v = "(not stored)"
+					} else if v != "" {
 						v = strings.Repeat("*", len(v))
 					} else if m.state == viewProfileEdit {
 						v = "(unchanged)"
 					}
 				}
 				fieldLine = v
+			} else if i == profileFieldPassword {
+				if hint := pskStrengthHint(m.profileForm.inputs[i].Value()); hint != "" {
+					fieldLine += "  " + lipgloss.NewStyle().Foreground(ansFaintTextColor).Render(hint)
+				}
 			}
 			prefix := "  "
 			if i == m.profileForm.focusIndex {
@@ -2322,7 +2844,7 @@ func (m model) View() string { /* Same as previous version with "Not enough spac
 		}
 		currMainS = infoBoxStyle.Render(strings.Join(lines, "\n"))
 	}
-	if m.state != viewNetworksList && m.state != viewActiveConnectionInfo && m.state != viewProfileDetails {
+	if m.state != viewNetworksList && m.state != viewActiveConnectionInfo && m.state != viewProfileDetails && (m.state != viewDiagnostics || m.isLoading) {
 		currMainS = lipgloss.Place(avW, cdh, lipgloss.Center, lipgloss.Center, currMainS)
 	}
 	mainSb.WriteString(currMainS)
@@ -2343,6 +2865,9 @@ func (m model) headerView(w int) string {
 	} else {
 		s += wifiStatusStyleDisabled.Render("Disabled ✘")
 	}
+	if m.watchdog != nil {
+		s += lipgloss.NewStyle().Foreground(ansFaintTextColor).Render(" · watchdog")
+	}
 
 	// Update hint (dim, non-intrusive)
 	updateHint := ""
@@ -2361,7 +2886,8 @@ func (m model) headerView(w int) string {
 		if sp < 1 {
 			sp = 1
 		}
-		return lipgloss.JoinHorizontal(lipgloss.Left, t, strings.Repeat(" ", sp), s)
+		header := lipgloss.JoinHorizontal(lipgloss.Left, t, strings.Repeat(" ", sp), s)
+		return m.withHeaderBanners(header, w)
 	}
 
 	// Distribute remaining space
@@ -2376,7 +2902,19 @@ func (m model) headerView(w int) string {
 		rightSpace = 1
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Left, t, strings.Repeat(" ", leftSpace), scanIndicator, strings.Repeat(" ", rightSpace), s, updateHint)
+	header := lipgloss.JoinHorizontal(lipgloss.Left, t, strings.Repeat(" ", leftSpace), scanIndicator, strings.Repeat(" ", rightSpace), s, updateHint)
+	return m.withHeaderBanners(header, w)
+}
+
+// withHeaderBanners appends the one-line portal, quota, time limit and undo
+// notices below the header row.
+func (m model) withHeaderBanners(header string, w int) string {
+	for _, banner := range []string{m.portalBannerView(w), m.quotaBannerView(w), m.timerBannerView(w), m.undoToastView(w)} {
+		if banner != "" {
+			header = lipgloss.JoinVertical(lipgloss.Left, header, banner)
+		}
+	}
+	return header
 }
 func (m model) footerView(w int, h string) string { /* Same */
 	return lipgloss.PlaceHorizontal(w, lipgloss.Center, helpGlobalStyle.Render(h))
@@ -2401,29 +2939,129 @@ Usage:
   nmtui-go [--help] [--version]
   nmtui-go [--update] [--update-prerelease] [--no-backup]
   nmtui-go [--check-update]
+  nmtui-go diagnose [--device DEV] [--dns-name NAME] [--tcp HOST:PORT] [--mtu-target HOST]
+  nmtui-go portal [--open] [--wait] [--interval 5s]
+  nmtui-go watch [--device DEV] [--interval 10s] [--grace 60s] [--no-fallback]
+  nmtui-go apply [--plan] [--prune] [--update-secrets] FILE.yaml|FILE.toml
+  nmtui-go plan [--prune] [--exit-code] FILE.yaml|FILE.toml
+  nmtui-go profile export [--secrets] [--dir DIR] [--all] [PROFILE...]
+  nmtui-go profile import [--replace] FILE.nmconnection...
+  nmtui-go profile prune [--older-than 90d] [--never-used] [--duplicates] [--dry-run] [--yes]
+  nmtui-go backup [-o FILE.nmbak] [--passphrase-file FILE]
+  nmtui-go restore [--dry-run] [--yes] [--only NAMES] [--passphrase-file FILE] FILE.nmbak
+  nmtui-go history [list | restore N]
+  nmtui-go usage [list | quota PROFILE SIZE|off]
+  nmtui-go schedule [list | connect PROFILE MINUTES | cancel PROFILE | add [--days mon-fri] [--disconnect] PROFILE HH:MM-HH:MM | remove PROFILE | run]
+  nmtui-go location [list | switch NAME | detect [--switch]]
+  nmtui-go config [show | path]
+  nmtui-go [--config FILE] [--set KEY=VALUE]... [COMMAND]
 
 Options:
   -h, --help            Show this help and exit
   -v, --version         Show version/build metadata and exit
   --update              Self-update to the latest GitHub release
   --check-update        Check if a newer version is available
+  --config FILE         Read settings from FILE instead of
+                        ~/.config/nmtui-go/config.toml
+  --set KEY=VALUE       Override one config setting (repeatable); must come
+                        before the command
 
   Modifiers for --update:
   --update-prerelease   Include pre-release versions when updating
   --no-backup           Don't keep backup of old binary after update
 
+Subcommands:
+  diagnose              Check gateway, DNS servers, NetworkManager connectivity,
+                        a TCP endpoint and path MTU; exits 1 if any check fails.
+                        Flags: --device, --dns-name, --dns-port, --tcp,
+                        --mtu-target, --timeout, --no-connectivity
+  portal                Check for a captive portal and print its login URL;
+                        --open launches it with xdg-open, --wait re-checks
+                        until connectivity is full. Exits 0 once online.
+  watch                 Headless connection watchdog: re-activates the active
+                        profile when it drops or connectivity stays
+                        limited/none for --grace, then falls back to other
+                        known in-range networks by priority. Runs until
+                        interrupted; every action is printed and logged.
+  apply                 Reconcile Wi-Fi/ethernet/VPN profiles with a YAML or
+                        TOML file: prints a create/modify/delete/unchanged
+                        plan, then applies it. --prune deletes profiles not
+                        in the file; --update-secrets rewrites passwords.
+  plan                  Same as apply --plan; --exit-code exits 3 when the
+                        file and NetworkManager differ (for CI review).
+  profile export        Write profiles (name or UUID, or --all) as
+                        NetworkManager .nmconnection keyfiles (mode 0600).
+                        Secrets are left out unless --secrets is given.
+  profile import        Create profiles from .nmconnection keyfiles, keeping
+                        their UUIDs; --replace overwrites an existing profile
+                        with the same UUID.
+  profile prune         Delete Wi-Fi profiles not used for --older-than
+                        (90d, 2w, 36h); --never-used and --duplicates also
+                        delete never-connected profiles and older duplicates
+                        of an SSID. Asks first unless --yes; --dry-run lists.
+  backup                Write every Wi-Fi/ethernet/VPN profile, including
+                        secrets (nmcli --show-secrets; run as root for system
+                        secrets), into one passphrase-encrypted archive.
+  restore               Show which profiles a backup would add or overwrite,
+                        ask for confirmation (--yes skips, --dry-run only
+                        shows), then recreate them with their original UUIDs.
+  history               List the profile snapshots taken before every delete
+                        or modify (newest first); "history restore N" puts
+                        snapshot N back with its original UUID.
+  usage                 Show this month's data usage per profile, recorded
+                        while the TUI or watch runs; "usage quota PROFILE 5G"
+                        sets a monthly quota ("off" removes it).
+  schedule              Time-limited connections ("connect Cafe 45") and
+                        per-profile autoconnect windows ("add --days mon-fri
+                        Office 09:00-18:00", --disconnect also drops it when
+                        the window ends). Applied while the TUI or watch runs,
+                        or once by "schedule run" (e.g. from cron).
+  config                Print the effective configuration (flags > env >
+                        config file > defaults) with the source of each
+                        value; "config path" prints the file location.
+  location              List the location sets in
+                        ~/.config/nmtui-go/locations.toml, switch to one
+                        (autoconnect, priority, DNS, connect/disconnect), or
+                        "detect" the current one from visible BSSIDs
+                        (--switch applies it).
+
 Features:
   - Scan for Wi-Fi networks (rescan on demand)
   - Connect to open and WPA/WPA2 PSK networks
   - Reuse existing NetworkManager profiles when available
   - Unified list with active and known indicators
   - Toggle Wi-Fi radio on/off
+  - Detail pane beside the lists on wide terminals (BSSIDs, profile, signal chart)
   - Show active connection details (IP, gateway, DNS, etc.)
+  - Live throughput, packet/error counters and link quality in the info view
   - Manage known Wi-Fi profiles (view/details/create/edit/forget)
+  - Reveal or copy (OSC 52, auto-cleared) the saved password of any profile
+  - Passwords from a password manager command, optionally kept out of NetworkManager
+  - Per-profile password storage: for all users, for this user only, or always ask
   - Disconnect active Wi-Fi connection
   - Filter network list by SSID
-
-Runtime keybindings (inside TUI):
+  - Built-in diagnostics (gateway, DNS, connectivity, TCP, MTU)
+  - Captive portal detection after connecting, with a header banner and login assist
+  - Opt-in connection watchdog that re-activates dropped or degraded connections
+  - Declarative profile management from YAML/TOML with plan/apply
+  - Export/import profiles as NetworkManager .nmconnection keyfiles
+  - Encrypted backup/restore of all profiles including secrets
+  - Snapshots before every forget/edit/replace, with undo and a restorable history
+  - Multi-select (Space) with bulk forget/autoconnect/priority/export/share
+  - Auto-join order editor for autoconnect priorities, with NetworkManager's current pick
+  - Stale profile cleanup (unused, never connected, duplicate SSIDs) with bulk delete
+  - Metered profiles, per-profile monthly data usage and quota warnings
+  - Time-limited connections and scheduled autoconnect windows per profile
+  - Location sets (Home/Office/...) switched by hand or detected from BSSIDs
+  - Optional config file (~/.config/nmtui-go/config.toml) with "config show"
+  - Themes (default, light, high-contrast, colorblind-safe, monochrome), NO_COLOR
+  - Rebindable keys with vim, emacs and arrows-only presets
+  - Command palette (: or Ctrl+p) listing every action of the current screen
+  - Mouse: click to select, double-click to connect, wheel scrolling,
+    clickable help-bar entries and dialog buttons
+  - Site survey mode with per-location signal recording and CSV/JSON export
+
+Runtime keybindings (inside TUI, default keymap; rebind in config.toml):
   Arrow Up/Down   Navigate list
   Enter           Select/connect/confirm
   Esc             Back/cancel
@@ -2433,12 +3071,29 @@ Runtime keybindings (inside TUI):
   t               Toggle Wi-Fi radio
   d               Disconnect active Wi-Fi
   i               Active connection info
+  D               Run network diagnostics
+  o               Open captive portal login page (when a portal is detected)
+  W               Toggle the connection watchdog
   p               Known profiles view
+  S               Site survey (in survey: Enter sets location, x exports)
   n               New profile (in profiles view)
   e               Edit selected profile
+  v / c           Reveal / copy the saved password (in profile details)
   Ctrl+f          Forget selected known profile
+  x / X           Export selected profile as a keyfile (X includes secrets)
+  I               Import a .nmconnection keyfile (in profiles view)
+  R               Restore profiles from an encrypted backup (in profiles view)
+  H               Profile history: restore a deleted or modified profile
+  Space           Mark saved network for bulk actions (Esc clears marks)
+  b               Bulk actions on marked profiles
+  C               Clean up stale profiles (in profiles view)
+  O               Auto-join order: reorder autoconnect priorities (in profiles view)
+  T               Connect saved network for N minutes (T again cancels)
+  L               Switch location set
+  z               Undo the last forget/edit (while the undo notice is shown)
   Ctrl+x          Clear password in profile form
   U (Shift+U)     Update to latest version (when update available)
+  : / Ctrl+p      Command palette: fuzzy-search every action of the screen
   ?               Toggle extended in-app help
   q / Ctrl+c      Quit (plain q is ignored while typing in text inputs)
 
@@ -2459,12 +3114,31 @@ Environment variables:
   NMTUI_NO_UPDATE_CHECK=1       Disable automatic update check on startup
   NMTUI_UPDATE_PRERELEASE=1     Include pre-release versions in update checks
   NMTUI_UPDATE_KEEP_BACKUP=0    Don't keep .old backup after update
+  NMTUI_WATCHDOG=1              Start the TUI with the connection watchdog enabled
+  NMTUI_WATCHDOG_INTERVAL=10s   Watchdog sampling interval (TUI and watch)
+  NMTUI_WATCHDOG_GRACE=60s      How long limited/none is tolerated before recovery
+  NMTUI_BACKUP_PASSPHRASE=...   Passphrase for backup/restore (instead of a prompt)
+  NMTUI_NMCLI_TIMEOUT=45s       Timeout for a single nmcli call
+  NMTUI_LOG_FILE=PATH           Debug log location (default ./nmtui-debug.log)
+  NMTUI_THEME=NAME              Color theme (default, light, high-contrast, ...)
+  NO_COLOR=1                    Use the monochrome theme unless one is configured
+  NMTUI_KEYMAP=NAME             Key binding preset (vim, emacs, arrows-only)
+  NMTUI_SECRET_COMMAND=CMD      Password lookup before prompting, e.g. "pass show wifi/{ssid}"
   GITHUB_TOKEN=<token>          Increase GitHub API rate limit (60->5000/hr)
 
+Config file:
+  ~/.config/nmtui-go/config.toml holds the settings above (except the
+  passphrase and token) plus network_list_width, scan_on_start, show_hidden,
+  sort_order, rescan_interval, secret_agent_owned and the diagnose_* endpoints
+  used by the TUI and "diagnose", and a [colors] table overriding single
+  theme colors (e.g. success = "10") and a [keys] table rebinding actions
+  (e.g. refresh = ["r", "ctrl+r"]). Flags override the environment, which
+  overrides the file. See "config show".
+
 Debug logging:
   Run with:
       DEBUG_TEA=1 nmtui-go
-  This writes nmtui-debug.log in the current directory.
+  This writes nmtui-debug.log in the current directory (or log_file).
   Sensitive nmcli arguments (passwords, pins, psk) are redacted.
 
 Project:
@@ -2494,9 +3168,9 @@ func handleCLIFlags(args []string) (exitNow bool, exitCode int) {
 		for _, a := range args[1:] {
 			switch a {
 			case "--update-prerelease":
-				os.Setenv("NMTUI_UPDATE_PRERELEASE", "1")
+				configFlags = append(configFlags, "update_prerelease=true")
 			case "--no-backup":
-				os.Setenv("NMTUI_UPDATE_KEEP_BACKUP", "0")
+				configFlags = append(configFlags, "update_keep_backup=false")
 			}
 		}
 		exitCode := performSelfUpdateCLI()
@@ -2504,6 +3178,32 @@ func handleCLIFlags(args []string) (exitNow bool, exitCode int) {
 	case "--check-update":
 		exitCode := performCheckUpdateCLI()
 		return true, exitCode
+	case "diagnose":
+		return true, runDiagnoseCLI(args[1:], os.Stdout, os.Stderr)
+	case "portal":
+		return true, runPortalCLI(args[1:], os.Stdout, os.Stderr)
+	case "watch":
+		return true, runWatchCLI(args[1:], os.Stdout, os.Stderr)
+	case "apply":
+		return true, runApplyCLI(args[1:], false, os.Stdout, os.Stderr)
+	case "plan":
+		return true, runApplyCLI(args[1:], true, os.Stdout, os.Stderr)
+	case "profile":
+		return true, runProfileCLI(args[1:], os.Stdin, os.Stdout, os.Stderr)
+	case "backup":
+		return true, runBackupCLI(args[1:], os.Stdout, os.Stderr)
+	case "restore":
+		return true, runRestoreCLI(args[1:], os.Stdin, os.Stdout, os.Stderr)
+	case "history":
+		return true, runHistoryCLI(args[1:], os.Stdout, os.Stderr)
+	case "usage":
+		return true, runUsageCLI(args[1:], os.Stdout, os.Stderr)
+	case "config":
+		return true, runConfigCLI(args[1:], os.Stdout, os.Stderr)
+	case "location":
+		return true, runLocationCLI(args[1:], os.Stdout, os.Stderr)
+	case "schedule":
+		return true, runScheduleCLI(args[1:], os.Stdout, os.Stderr)
 	default:
 		if strings.HasPrefix(args[0], "-") {
 			fmt.Fprintf(os.Stderr, "Unknown option: %s\n\n", args[0])
@@ -2515,17 +3215,31 @@ func handleCLIFlags(args []string) (exitNow bool, exitCode int) {
 }
 
 func main() { /* Same log setup */
-	if exitNow, exitCode := handleCLIFlags(os.Args[1:]); exitNow {
+	installProfileSnapshots()
+	args, err := parseGlobalOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	// A broken config is fatal, except for the commands that help fix it.
+	cfg, err := loadAppConfig()
+	if err != nil && !(len(args) > 0 && slices.Contains([]string{"config", "-h", "--help", "-v", "--version"}, args[0])) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	applyProcessConfig(cfg)
+	if exitNow, exitCode := handleCLIFlags(args); exitNow {
 		os.Exit(exitCode)
 	}
 
 	logOut := io.Discard
 	var logFH *os.File
-	if os.Getenv("DEBUG_TEA") != "" {
+	if cfg.Debug {
 		var err error
-		logFH, err = os.OpenFile(debugLogFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		logFH, err = os.OpenFile(cfg.LogFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Err open log %s: %v\n", debugLogFile, err)
+			fmt.Fprintf(os.Stderr, "Err open log %s: %v\n", cfg.LogFile, err)
 		} else {
 			logOut = logFH
 			defer func() {
@@ -2538,17 +3252,18 @@ func main() { /* Same log setup */
 	}
 	log.SetOutput(logOut)
 	log.SetFlags(log.Ltime | log.Lshortfile)
-	if os.Getenv("DEBUG_TEA") != "" && logOut != io.Discard {
+	if cfg.Debug && logOut != io.Discard {
 		log.Println("--- NMTUI Log Start ---")
 	}
 	im := initialModel()
-	p := tea.NewProgram(im, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(im, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithOutput(tuiOutput))
 	tuiProgram = p
 	fm, err := p.Run()
 	if err != nil {
 		log.Printf("Err run TUI: %v", err)
 		if fmm, ok := fm.(model); ok {
-			log.Printf("Final model on err: %+v", fmm)
+			// Not the whole model: it may hold a typed or revealed password.
+			log.Printf("Final model on err: state %d (previous %d), status %q", fmm.state, fmm.previousState, fmm.connectionStatusMsg)
 		}
 		if logOut == io.Discard {
 			fmt.Fprintf(os.Stderr, "Err run TUI: %v\n", err)
@@ -0,0 +1,222 @@
+// nmtui/cmd/mouse.go
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// doubleClickInterval is how quickly a second click on the same list item
+// must follow the first to count as a double click.
+const doubleClickInterval = 400 * time.Millisecond
+
+type mouseClick struct {
+	at    time.Time
+	state viewState
+	index int
+}
+
+// dialogButton is a clickable label in a dialog that stands for a key.
+type dialogButton struct {
+	label string
+	key   tea.KeyType
+}
+
+func (m model) dialogButtons() []dialogButton {
+	switch m.state {
+	case viewConfirmDisconnect:
+		return []dialogButton{{"Disconnect", tea.KeyEnter}, {"Cancel", tea.KeyEsc}}
+	case viewConfirmForget:
+		return []dialogButton{{"Forget", tea.KeyEnter}, {"Cancel", tea.KeyEsc}}
+	case viewConnectionResult:
+		return []dialogButton{{"OK", tea.KeyEnter}}
+	}
+	return nil
+}
+
+func (b dialogButton) text() string { return "[ " + b.label + " ]" }
+
+func renderDialogButtons(buttons []dialogButton) string {
+	parts := make([]string, len(buttons))
+	for i, b := range buttons {
+		parts[i] = dialogButtonStyle.Render(b.text())
+	}
+	return strings.Join(parts, "   ")
+}
+
+// mouseKey turns a mouse event into the key press it stands for: the wheel
+// scrolls like the arrow keys, a click selects a list item (a double click
+// also presses enter), and clicks on help-bar entries and dialog buttons
+// press their key. Events with no such meaning report false.
+func (m *model) mouseKey(msg tea.MouseMsg) (tea.KeyMsg, bool) {
+	if m.isTextInputActive() {
+		return tea.KeyMsg{}, false
+	}
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		return tea.KeyMsg{Type: tea.KeyUp}, true
+	case msg.Button == tea.MouseButtonWheelDown:
+		return tea.KeyMsg{Type: tea.KeyDown}, true
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress:
+		return tea.KeyMsg{}, false
+	}
+
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if msg.Y < 0 || msg.Y >= len(lines) {
+		return tea.KeyMsg{}, false
+	}
+	if k, ok := m.helpKeyAt(lines, msg.X, msg.Y); ok {
+		return k, true
+	}
+	for _, b := range m.dialogButtons() {
+		if labelAt(lines[msg.Y], b.text(), msg.X) {
+			return tea.KeyMsg{Type: b.key}, true
+		}
+	}
+
+	var l *list.Model
+	switch {
+	case m.state == viewNetworksList && !m.isFiltering:
+		l = &m.wifiList
+	case m.state == viewKnownNetworksList:
+		l = &m.knownWifiList
+	default:
+		return tea.KeyMsg{}, false
+	}
+	// The detail pane beside the list is not clickable.
+	avW := m.width - appStyle.GetHorizontalFrameSize()
+	if m.detailPaneWidth(avW) > 0 && msg.X >= appStyle.GetMarginLeft()+m.listDisplayWidth {
+		return tea.KeyMsg{}, false
+	}
+	index, ok := listItemAt(*l, lines, msg.Y)
+	if !ok {
+		return tea.KeyMsg{}, false
+	}
+	l.Select(index)
+	last := m.click
+	m.click = mouseClick{at: time.Now(), state: m.state, index: index}
+	if last.state == m.state && last.index == index && time.Since(last.at) <= doubleClickInterval {
+		m.click = mouseClick{}
+		return tea.KeyMsg{Type: tea.KeyEnter}, true
+	}
+	return tea.KeyMsg{}, false
+}
+
+// helpKeyAt finds the short help entry under the pointer; the help bar is
+// the last lines of the screen above the bottom margin.
+func (m model) helpKeyAt(lines []string, x, y int) (tea.KeyMsg, bool) {
+	if m.help.ShowAll {
+		return tea.KeyMsg{}, false
+	}
+	bottom := len(lines) - 1 - appStyle.GetMarginBottom()
+	if y != bottom {
+		return tea.KeyMsg{}, false
+	}
+	for _, b := range m.activeKeys().ShortHelp() {
+		if !b.Enabled() {
+			continue
+		}
+		if labelAt(lines[y], b.Help().Key+" "+b.Help().Desc, x) {
+			return bindingKeyMsg(b)
+		}
+	}
+	return tea.KeyMsg{}, false
+}
+
+// labelAt reports whether the cell column x of a plain-text line falls on
+// label, matched as a whole word.
+func labelAt(line, label string, x int) bool {
+	for from := 0; ; {
+		i := strings.Index(line[from:], label)
+		if i < 0 {
+			return false
+		}
+		i += from
+		end := i + len(label)
+		before, _ := utf8.DecodeLastRuneInString(line[:i])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if (i == 0 || before == ' ') && (end == len(line) || after == ' ') {
+			start := ansi.StringWidth(line[:i])
+			if x >= start && x < start+ansi.StringWidth(label) {
+				return true
+			}
+		}
+		from = end
+	}
+}
+
+// listItemAt maps a screen line to the index of the list item drawn there,
+// using the list's title line to find where the list starts.
+func listItemAt(l list.Model, lines []string, y int) (int, bool) {
+	title := strings.TrimSpace(ansi.Strip(l.Styles.Title.Render(l.Title)))
+	if n := 12; utf8.RuneCountInString(title) > n {
+		title = string([]rune(title)[:n])
+	}
+	top := -1
+	for i, line := range lines {
+		if strings.Contains(line, title) {
+			top = i
+			break
+		}
+	}
+	if top < 0 {
+		return 0, false
+	}
+	top += lipgloss.Height(l.Styles.TitleBar.Render(l.Styles.Title.Render(l.Title)))
+	if l.ShowStatusBar() {
+		top += lipgloss.Height(l.Styles.StatusBar.Render("x"))
+	}
+	var d itemDelegate
+	row := y - top
+	if row < 0 || row%(d.Height()+d.Spacing()) >= d.Height() {
+		return 0, false
+	}
+	onPage := row / (d.Height() + d.Spacing())
+	if onPage >= l.Paginator.ItemsOnPage(len(l.VisibleItems())) {
+		return 0, false
+	}
+	return l.Paginator.Page*l.Paginator.PerPage + onPage, true
+}
+
+// keyTypesByName maps key names such as "enter" or "ctrl+f" back to their
+// key type.
+var keyTypesByName = func() map[string]tea.KeyType {
+	names := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-128); t <= tea.KeyBackspace; t++ {
+		if t == tea.KeyRunes {
+			continue
+		}
+		if name := (tea.Key{Type: t}).String(); name != "" {
+			if _, dup := names[name]; !dup {
+				names[name] = t
+			}
+		}
+	}
+	return names
+}()
+
+// bindingKeyMsg is the key press of a binding's first key.
+func bindingKeyMsg(b key.Binding) (tea.KeyMsg, bool) {
+	if len(b.Keys()) == 0 {
+		return tea.KeyMsg{}, false
+	}
+	k := b.Keys()[0]
+	alt := false
+	if rest, ok := strings.CutPrefix(k, "alt+"); ok && len(rest) > 0 {
+		k, alt = rest, true
+	}
+	if t, ok := keyTypesByName[k]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}, true
+	}
+	if r := []rune(k); len(r) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: r, Alt: alt}, true
+	}
+	return tea.KeyMsg{}, false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// clickOn left-clicks the first cell of the last occurrence of text on
+// screen.
+func clickOn(t *testing.T, m model, text string) model {
+	t.Helper()
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	for y := len(lines) - 1; y >= 0; y-- {
+		if i := strings.Index(lines[y], text); i >= 0 {
+			updated, _ := m.Update(tea.MouseMsg{X: ansi.StringWidth(lines[y][:i]), Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+			return updated.(model)
+		}
+	}
+	t.Fatalf("%q is not on screen:\n%s", text, m.View())
+	return m
+}
+
+func TestMouseSelectAndDoubleClick(t *testing.T) {
+	m := bulkTestModel(t)
+	m = clickOn(t, m, "Office")
+	if m.knownWifiList.Index() != 2 || m.state != viewKnownNetworksList {
+		t.Fatalf("a click should only select Office, got index %d", m.knownWifiList.Index())
+	}
+	m = clickOn(t, m, "Home")
+	m = clickOn(t, m, "Home")
+	if m.state != viewProfileDetails || m.profileDetailsID != "u-home" {
+		t.Fatalf("a double click should open Home, state %v id %q", m.state, m.profileDetailsID)
+	}
+
+	m.state, m.isLoading = viewKnownNetworksList, false
+	updated, _ := m.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	m = updated.(model)
+	if m.knownWifiList.Index() != 2 {
+		t.Fatalf("the wheel should move the selection, got %d", m.knownWifiList.Index())
+	}
+}
+
+func TestMouseHelpBarAndDialogButtons(t *testing.T) {
+	m := bulkTestModel(t)
+	m = clickOn(t, m, "n new profile")
+	if m.state != viewProfileCreate {
+		t.Fatalf("clicking the help entry should create a profile, state %v", m.state)
+	}
+	// Text inputs keep the mouse out of the way.
+	m = clickOn(t, m, "esc/h back/cancel")
+	if m.state != viewProfileCreate {
+		t.Fatalf("clicks are ignored while typing")
+	}
+
+	m.state = viewConfirmForget
+	m.previousState = viewKnownNetworksList
+	m.selectedAP = m.knownWifiList.Items()[0].(wifiAP)
+	if !strings.Contains(m.View(), "[ Forget ]   [ Cancel ]") {
+		t.Fatalf("expected dialog buttons:\n%s", m.View())
+	}
+	m = clickOn(t, m, "[ Cancel ]")
+	if m.state != viewKnownNetworksList {
+		t.Fatalf("Cancel should close the dialog, state %v", m.state)
+	}
+}
@@ -0,0 +1,171 @@
+// nmtui/cmd/palette.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// paletteScreen is the command palette: every action of keyActions that is
+// available on the screen it was opened from, narrowed by a fuzzy query.
+type paletteScreen struct {
+	input    textinput.Model
+	actions  []keyAction // available on returnTo
+	matches  []keyAction // actions filtered by the query, best first
+	cursor   int
+	returnTo viewState
+}
+
+// activeKeys is the keymap of the current screen, with the bindings that
+// only apply in some situations switched off.
+func (m model) activeKeys() keyMap {
+	k := m.keys
+	k.currentState = m.state
+	k.Update.SetEnabled(m.updateAvailable && !m.isUpdating)
+	k.Portal.SetEnabled(m.portal != nil)
+	k.Undo.SetEnabled(m.undo != nil)
+	return k
+}
+
+func (m *model) openPalette() {
+	keys := m.activeKeys()
+	p := &paletteScreen{returnTo: m.state}
+	for _, a := range keyActions {
+		if a.availableIn(m.state) && (a.run != nil || a.field(&keys).Enabled()) {
+			p.actions = append(p.actions, a)
+		}
+	}
+	p.input = textinput.New()
+	p.input.Placeholder = "Type a command..."
+	p.input.Prompt = "> "
+	p.input.CharLimit = 64
+	p.input.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	p.input.Focus()
+	p.filter()
+	m.palette = p
+	m.state = viewPalette
+	m.clearStatus()
+}
+
+func (m *model) closePalette() {
+	if m.palette != nil {
+		m.state = m.palette.returnTo
+	}
+	m.palette = nil
+	m.resizeComponents()
+}
+
+type paletteTitles []keyAction
+
+func (t paletteTitles) String(i int) string { return t[i].title }
+func (t paletteTitles) Len() int            { return len(t) }
+
+func (p *paletteScreen) filter() {
+	query := strings.TrimSpace(p.input.Value())
+	p.cursor = 0
+	if query == "" {
+		p.matches = p.actions
+		return
+	}
+	p.matches = nil
+	for _, match := range fuzzy.FindFrom(query, paletteTitles(p.actions)) {
+		p.matches = append(p.matches, p.actions[match.Index])
+	}
+}
+
+func (m *model) handlePaletteKeys(msg tea.KeyMsg) []tea.Cmd {
+	p := m.palette
+	if p == nil {
+		return nil
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closePalette()
+		return nil
+	case tea.KeyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return nil
+	case tea.KeyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+		return nil
+	case tea.KeyEnter:
+		if len(p.matches) == 0 {
+			return nil
+		}
+		a := p.matches[p.cursor]
+		m.closePalette()
+		return m.runAction(a)
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	p.filter()
+	return []tea.Cmd{cmd}
+}
+
+// runAction performs an action on the current screen: actions with a run
+// function are called, the others are replayed as a press of their key.
+func (m *model) runAction(a keyAction) []tea.Cmd {
+	if a.run != nil {
+		return a.run(m)
+	}
+	msg, ok := bindingKeyMsg(*a.field(&m.keys))
+	if !ok {
+		return nil
+	}
+	return []tea.Cmd{func() tea.Msg { return msg }}
+}
+
+// keyRunAction finds the action with a run function bound to msg on the
+// current screen.
+func (m model) keyRunAction(msg tea.KeyMsg) (keyAction, bool) {
+	for _, a := range keyActions {
+		if a.run != nil && a.availableIn(m.state) && key.Matches(msg, *a.field(&m.keys)) {
+			return a, true
+		}
+	}
+	return keyAction{}, false
+}
+
+func (m model) paletteView(width, height int) string {
+	p := m.palette
+	if p == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{titleStyle.Render("Commands"), p.input.View(), ""}
+
+	// Keep the cursor visible when there are more matches than lines.
+	rows := height - 10
+	if rows < 3 {
+		rows = 3
+	}
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, label.Render("No matching command."))
+	}
+	for i := start; i < len(p.matches) && i < start+rows; i++ {
+		a := p.matches[i]
+		line := fmt.Sprintf("%-44s %s", truncateRunes(a.title, 44), label.Render(a.field(&m.keys).Help().Key))
+		if i == p.cursor {
+			line = listSelectedItemStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", label.Render("Type to search  ↑/↓: select  Enter: run  Esc: close"))
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeText(t *testing.T, m model, text string) model {
+	t.Helper()
+	for _, r := range text {
+		m, _ = press(t, m, runeKey(r))
+	}
+	return m
+}
+
+func TestPaletteListsAndRunsActions(t *testing.T) {
+	m := windowedModel(t)
+	m.isLoading = false
+	m, _ = press(t, m, runeKey(':'))
+	if m.state != viewPalette {
+		t.Fatalf("expected the palette, state %v", m.state)
+	}
+	v := m.View()
+	if !strings.Contains(v, "Toggle the Wi-Fi radio") || !strings.Contains(v, "Run network diagnostics") {
+		t.Fatalf("expected the main list actions:\n%s", v)
+	}
+	// Profile-only and unavailable actions are not offered here.
+	if strings.Contains(v, "Create a Wi-Fi profile") || strings.Contains(v, "Open the captive portal login") {
+		t.Fatalf("unexpected actions:\n%s", v)
+	}
+
+	m = typeText(t, m, "diag")
+	if len(m.palette.matches) == 0 || m.palette.matches[0].name != "diagnose" {
+		t.Fatalf("expected diagnostics first, got %v", m.palette.matches)
+	}
+	m, cmd := press(t, m, enterKey)
+	if m.state != viewNetworksList {
+		t.Fatalf("the palette should close before the action runs")
+	}
+	msg := cmd()
+	if k, ok := msg.(tea.KeyMsg); !ok || k.String() != "D" {
+		t.Fatalf("expected the diagnostics key, got %#v", msg)
+	}
+	updated, _ := m.Update(msg)
+	if m = updated.(model); m.state != viewDiagnostics {
+		t.Fatalf("expected diagnostics, state %v", m.state)
+	}
+
+	m.state = viewNetworksList
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = typeText(t, m, "zzzz")
+	if !strings.Contains(m.View(), "No matching command.") {
+		t.Fatalf("expected no matches:\n%s", m.View())
+	}
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.state != viewNetworksList || m.palette != nil {
+		t.Fatalf("Esc should close the palette")
+	}
+}
+
+func TestPaletteShareAndKeyBinding(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeAppConfig(t, "[keys]\nshare = \"s\"\n")
+	m := bulkTestModel(t)
+	m, _ = press(t, m, downKey, runeKey(':'))
+	m = typeText(t, m, "share")
+	m, cmd := press(t, m, enterKey)
+	done := cmd().(tea.BatchMsg)[0]().(bulkDoneMsg)
+	data, err := os.ReadFile(done.path)
+	if err != nil || string(data) != "# Home\nWIFI:T:WPA;S:Home;P:hunter22;;\n" {
+		t.Fatalf("unexpected share file: %v\n%s", err, data)
+	}
+
+	// The same action on its configured key, shown in the palette.
+	m.isLoading = false
+	m, cmd = press(t, m, runeKey('s'))
+	if cmd == nil || !strings.Contains(m.connectionStatusMsg, "Sharing 1 profile(s)") {
+		t.Fatalf("s should share, status %q", m.connectionStatusMsg)
+	}
+	m.isLoading = false
+	m, _ = press(t, m, runeKey(':'))
+	if !strings.Contains(m.View(), "Share networks as Wi-Fi QR strings") {
+		t.Fatalf("expected the share entry:\n%s", m.View())
+	}
+}
@@ -0,0 +1,257 @@
+// nmtui/cmd/password.go
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const pskMask = "••••••••"
+
+// lockedOutput is the TUI's output. The renderer writes each frame with a
+// single Write, so sharing its lock with the clipboard keeps an OSC 52
+// sequence from landing in the middle of a frame. Embedding the *os.File
+// keeps bubbletea's terminal size and raw mode handling working.
+type lockedOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *lockedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *lockedOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+var tuiOutput = &lockedOutput{File: os.Stdout}
+
+// clipboardOut is where OSC 52 sequences are written; the terminal reads
+// them from the program's output, also over SSH.
+var clipboardOut io.Writer = tuiOutput
+
+// savedPSK is the password of the profile shown in the details. It is
+// read only when it is revealed or copied, and forgotten when the details
+// are left.
+type savedPSK struct {
+	uuid     string
+	applies  bool // the profile uses a pre-shared key
+	value    string
+	loaded   bool
+	revealed bool
+}
+
+type pskLoadedMsg struct {
+	uuid string
+	psk  string
+	err  error
+	copy bool // copy it instead of revealing it
+}
+
+type clipboardMsg struct {
+	err     error
+	cleared bool
+}
+
+// clipboardClearMsg clears the clipboard unless something was copied
+// again since; gen tells the copies apart.
+type clipboardClearMsg struct{ gen int }
+
+func fetchSavedPSKCmd(uuid string, copy bool) tea.Cmd {
+	return func() tea.Msg {
+		psk, err := gonetworkmanager.SavedWifiPSK(uuid)
+		return pskLoadedMsg{uuid: uuid, psk: psk, err: err, copy: copy}
+	}
+}
+
+// osc52 is the escape sequence that sets the terminal's clipboard; inside
+// tmux it is passed through to the outer terminal.
+func osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+func writeClipboardCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := io.WriteString(clipboardOut, osc52(text))
+		return clipboardMsg{err: err, cleared: text == ""}
+	}
+}
+
+// profileDetailsContent is the profile details text with the password
+// line, masked unless it was revealed.
+func (m model) profileDetailsContent() string {
+	if !m.psk.applies {
+		return m.profileDetailsText
+	}
+	password := pskMask
+	if m.psk.revealed {
+		password = m.psk.value
+	}
+	hint := lipgloss.NewStyle().Foreground(ansFaintTextColor).Render(fmt.Sprintf("(%s: reveal, %s: copy)",
+		m.keys.RevealPassword.Help().Key, m.keys.CopyPassword.Help().Key))
+	return m.profileDetailsText + "\n" + fmt.Sprintf("Password: %s  %s", password, hint)
+}
+
+// usesWPAPSK reports whether the access point takes a WPA or WPA2
+// pre-shared key, the password ValidateWifiPSK checks. WEP keys and WPA3
+// (SAE) passwords follow other length rules and are left to NetworkManager.
+func (ap wifiAP) usesWPAPSK() bool {
+	security := strings.Fields(strings.ToUpper(ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSecurity]))
+	if slices.Contains(security, "802.1X") {
+		return false
+	}
+	return slices.Contains(security, "WPA1") || slices.Contains(security, "WPA2") || slices.Contains(security, "WPA")
+}
+
+// pskStrengthHint rates a password as it is typed: why it is not valid
+// yet, or a rough strength from its length and the kinds of characters in
+// it.
+func pskStrengthHint(psk string) string {
+	if psk == "" {
+		return ""
+	}
+	if err := gonetworkmanager.ValidateWifiPSK(psk); err != nil {
+		return strings.ToUpper(err.Error()[:1]) + err.Error()[1:]
+	}
+	if len(psk) == 64 {
+		return "Raw 256-bit key"
+	}
+	pool := 0
+	for _, class := range []string{"abcdefghijklmnopqrstuvwxyz", "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "0123456789"} {
+		if strings.ContainsAny(psk, class) {
+			pool += len(class)
+		}
+	}
+	if strings.Trim(psk, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		pool += 33 // space and punctuation
+	}
+	bits := int(float64(len(psk)) * math.Log2(float64(pool)))
+	rating := "strong"
+	switch {
+	case bits < 40:
+		rating = "weak"
+	case bits < 60:
+		rating = "fair"
+	case bits < 80:
+		rating = "good"
+	}
+	return fmt.Sprintf("Strength: %s (~%d bits)", rating, bits)
+}
+
+func (m *model) refreshProfileDetails() {
+	m.activeConnInfoViewport.SetContent(m.profileDetailsContent())
+}
+
+func (m *model) revealPassword() []tea.Cmd {
+	switch {
+	case !m.psk.applies:
+		m.setStatus("This profile stores no password.", toggleHiddenStatusMsgStyle)
+		return nil
+	case m.psk.revealed:
+		m.psk.revealed = false
+	case m.psk.loaded:
+		m.psk.revealed = true
+	default:
+		m.setStatus("Reading the saved password...", connectingStyle)
+		return []tea.Cmd{fetchSavedPSKCmd(m.psk.uuid, false)}
+	}
+	m.clearStatus()
+	m.refreshProfileDetails()
+	return nil
+}
+
+func (m *model) copyPassword() []tea.Cmd {
+	switch {
+	case !m.psk.applies:
+		m.setStatus("This profile stores no password.", toggleHiddenStatusMsgStyle)
+		return nil
+	case !m.psk.loaded:
+		m.setStatus("Reading the saved password...", connectingStyle)
+		return []tea.Cmd{fetchSavedPSKCmd(m.psk.uuid, true)}
+	}
+	return m.copyToClipboard(m.psk.value)
+}
+
+// copyToClipboard copies text with OSC 52 and schedules clearing it.
+func (m *model) copyToClipboard(text string) []tea.Cmd {
+	m.clipboardGen++
+	cmds := []tea.Cmd{writeClipboardCmd(text)}
+	status := "Password copied to the clipboard."
+	if m.clipboardClear > 0 {
+		gen := m.clipboardGen
+		cmds = append(cmds, tea.Tick(m.clipboardClear, func(time.Time) tea.Msg { return clipboardClearMsg{gen: gen} }))
+		status = fmt.Sprintf("Password copied to the clipboard; it is cleared in %s.", m.clipboardClear)
+	}
+	m.setStatus(status, successStyle)
+	return cmds
+}
+
+// quit clears the clipboard if a password was copied, since the pending
+// clipboardClearMsg never arrives once the program has exited.
+func (m *model) quit() tea.Cmd {
+	if m.clipboardGen != 0 {
+		if _, err := io.WriteString(clipboardOut, osc52("")); err != nil {
+			log.Printf("Clipboard: could not clear on quit: %v", err)
+		}
+	}
+	return tea.Quit
+}
+
+func (m *model) handlePSKLoaded(msg pskLoadedMsg) []tea.Cmd {
+	if msg.uuid != m.psk.uuid || m.state != viewProfileDetails {
+		return nil
+	}
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not read the password: %v", msg.err), errorStyle)
+		return nil
+	}
+	if msg.psk == "" {
+		m.setStatus("No password is stored in this profile (a secret agent may own it).", toggleHiddenStatusMsgStyle)
+		return nil
+	}
+	m.psk.value, m.psk.loaded = msg.psk, true
+	if msg.copy {
+		return m.copyToClipboard(msg.psk)
+	}
+	m.psk.revealed = true
+	m.clearStatus()
+	m.refreshProfileDetails()
+	return nil
+}
+
+func (m *model) handleClipboard(msg tea.Msg) []tea.Cmd {
+	switch msg := msg.(type) {
+	case clipboardClearMsg:
+		if msg.gen == m.clipboardGen {
+			return []tea.Cmd{writeClipboardCmd("")}
+		}
+	case clipboardMsg:
+		switch {
+		case msg.err != nil:
+			m.setStatus(fmt.Sprintf("Could not copy to the clipboard: %v", msg.err), errorStyle)
+		case msg.cleared && strings.Contains(m.connectionStatusMsg, "copied to the clipboard"):
+			m.setStatus("Clipboard cleared.", toggleHiddenStatusMsgStyle)
+		}
+	}
+	return nil
+}
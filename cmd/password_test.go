@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestRevealAndCopyPassword(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("TMUX", "")
+	installFakeCommand(t, "nmcli", `case "$*" in
+  "-s -g 802-11-wireless-security.psk connection show u-home") echo "$*" >> "$STATE/calls"; printf 'hunter\\:22\n' ;;
+  *) exit 4 ;;
+esac
+`)
+	var clip bytes.Buffer
+	oldOut := clipboardOut
+	clipboardOut = &clip
+	t.Cleanup(func() { clipboardOut = oldOut })
+
+	m := windowedModel(t)
+	m.isLoading = false
+	m.clipboardClear = time.Minute
+	m.state, m.profileDetailsID = viewProfileDetails, "u-home"
+	updated, _ := m.Update(profileLoadedMsg{profile: gonetworkmanager.ConnectionProfile{
+		gonetworkmanager.NmcliFieldConnectionName: "Home",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-home",
+		"802-11-wireless.ssid":                    "Home",
+		"802-11-wireless-security.key-mgmt":       "wpa-psk",
+	}})
+	m = updated.(model)
+	if v := m.View(); !strings.Contains(v, "Password: "+pskMask) || strings.Contains(v, "hunter") {
+		t.Fatalf("the password should be masked:\n%s", v)
+	}
+
+	m, cmd := press(t, m, runeKey('v'))
+	updated, _ = m.Update(cmd())
+	m = updated.(model)
+	if !strings.Contains(m.View(), "Password: hunter:22") {
+		t.Fatalf("v should reveal the password:\n%s", m.View())
+	}
+	m, _ = press(t, m, runeKey('v'))
+	if strings.Contains(m.View(), "hunter") {
+		t.Fatalf("a second v should mask it again")
+	}
+
+	// The password is read once and copied with OSC 52.
+	m, cmd = press(t, m, runeKey('c'))
+	cmds := cmd().(tea.BatchMsg)
+	updated, _ = m.Update(cmds[0]())
+	m = updated.(model)
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("hunter:22")) + "\a"
+	if clip.String() != want || !strings.Contains(m.connectionStatusMsg, "cleared in 1m0s") {
+		t.Fatalf("unexpected clipboard write %q, status %q", clip.String(), m.connectionStatusMsg)
+	}
+	if calls, _ := os.ReadFile(filepath.Join(state, "calls")); strings.Count(string(calls), "\n") != 1 {
+		t.Fatalf("the password should be read once, got:\n%s", calls)
+	}
+
+	// A clear timer from an older copy leaves the clipboard alone.
+	clip.Reset()
+	if _, cmd := m.Update(clipboardClearMsg{gen: m.clipboardGen - 1}); cmd != nil {
+		t.Fatalf("a stale clear should do nothing")
+	}
+	updated, cmd = m.Update(clipboardClearMsg{gen: m.clipboardGen})
+	updated, _ = updated.(model).Update(cmd())
+	m = updated.(model)
+	if clip.String() != "\x1b]52;c;\a" || !strings.Contains(m.connectionStatusMsg, "Clipboard cleared.") {
+		t.Fatalf("expected the clipboard to be cleared, got %q, status %q", clip.String(), m.connectionStatusMsg)
+	}
+
+	// Leaving the details forgets the password.
+	m, _ = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.psk.value != "" || m.psk.loaded {
+		t.Fatalf("the password should be forgotten: %+v", m.psk)
+	}
+}
+
+func TestQuitClearsCopiedPassword(t *testing.T) {
+	t.Setenv("TMUX", "")
+	var clip bytes.Buffer
+	oldOut := clipboardOut
+	clipboardOut = &clip
+	t.Cleanup(func() { clipboardOut = oldOut })
+
+	m := windowedModel(t)
+	m.isLoading = false
+	m.clipboardClear = time.Minute
+	if _, cmd := press(t, m, tea.KeyMsg{Type: tea.KeyCtrlC}); clip.Len() != 0 || cmd == nil {
+		t.Fatalf("nothing was copied, so quitting should leave the clipboard alone: %q", clip.String())
+	}
+
+	m.copyToClipboard("hunter22")
+	if _, cmd := press(t, m, tea.KeyMsg{Type: tea.KeyCtrlC}); cmd == nil {
+		t.Fatal("expected ctrl+c to quit")
+	}
+	if clip.String() != "\x1b]52;c;\a" {
+		t.Fatalf("quitting before the clear timer should clear the clipboard, got %q", clip.String())
+	}
+}
+
+func TestPasswordPromptValidation(t *testing.T) {
+	m := windowedModel(t)
+	m.isLoading = false
+	m.selectedAP = surveyAP("Home", "aa:01", "80", "6")
+	m.promptForPassword("")
+
+	m = typeText(t, m, "pässwort")
+	m, cmd := press(t, m, enterKey)
+	if m.state != viewPasswordInput || cmd != nil || !strings.Contains(m.View(), "printable ASCII") {
+		t.Fatalf("a non-ASCII password should be refused, state %v:\n%s", m.state, m.View())
+	}
+
+	// A 64-digit hex key fits and is accepted.
+	m.passwordInput.SetValue("")
+	m = typeText(t, m, strings.Repeat("0123456789abcdef", 4))
+	if !strings.Contains(m.View(), "Raw 256-bit key") {
+		t.Fatalf("expected the raw key hint:\n%s", m.View())
+	}
+	if m, _ = press(t, m, enterKey); m.state != viewConnecting {
+		t.Fatalf("a hex key should be accepted, state %v", m.state)
+	}
+
+	// WEP keys and WPA3 passwords follow other rules and are left to
+	// NetworkManager.
+	for _, security := range []string{"WEP", "WPA3"} {
+		m.selectedAP = surveyAP("Old", "aa:02", "60", "1")
+		m.selectedAP.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSecurity] = security
+		m.promptForPassword("")
+		m = typeText(t, m, "abcde")
+		if strings.Contains(m.View(), "too short") {
+			t.Fatalf("%s: no WPA-PSK hint expected:\n%s", security, m.View())
+		}
+		if m, _ = press(t, m, enterKey); m.state != viewConnecting {
+			t.Fatalf("%s: a 5-character key should be accepted, state %v", security, m.state)
+		}
+	}
+
+	for psk, want := range map[string]string{"abc": "Password is too short", "password": "Strength: weak", "sunshine42": "Strength: fair", "Sunshine42!": "Strength: good", "correct horse battery staple": "Strength: strong"} {
+		if got := pskStrengthHint(psk); !strings.HasPrefix(got, want) {
+			t.Errorf("pskStrengthHint(%q) = %q, want %q", psk, got, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Constants ---
+
+const (
+	connectivityFull      = "full"
+	connectivityPortal    = "portal"
+	portalRecheckInterval = 5 * time.Second
+	portalProbeTimeout    = 5 * time.Second
+)
+
+// portalProbeURL is fetched without following redirects to discover where a
+// captive portal sends unauthenticated clients. It is a var so tests can
+// point it at a local server.
+var portalProbeURL = "http://nmcheck.gnome.org/check_network_status.txt"
+
+// portalOpenCommand opens the portal login page in the user's browser.
+var portalOpenCommand = "xdg-open"
+
+// --- Types ---
+
+// portalState tracks a detected captive portal until connectivity is full.
+type portalState struct {
+	url         string
+	state       string
+	recheckWait bool // a recheck tick is outstanding
+	canOpen     bool // canOpenBrowser, worked out once when the portal is detected
+}
+
+type connectivityCheckMsg struct {
+	state     string
+	portalURL string
+	err       error
+}
+
+type portalRecheckMsg struct{}
+
+type portalOpenedMsg struct {
+	url string
+	err error
+}
+
+// --- Detection ---
+
+// probeConnectivity asks NetworkManager for a fresh connectivity check and,
+// when a portal is reported, resolves the login URL.
+func probeConnectivity() connectivityCheckMsg {
+	out, err := gonetworkmanager.GetNetworkConnectivityState(true)
+	if err != nil {
+		return connectivityCheckMsg{err: err}
+	}
+	msg := connectivityCheckMsg{state: strings.TrimSpace(out)}
+	if msg.state == connectivityPortal {
+		msg.portalURL = detectPortalURL(portalProbeURL, portalProbeTimeout)
+	}
+	return msg
+}
+
+func checkConnectivityCmd() tea.Cmd {
+	return func() tea.Msg {
+		msg := probeConnectivity()
+		if msg.err != nil {
+			log.Printf("Cmd: Connectivity check error: %v", msg.err)
+		} else {
+			log.Printf("Cmd: Connectivity state %q (portal URL %q)", msg.state, msg.portalURL)
+		}
+		return msg
+	}
+}
+
+// detectPortalURL requests probe without following redirects. A redirect
+// points at the portal; any other intercepted response means the portal is
+// served in place of the probe, so the probe URL itself opens the login page.
+func detectPortalURL(probe string, timeout time.Duration) string {
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(probe)
+	if err != nil {
+		log.Printf("Portal: probe %s failed: %v", probe, err)
+		return probe
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if loc, err := resp.Location(); err == nil {
+			return loc.String()
+		}
+	}
+	return probe
+}
+
+func portalRecheckCmd() tea.Cmd {
+	return tea.Tick(portalRecheckInterval, func(time.Time) tea.Msg { return portalRecheckMsg{} })
+}
+
+// canOpenBrowser reports whether a graphical session and the opener exist.
+func canOpenBrowser() bool {
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	_, err := exec.LookPath(portalOpenCommand)
+	return err == nil
+}
+
+func openPortal(url string) error {
+	cmd := exec.Command(portalOpenCommand, url)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", portalOpenCommand, err)
+	}
+	go cmd.Wait() // reap the opener; it usually hands off to the browser and exits
+	return nil
+}
+
+func openPortalCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		err := openPortal(url)
+		if err != nil {
+			log.Printf("Cmd: Error opening portal %s: %v", url, err)
+		}
+		return portalOpenedMsg{url: url, err: err}
+	}
+}
+
+// --- Model integration ---
+
+// handleConnectivityCheck starts, updates or clears the portal banner. While a
+// portal is pending it keeps re-checking until NetworkManager reports full.
+func (m *model) handleConnectivityCheck(msg connectivityCheckMsg) tea.Cmd {
+	if msg.err != nil {
+		if m.portal != nil {
+			m.portal.recheckWait = true
+			return portalRecheckCmd()
+		}
+		return nil
+	}
+	if msg.state == connectivityFull {
+		if m.portal == nil {
+			return nil
+		}
+		m.portal = nil
+		if m.state == viewNetworksList || m.state == viewConnectionResult {
+			m.connectionStatusMsg = successStyle.Render("Captive portal login complete; internet access is available.")
+		}
+		return m.relayoutCmd()
+	}
+	if m.portal == nil {
+		if msg.state != connectivityPortal {
+			// limited/none without a portal is not ours to chase here.
+			return nil
+		}
+		m.portal = &portalState{url: msg.portalURL, state: msg.state, recheckWait: true, canOpen: canOpenBrowser()}
+		return tea.Batch(m.relayoutCmd(), portalRecheckCmd())
+	}
+	m.portal.state = msg.state
+	if msg.portalURL != "" {
+		m.portal.url = msg.portalURL
+	}
+	m.portal.recheckWait = true
+	return portalRecheckCmd()
+}
+
+func (m *model) handlePortalRecheck() tea.Cmd {
+	if m.portal == nil || !m.portal.recheckWait {
+		return nil
+	}
+	m.portal.recheckWait = false
+	return checkConnectivityCmd()
+}
+
+// openPortalLogin opens the login page, or prints the URL when no browser
+// can be launched (e.g. over SSH).
+func (m *model) openPortalLogin() tea.Cmd {
+	if m.portal == nil || m.portal.url == "" {
+		return nil
+	}
+	if !m.portal.canOpen {
+		m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("No browser available here. Open %s on a device connected to this network.", m.portal.url))
+		return nil
+	}
+	return openPortalCmd(m.portal.url)
+}
+
+// relayoutCmd re-sends the window size so components account for header
+// height changes such as the portal banner appearing.
+func (m model) relayoutCmd() tea.Cmd {
+	w, h := m.width, m.height
+	if w == 0 || h == 0 {
+		return nil
+	}
+	return func() tea.Msg { return tea.WindowSizeMsg{Width: w, Height: h} }
+}
+
+func (m model) portalBannerView(width int) string {
+	if m.portal == nil {
+		return ""
+	}
+	text := "⚠ Captive portal: log in to reach the internet"
+	if m.portal.state != connectivityPortal {
+		text = fmt.Sprintf("⚠ Waiting for portal login (connectivity: %s)", m.portal.state)
+	}
+	if m.portal.url != "" {
+		text += " — " + m.portal.url
+	}
+	if m.portal.canOpen {
+		text += fmt.Sprintf(" (%s to open)", m.keys.Portal.Help().Key)
+	}
+	style := lipgloss.NewStyle().Foreground(ansErrorColor).Bold(true).MaxWidth(width)
+	return style.Render(truncateRunes(text, width))
+}
+
+// --- CLI ---
+
+// runPortalCLI is the entry point for the `portal` subcommand.
+func runPortalCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("portal", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	open := fs.Bool("open", false, "open the portal login page with "+portalOpenCommand)
+	wait := fs.Bool("wait", false, "re-check until connectivity is full")
+	interval := fs.Duration("interval", portalRecheckInterval, "re-check interval with --wait")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	lastState, opened := "", false
+	for {
+		msg := probeConnectivity()
+		if msg.err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", msg.err)
+			return 1
+		}
+		if msg.state != lastState {
+			fmt.Fprintf(stdout, "Connectivity: %s\n", msg.state)
+			lastState = msg.state
+			if msg.state == connectivityPortal {
+				fmt.Fprintf(stdout, "Captive portal detected. Log in at: %s\n", msg.portalURL)
+			}
+		}
+		if msg.state == connectivityPortal && *open && !opened {
+			opened = true
+			if err := openPortal(msg.portalURL); err != nil {
+				fmt.Fprintf(stderr, "Could not open browser: %v\n", err)
+			}
+		}
+		if msg.state == connectivityFull {
+			return 0
+		}
+		if !*wait {
+			return 1
+		}
+		time.Sleep(*interval)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDetectPortalURLFollowsRedirectTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://login.hotel.example/welcome?x=1", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	if got := detectPortalURL(srv.URL+"/probe", portalProbeTimeout); got != "http://login.hotel.example/welcome?x=1" {
+		t.Fatalf("expected portal redirect target, got %q", got)
+	}
+}
+
+func TestDetectPortalURLFallsBackToProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>Please log in</html>"))
+	}))
+	defer srv.Close()
+
+	probe := srv.URL + "/probe"
+	if got := detectPortalURL(probe, portalProbeTimeout); got != probe {
+		t.Fatalf("expected inline portal to fall back to the probe URL, got %q", got)
+	}
+}
+
+func TestPortalBannerLifecycle(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	m := windowedModel(t)
+	m.state = viewNetworksList
+	m.isLoading = false
+
+	updated, cmd := m.Update(connectivityCheckMsg{state: connectivityPortal, portalURL: "http://portal.example/login"})
+	m2 := updated.(model)
+	if m2.portal == nil || cmd == nil {
+		t.Fatalf("expected portal state and a recheck to be scheduled")
+	}
+	if view := m2.View(); !strings.Contains(view, "Captive portal") || !strings.Contains(view, "http://portal.example/login") {
+		t.Fatalf("expected portal banner with URL in header:\n%s", view)
+	}
+	// Whether a browser can be opened is worked out once, on detection.
+	t.Setenv("DISPLAY", ":0")
+	if strings.Contains(m2.View(), m2.keys.Portal.Help().Key+" to open") {
+		t.Fatalf("the browser check should not run again on every render")
+	}
+
+	// Without a graphical session the URL is printed instead of opened.
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m2 = updated.(model)
+	if !strings.Contains(m2.connectionStatusMsg, "http://portal.example/login") {
+		t.Fatalf("expected URL in status for headless users, got %q", m2.connectionStatusMsg)
+	}
+
+	// Only the outstanding tick triggers a recheck.
+	updated, cmd = m2.Update(portalRecheckMsg{})
+	m2 = updated.(model)
+	if cmd == nil {
+		t.Fatalf("expected recheck command")
+	}
+	if _, cmd = m2.Update(portalRecheckMsg{}); cmd != nil {
+		t.Fatalf("duplicate recheck tick should be ignored")
+	}
+
+	updated, _ = m2.Update(connectivityCheckMsg{state: "limited"})
+	m2 = updated.(model)
+	if m2.portal == nil || !m2.portal.recheckWait {
+		t.Fatalf("expected to keep re-checking until full")
+	}
+
+	updated, _ = m2.Update(connectivityCheckMsg{state: connectivityFull})
+	m2 = updated.(model)
+	if m2.portal != nil {
+		t.Fatalf("expected portal banner to clear on full connectivity")
+	}
+	if strings.Contains(m2.View(), "Captive portal:") {
+		t.Fatalf("banner still rendered after login")
+	}
+}
+
+func TestConnectivityCheckWithoutPortalIsIgnored(t *testing.T) {
+	m := windowedModel(t)
+	updated, cmd := m.Update(connectivityCheckMsg{state: "limited"})
+	if updated.(model).portal != nil || cmd != nil {
+		t.Fatalf("limited connectivity without a portal should not start rechecks")
+	}
+}
+
+func TestRunPortalCLI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://wifi.airport.example/", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+	old := portalProbeURL
+	portalProbeURL = srv.URL
+	t.Cleanup(func() { portalProbeURL = old })
+
+	installFakeCommand(t, "nmcli", `echo portal`)
+	var out, errOut bytes.Buffer
+	if code := runPortalCLI(nil, &out, &errOut); code != 1 {
+		t.Fatalf("expected exit 1 while behind a portal, got %d (%s)", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "Log in at: http://wifi.airport.example/") {
+		t.Fatalf("expected portal URL to be printed, got %q", out.String())
+	}
+
+	// --wait keeps polling until NetworkManager reports full.
+	state := t.TempDir() + "/count"
+	installFakeCommand(t, "nmcli", `
+n=$(cat "`+state+`" 2>/dev/null || echo 0)
+echo $((n+1)) > "`+state+`"
+if [ "$n" -lt 2 ]; then echo portal; else echo full; fi
+`)
+	out.Reset()
+	if code := runPortalCLI([]string{"--wait", "--interval", "1ms"}, &out, &errOut); code != 0 {
+		t.Fatalf("expected exit 0 once online, got %d", code)
+	}
+	if got := out.String(); strings.Count(got, "Connectivity:") != 2 || !strings.Contains(got, "Connectivity: full") {
+		t.Fatalf("expected one line per state change, got %q", got)
+	}
+}
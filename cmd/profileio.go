@@ -0,0 +1,370 @@
+// nmtui/cmd/profileio.go
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Keyfile export / import ---
+
+// keyfileName turns a profile name into a file name that is safe on any
+// filesystem while staying recognisable.
+func keyfileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r < 0x20:
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		name = "connection"
+	}
+	return name + gonetworkmanager.KeyfileExtension
+}
+
+// writeKeyfile writes kf into dir. Keyfiles may carry secrets and
+// NetworkManager refuses to load world-readable ones, so they are always 0600.
+// used tracks file names already written in this run to avoid overwriting a
+// same-named profile exported a moment earlier. Unless replace is set, an
+// existing file is left alone and its path returned with the error.
+func writeKeyfile(kf *gonetworkmanager.Keyfile, dir string, used map[string]bool, replace bool) (string, error) {
+	name := keyfileName(kf.ID())
+	if used[name] && kf.UUID() != "" {
+		name = strings.TrimSuffix(name, gonetworkmanager.KeyfileExtension) + "-" + shortUUID(kf.UUID()) + gonetworkmanager.KeyfileExtension
+	}
+	if used != nil {
+		used[name] = true
+	}
+	path := filepath.Join(dir, name)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if replace {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return path, err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return "", err
+	}
+	if _, err := f.Write(kf.Marshal()); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+func shortUUID(uuid string) string {
+	if len(uuid) > 8 {
+		return uuid[:8]
+	}
+	return uuid
+}
+
+// exportProfileKeyfile fetches one profile and writes it into dir.
+func exportProfileKeyfile(profileID, dir string, withSecrets bool, used map[string]bool, replace bool) (string, error) {
+	kf, err := gonetworkmanager.ExportKeyfile(profileID, withSecrets)
+	if err != nil {
+		return "", err
+	}
+	path, err := writeKeyfile(kf, dir, used, replace)
+	if err != nil {
+		return path, err
+	}
+	log.Printf("Profile export: wrote %s (secrets: %v)", path, withSecrets)
+	return path, nil
+}
+
+// importKeyfilePath parses and imports a single .nmconnection file.
+func importKeyfilePath(path string, replace bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	kf, err := gonetworkmanager.ParseKeyfile(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if _, err := gonetworkmanager.ImportKeyfile(kf, replace); err != nil {
+		return kf.ID(), err
+	}
+	log.Printf("Profile import: created %q from %s", kf.ID(), path)
+	return kf.ID(), nil
+}
+
+// exportableProfiles lists the profiles `--all` exports: the same Wi-Fi,
+// ethernet and VPN types the declarative config manages.
+func exportableProfiles() ([]gonetworkmanager.ConnectionProfile, error) {
+	all, err := gonetworkmanager.GetConnectionProfilesList(false)
+	if err != nil {
+		return nil, err
+	}
+	var out []gonetworkmanager.ConnectionProfile
+	for _, p := range all {
+		switch canonicalConnType(p[gonetworkmanager.NmcliFieldConnectionType]) {
+		case netTypeWifi, netTypeEthernet, netTypeVPN:
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// --- CLI ---
+
+func runProfileCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	usage := func() {
+		fmt.Fprintf(stderr, "Usage: %s profile export [--secrets] [--dir DIR] [--all] [PROFILE...]\n", effectiveAppName())
+		fmt.Fprintf(stderr, "       %s profile import [--replace] FILE...\n", effectiveAppName())
+		fmt.Fprintf(stderr, "       %s profile prune [--older-than 90d] [--never-used] [--duplicates] [--dry-run] [--yes]\n", effectiveAppName())
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "export":
+		return runProfileExportCLI(args[1:], stdout, stderr)
+	case "import":
+		return runProfileImportCLI(args[1:], stdout, stderr)
+	case "prune":
+		return runProfilePruneCLI(args[1:], stdin, stdout, stderr)
+	case "-h", "--help":
+		usage()
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown profile command: %s\n", args[0])
+		usage()
+		return 2
+	}
+}
+
+func runProfileExportCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("profile export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	withSecrets := fs.Bool("secrets", false, "include passwords and keys (needs permission to read them)")
+	dir := fs.String("dir", ".", "directory to write .nmconnection files into")
+	all := fs.Bool("all", false, "export every Wi-Fi, ethernet and VPN profile")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ids := fs.Args()
+	if *all {
+		profiles, err := exportableProfiles()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error listing profiles: %v\n", err)
+			return 1
+		}
+		for _, p := range profiles {
+			ids = append(ids, p[gonetworkmanager.NmcliFieldConnectionUUID])
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(stderr, "Nothing to export: name one or more profiles or pass --all.")
+		return 2
+	}
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	used := make(map[string]bool)
+	for _, id := range ids {
+		path, err := exportProfileKeyfile(id, *dir, *withSecrets, used, true)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "  ✗ %s: %v\n", id, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "  ✓ %s -> %s\n", id, path)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "Export finished with %d error(s).\n", failed)
+		return 1
+	}
+	if !*withSecrets {
+		fmt.Fprintln(stdout, "Secrets were not included; use --secrets to export passwords.")
+	}
+	return 0
+}
+
+func runProfileImportCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("profile import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	replace := fs.Bool("replace", false, "replace an existing profile with the same UUID")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintf(stderr, "Usage: %s profile import [--replace] FILE...\n", effectiveAppName())
+		return 2
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		name, err := importKeyfilePath(path, *replace)
+		if err != nil {
+			failed++
+			if errors.Is(err, gonetworkmanager.ErrProfileExists) {
+				err = fmt.Errorf("%w (use --replace to overwrite)", err)
+			}
+			fmt.Fprintf(stdout, "  ✗ %s: %v\n", path, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "  ✓ imported %s\n", name)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "Import finished with %d error(s).\n", failed)
+		return 1
+	}
+	return 0
+}
+
+// --- TUI ---
+
+// The TUI exports into the working directory, which may hold files the user
+// did not have in mind, so it never overwrites one.
+
+type profileExportedMsg struct {
+	path string
+	err  error
+}
+
+type profileImportedMsg struct {
+	name string
+	err  error
+}
+
+func exportProfileCmd(profileID string, withSecrets bool) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			dir = "."
+		}
+		path, err := exportProfileKeyfile(profileID, dir, withSecrets, nil, false)
+		return profileExportedMsg{path: path, err: err}
+	}
+}
+
+func importProfileCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		name, err := importKeyfilePath(path, false)
+		return profileImportedMsg{name: name, err: err}
+	}
+}
+
+func newImportPathInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "path/to/profile.nmconnection"
+	ti.CharLimit = 256
+	ti.Prompt = passwordPromptStyle.Render("📄 Import: ")
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	return ti
+}
+
+// handleImportPathKeys drives the path prompt shown under the profile list.
+func (m *model) handleImportPathKeys(msg tea.KeyMsg) []tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.importPathInput.Blur()
+		m.resizeComponents()
+		return nil
+	case tea.KeyEnter:
+		path := strings.TrimSpace(m.importPathInput.Value())
+		if path == "" {
+			return nil
+		}
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		m.importPathInput.Blur()
+		m.resizeComponents()
+		m.isLoading = true
+		m.setStatus(fmt.Sprintf("Importing %s...", filepath.Base(path)), connectingStyle)
+		return []tea.Cmd{importProfileCmd(path), m.spinner.Tick}
+	}
+	var cmd tea.Cmd
+	m.importPathInput, cmd = m.importPathInput.Update(msg)
+	return []tea.Cmd{cmd}
+}
+
+// handleProfileIOKeys handles export and import in the profiles view.
+func (m *model) handleProfileIOKeys(msg tea.KeyMsg) []tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Export), key.Matches(msg, m.keys.ExportSecrets):
+		i, ok := m.knownWifiList.SelectedItem().(wifiAP)
+		profileID := ""
+		if ok {
+			profileID = i.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID]
+		}
+		if profileID == "" {
+			m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render("No profile selected.")
+			return nil
+		}
+		withSecrets := key.Matches(msg, m.keys.ExportSecrets)
+		m.setStatus(fmt.Sprintf("Exporting %s...", i.getSSIDFromScannedAP()), connectingStyle)
+		return []tea.Cmd{exportProfileCmd(profileID, withSecrets)}
+	case key.Matches(msg, m.keys.Import):
+		m.importPathInput.SetValue("")
+		m.importPathInput.Focus()
+		m.clearStatus()
+		m.resizeComponents()
+		return []tea.Cmd{textinput.Blink}
+	}
+	return nil
+}
+
+func (m *model) handleProfileExported(msg profileExportedMsg) {
+	if errors.Is(msg.err, os.ErrExist) {
+		m.setStatus(fmt.Sprintf("Not exported: %s already exists. Move it away and export again.", msg.path), errorStyle)
+		return
+	}
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Export failed: %v", msg.err), errorStyle)
+		return
+	}
+	m.setStatus(fmt.Sprintf("Exported %s", msg.path), successStyle)
+}
+
+func (m *model) handleProfileImported(msg profileImportedMsg) []tea.Cmd {
+	m.isLoading = false
+	if msg.err != nil {
+		if errors.Is(msg.err, gonetworkmanager.ErrProfileExists) {
+			m.setStatus(fmt.Sprintf("%s already exists; use `%s profile import --replace` to overwrite.", msg.name, effectiveAppName()), errorStyle)
+		} else {
+			m.setStatus(fmt.Sprintf("Import failed: %v", msg.err), errorStyle)
+		}
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Imported profile %s", msg.name), successStyle)
+	m.isLoading = true
+	m.knownWifiList.Title = "Loading Profiles..."
+	return []tea.Cmd{fetchKnownWifiApsCmd(), m.spinner.Tick}
+}
+
+func (m model) importPathView() string {
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(ansBorderColor).
+		Padding(0, 1).
+		Render(m.importPathInput.View())
+}
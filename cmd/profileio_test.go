@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+const profileIOFakeNmcli = `
+case "$*" in
+  "-m multiline connection show u-home"|"-s -m multiline connection show u-home")
+    printf 'connection.id: Home/Net\nconnection.uuid: u-home\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: HomeNet\n802-11-wireless-security.key-mgmt: wpa-psk\n'
+    case "$1" in -s) echo '802-11-wireless-security.psk: hunter22' ;; *) echo '802-11-wireless-security.psk: <hidden>' ;; esac ;;
+  "-m multiline connection show u-taken") printf 'connection.id: Taken\nconnection.uuid: u-taken\n' ;;
+  "-m multiline connection show "*) echo "Error: unknown connection" >&2; exit 10 ;;
+  "connection add"*) echo "Connection successfully added." ;;
+  *) exit 9 ;;
+esac
+`
+
+func TestKeyfileName(t *testing.T) {
+	cases := map[string]string{"Home/Net": "Home_Net.nmconnection", "..hidden": "hidden.nmconnection", "  ": "connection.nmconnection", "Café": "Café.nmconnection"}
+	for in, want := range cases {
+		if got := keyfileName(in); got != want {
+			t.Errorf("keyfileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRunProfileExportCLI(t *testing.T) {
+	installFakeCommand(t, "nmcli", profileIOFakeNmcli)
+	dir := filepath.Join(t.TempDir(), "out")
+
+	var out, errOut bytes.Buffer
+	if code := runProfileCLI([]string{"export", "--dir", dir, "u-home"}, nil, &out, &errOut); code != 0 {
+		t.Fatalf("export exit %d: %s%s", code, out.String(), errOut.String())
+	}
+	path := filepath.Join(dir, "Home_Net.nmconnection")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected %s: %v", path, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("keyfile mode = %v, want 0600", info.Mode().Perm())
+	}
+	if strings.Contains(string(data), "psk=") || !strings.Contains(string(data), "[wifi]\nssid=HomeNet\n") {
+		t.Fatalf("unexpected keyfile without secrets:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "--secrets") {
+		t.Fatalf("expected a hint about --secrets, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if code := runProfileCLI([]string{"export", "--secrets", "--dir", dir, "u-home", "u-missing"}, nil, &out, &errOut); code != 1 {
+		t.Fatalf("expected exit 1 for the missing profile, got %d", code)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "psk=hunter22") {
+		t.Fatalf("--secrets should include the psk:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "✗ u-missing") {
+		t.Fatalf("expected per-profile failure, got:\n%s", out.String())
+	}
+
+	if code := runProfileCLI([]string{"export"}, nil, &out, &errOut); code != 2 {
+		t.Fatalf("export without profiles should be a usage error, got %d", code)
+	}
+}
+
+func TestRunProfileImportCLI(t *testing.T) {
+	installFakeCommand(t, "nmcli", profileIOFakeNmcli)
+	dir := t.TempDir()
+	fresh := filepath.Join(dir, "fresh.nmconnection")
+	taken := filepath.Join(dir, "taken.nmconnection")
+	os.WriteFile(fresh, []byte("[connection]\nid=Fresh\nuuid=u-fresh\ntype=ethernet\n"), 0600)
+	os.WriteFile(taken, []byte("[connection]\nid=Taken\nuuid=u-taken\ntype=ethernet\n"), 0600)
+
+	var out, errOut bytes.Buffer
+	if code := runProfileCLI([]string{"import", fresh, taken}, nil, &out, &errOut); code != 1 {
+		t.Fatalf("expected exit 1 because Taken exists, got %d", code)
+	}
+	if !strings.Contains(out.String(), "✓ imported Fresh") || !strings.Contains(out.String(), "use --replace") {
+		t.Fatalf("unexpected import output:\n%s", out.String())
+	}
+	if code := runProfileCLI([]string{"bogus"}, nil, &out, &errOut); code != 2 {
+		t.Fatalf("unknown subcommand should exit 2, got %d", code)
+	}
+}
+
+func TestProfilesViewExportAndImport(t *testing.T) {
+	installFakeCommand(t, "nmcli", profileIOFakeNmcli)
+	t.Chdir(t.TempDir())
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	m.knownWifiList.SetItems([]list.Item{wifiAP{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{
+		gonetworkmanager.NmcliFieldWifiSSID:       "HomeNet",
+		gonetworkmanager.NmcliFieldConnectionName: "Home/Net",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-home",
+		gonetworkmanager.NmcliFieldWifiSecurity:   "--",
+		gonetworkmanager.NmcliFieldWifiSignal:     "0",
+	}, IsKnown: true}})
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	m2 := updated.(model)
+	if cmd == nil {
+		t.Fatalf("expected X to start an export")
+	}
+	updated, _ = m2.Update(cmd())
+	m2 = updated.(model)
+	wd, _ := os.Getwd()
+	full := filepath.Join(wd, "Home_Net.nmconnection")
+	if !strings.Contains(m2.connectionStatusMsg, "Exported "+full) {
+		t.Fatalf("expected export confirmation with the full path, got %q", m2.connectionStatusMsg)
+	}
+	if data, err := os.ReadFile("Home_Net.nmconnection"); err != nil || !strings.Contains(string(data), "psk=hunter22") {
+		t.Fatalf("expected keyfile with secrets in the working directory: %v\n%s", err, data)
+	}
+
+	// A second export leaves the existing file alone.
+	if err := os.WriteFile("Home_Net.nmconnection", []byte("mine"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	m2 = updated.(model)
+	updated, _ = m2.Update(cmd())
+	m2 = updated.(model)
+	if !strings.Contains(m2.connectionStatusMsg, full+" already exists") {
+		t.Fatalf("expected a refusal to overwrite, got %q", m2.connectionStatusMsg)
+	}
+	if data, _ := os.ReadFile("Home_Net.nmconnection"); string(data) != "mine" {
+		t.Fatalf("the existing file was overwritten: %q", data)
+	}
+
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'I'}})
+	m2 = updated.(model)
+	if !m2.importPathInput.Focused() || !m2.isTextInputActive() {
+		t.Fatalf("expected I to open the import prompt")
+	}
+	if !strings.Contains(m2.View(), "Import:") {
+		t.Fatalf("expected the import prompt in the view")
+	}
+	// q is text while the prompt is open.
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	m2 = updated.(model)
+	if m2.importPathInput.Value() != "q" {
+		t.Fatalf("expected q to be typed into the prompt, got %q", m2.importPathInput.Value())
+	}
+	m2.importPathInput.SetValue("Home_Net.nmconnection")
+	updated, cmd = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if m2.importPathInput.Focused() || cmd == nil {
+		t.Fatalf("expected Enter to start the import")
+	}
+	updated, _ = m2.Update(profileImportedMsg{name: "Home/Net", err: gonetworkmanager.ErrProfileExists})
+	m2 = updated.(model)
+	if !strings.Contains(m2.connectionStatusMsg, "--replace") {
+		t.Fatalf("expected a hint about replacing, got %q", m2.connectionStatusMsg)
+	}
+	updated, cmd = m2.Update(profileImportedMsg{name: "Home/Net"})
+	m2 = updated.(model)
+	if cmd == nil || !strings.Contains(m2.connectionStatusMsg, "Imported profile Home/Net") {
+		t.Fatalf("expected success and a profile refresh, got %q", m2.connectionStatusMsg)
+	}
+}
@@ -0,0 +1,812 @@
+// nmtui/cmd/schedule.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	scheduleFileName     = "schedule.json"
+	scheduleTickInterval = 15 * time.Second
+	defaultConnectFor    = 30 * time.Minute
+)
+
+// connectTimer disconnects a profile once Until has passed ("connect for N
+// minutes").
+type connectTimer struct {
+	UUID  string    `json:"uuid"`
+	Name  string    `json:"name"`
+	Until time.Time `json:"until"`
+}
+
+// scheduleRule keeps a profile's autoconnect on only inside a daily time
+// window, e.g. 09:00-18:00 on weekdays. Windows may cross midnight.
+type scheduleRule struct {
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	Window     string `json:"window"`         // "HH:MM-HH:MM"
+	Days       string `json:"days,omitempty"` // "mon-fri", "sat,sun"; empty = every day
+	Disconnect bool   `json:"disconnect,omitempty"`
+	// InWindow is the result of the previous evaluation, so the disconnect
+	// happens once when the window ends rather than every time the profile
+	// is used outside it.
+	InWindow *bool `json:"inWindow,omitempty"`
+}
+
+type scheduleState struct {
+	Timers []connectTimer `json:"timers,omitempty"`
+	Rules  []scheduleRule `json:"rules,omitempty"`
+}
+
+// --- Rules ---
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseWindow(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid window %q (use HH:MM-HH:MM)", s)
+	}
+	if start, err = parseClock(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseClock(to); err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("window %q is empty", s)
+	}
+	return start, end, nil
+}
+
+func parseWeekday(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 3 {
+		if i := slices.Index(weekdayNames, s[:3]); i >= 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", s)
+}
+
+// parseDays accepts "", "daily", "weekdays", "weekends" or a comma-separated
+// list of days and ranges such as "mon-fri" or "fri-sun".
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "daily", "all":
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	case "weekdays":
+		s = "mon-fri"
+	case "weekends":
+		s = "sat,sun"
+	}
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseWeekday(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseWeekday(to); err != nil {
+				return days, err
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func newScheduleRule(uuid, name, window, days string, disconnect bool) (scheduleRule, error) {
+	if _, _, err := parseWindow(window); err != nil {
+		return scheduleRule{}, err
+	}
+	if _, err := parseDays(days); err != nil {
+		return scheduleRule{}, err
+	}
+	return scheduleRule{UUID: uuid, Name: name, Window: strings.TrimSpace(window), Days: strings.TrimSpace(days), Disconnect: disconnect}, nil
+}
+
+// contains reports whether t falls inside the rule's window. For a window
+// that crosses midnight the day is the one it started on.
+func (r scheduleRule) contains(t time.Time) bool {
+	start, end, err := parseWindow(r.Window)
+	if err != nil {
+		return true
+	}
+	days, err := parseDays(r.Days)
+	if err != nil {
+		return true
+	}
+	now, today := t.Hour()*60+t.Minute(), int(t.Weekday())
+	if start < end {
+		return days[today] && now >= start && now < end
+	}
+	if now >= start {
+		return days[today]
+	}
+	return now < end && days[(today+6)%7]
+}
+
+func (r scheduleRule) describe() string {
+	text := "autoconnect only " + r.Window
+	if r.Days != "" {
+		text += " " + r.Days
+	}
+	if r.Disconnect {
+		text += ", disconnect when it ends"
+	}
+	return text
+}
+
+// --- Planning ---
+
+type scheduleActionKind int
+
+const (
+	scheduleDisconnect scheduleActionKind = iota
+	scheduleAutoconnectOn
+	scheduleAutoconnectOff
+)
+
+type scheduleAction struct {
+	Kind   scheduleActionKind
+	UUID   string
+	Name   string
+	Reason string
+}
+
+func (a scheduleAction) event(err error) string {
+	text := map[scheduleActionKind]string{
+		scheduleDisconnect:     "disconnected " + a.Name,
+		scheduleAutoconnectOn:  "autoconnect on for " + a.Name,
+		scheduleAutoconnectOff: "autoconnect off for " + a.Name,
+	}[a.Kind]
+	text += " (" + a.Reason + ")"
+	if err != nil {
+		text += " failed: " + err.Error()
+	}
+	return text
+}
+
+// plan works out what the timers and rules require at now. It also updates
+// the bookkeeping: timers of deleted profiles, and expired timers of
+// profiles no longer active, are dropped, and each rule remembers whether
+// it was inside its window. An expired timer whose profile is still up is
+// kept until runSchedule has disconnected it, so a failed disconnect is
+// retried on the next run.
+func (s *scheduleState) plan(profiles []gonetworkmanager.KnownWifiProfile, now time.Time) []scheduleAction {
+	byUUID := make(map[string]gonetworkmanager.KnownWifiProfile, len(profiles))
+	for _, p := range profiles {
+		byUUID[p.UUID] = p
+	}
+	var actions []scheduleAction
+	disconnecting := make(map[string]bool)
+
+	timers := s.Timers[:0]
+	for _, t := range s.Timers {
+		p, ok := byUUID[t.UUID]
+		switch {
+		case !ok:
+		case now.Before(t.Until):
+			timers = append(timers, t)
+		case p.Device != "":
+			timers = append(timers, t)
+			actions = append(actions, scheduleAction{Kind: scheduleDisconnect, UUID: t.UUID, Name: p.Name, Reason: "time limit reached"})
+			disconnecting[t.UUID] = true
+		}
+	}
+	s.Timers = timers
+
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		p, ok := byUUID[r.UUID]
+		if !ok {
+			continue
+		}
+		in := r.contains(now)
+		wasIn := r.InWindow != nil && *r.InWindow
+		r.InWindow = &in
+		reason := "outside " + r.Window
+		if in {
+			reason = "inside " + r.Window
+		}
+		if p.Autoconnect != in {
+			kind := scheduleAutoconnectOff
+			if in {
+				kind = scheduleAutoconnectOn
+			}
+			actions = append(actions, scheduleAction{Kind: kind, UUID: r.UUID, Name: p.Name, Reason: reason})
+		}
+		if r.Disconnect && wasIn && !in && p.Device != "" && !disconnecting[r.UUID] {
+			actions = append(actions, scheduleAction{Kind: scheduleDisconnect, UUID: r.UUID, Name: p.Name, Reason: r.Window + " ended"})
+			disconnecting[r.UUID] = true
+		}
+	}
+	return actions
+}
+
+// --- Persistence ---
+
+// scheduleMu serialises read-modify-write of the schedule file.
+var scheduleMu sync.Mutex
+
+func schedulePath() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scheduleFileName), nil
+}
+
+func loadSchedule() (*scheduleState, error) {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+	return loadScheduleLocked()
+}
+
+func loadScheduleLocked() (*scheduleState, error) {
+	path, err := schedulePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &scheduleState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &scheduleState{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func saveScheduleLocked(s *scheduleState) error {
+	path, err := schedulePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// updateSchedule applies fn to the stored schedule and writes it back.
+// As with usage data, an unreadable schedule is an error: silently dropping
+// the user's rules would be worse than refusing to change them.
+func updateSchedule(fn func(*scheduleState) error) error {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+	s, err := loadScheduleLocked()
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		return err
+	}
+	return saveScheduleLocked(s)
+}
+
+type scheduleResult struct {
+	Events       []string
+	Disconnected []string // UUIDs
+	Timers       []connectTimer
+}
+
+// runSchedule applies whatever the timers and rules require right now. Both
+// the TUI and `watch` call it on every tick; it is a no-op without entries.
+func runSchedule(now time.Time) (scheduleResult, error) {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+	var res scheduleResult
+	s, err := loadScheduleLocked()
+	if err != nil {
+		return res, err
+	}
+	res.Timers = s.Timers
+	if len(s.Timers) == 0 && len(s.Rules) == 0 {
+		return res, nil
+	}
+	profiles, err := gonetworkmanager.GetKnownWifiProfiles()
+	if err != nil {
+		return res, err
+	}
+	for _, a := range s.plan(profiles, now) {
+		var err error
+		switch a.Kind {
+		case scheduleDisconnect:
+			if _, err = gonetworkmanager.ConnectionDown(a.UUID); err == nil {
+				res.Disconnected = append(res.Disconnected, a.UUID)
+				s.Timers = slices.DeleteFunc(s.Timers, func(t connectTimer) bool { return t.UUID == a.UUID && !now.Before(t.Until) })
+			}
+		case scheduleAutoconnectOn, scheduleAutoconnectOff:
+			value := map[bool]string{true: "yes", false: "no"}[a.Kind == scheduleAutoconnectOn]
+			_, err = gonetworkmanager.ModifyConnectionUnrecorded(a.UUID, []gonetworkmanager.ConnectionSetting{{Key: nmPropAutoconnect, Value: value}})
+		}
+		event := a.event(err)
+		log.Printf("Schedule: %s", event)
+		res.Events = append(res.Events, event)
+	}
+	res.Timers = s.Timers
+	return res, saveScheduleLocked(s)
+}
+
+// connectFor activates a profile and records when to disconnect it. The timer
+// is stored first so that a crash right after connecting cannot leave the
+// profile up indefinitely.
+func connectFor(uuid, name string, d time.Duration, now time.Time) (connectTimer, error) {
+	timer := connectTimer{UUID: uuid, Name: name, Until: now.Add(d)}
+	err := updateSchedule(func(s *scheduleState) error {
+		s.Timers = slices.DeleteFunc(s.Timers, func(t connectTimer) bool { return t.UUID == uuid })
+		s.Timers = append(s.Timers, timer)
+		return nil
+	})
+	if err != nil {
+		return timer, err
+	}
+	if _, err := gonetworkmanager.ConnectionUp(uuid); err != nil {
+		if _, cerr := cancelConnectFor(uuid); cerr != nil {
+			log.Printf("Schedule: could not drop the timer of %s: %v", name, cerr)
+		}
+		return timer, err
+	}
+	return timer, nil
+}
+
+// cancelConnectFor drops the timer of a profile (by UUID or name) and
+// returns it; the profile stays connected.
+func cancelConnectFor(profile string) (*connectTimer, error) {
+	var cancelled *connectTimer
+	err := updateSchedule(func(s *scheduleState) error {
+		for i, t := range s.Timers {
+			if t.UUID == profile || t.Name == profile {
+				cancelled = &t
+				s.Timers = slices.Delete(s.Timers, i, i+1)
+				return nil
+			}
+		}
+		return nil
+	})
+	return cancelled, err
+}
+
+// parseConnectDuration accepts a Go duration ("45m", "1h30m") or a plain
+// number of minutes.
+func parseConnectDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	if n, nerr := strconv.Atoi(s); nerr == nil {
+		d, err = time.Duration(n)*time.Minute, nil
+	}
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("duration must be at least one minute (e.g. 30 or 1h30m)")
+	}
+	return d, nil
+}
+
+func formatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// --- CLI ---
+
+func runScheduleCLI(args []string, stdout, stderr io.Writer) int {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("schedule "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	days := fs.String("days", "", "days the window applies to (mon-fri, sat,sun; default every day)")
+	disconnect := fs.Bool("disconnect", false, "also disconnect the profile when the window ends")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	fail := func(err error) int {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	lookup := func(ref string) (uuid, name string, err error) {
+		p, err := gonetworkmanager.GetConnectionProfileByID(ref)
+		if err == nil && p == nil {
+			err = fmt.Errorf("profile %s not found", ref)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		// runSchedule only tracks Wi-Fi profiles, so a timer or rule on
+		// anything else would be dropped or skipped without a word.
+		uuid, name = profileIdentity(p)
+		if t := canonicalConnType(p["connection.type"]); t != netTypeWifi {
+			return "", "", fmt.Errorf("%s is a %s profile; only Wi-Fi profiles can be scheduled", name, t)
+		}
+		return uuid, name, nil
+	}
+
+	switch sub {
+	case "list":
+		s, err := loadSchedule()
+		if err != nil {
+			return fail(err)
+		}
+		printSchedule(stdout, s, time.Now())
+		return 0
+	case "connect":
+		if fs.NArg() != 2 {
+			fmt.Fprintf(stderr, "Usage: %s schedule connect PROFILE MINUTES|DURATION\n", effectiveAppName())
+			return 2
+		}
+		d, err := parseConnectDuration(fs.Arg(1))
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		uuid, name, err := lookup(fs.Arg(0))
+		if err != nil {
+			return fail(err)
+		}
+		timer, err := connectFor(uuid, name, d, time.Now())
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(stdout, "Connected to %s until %s.\n", name, timer.Until.Format("15:04"))
+		fmt.Fprintf(stdout, "It is disconnected by a running %s or `%s watch`, or by `%s schedule run` from cron.\n", effectiveAppName(), effectiveAppName(), effectiveAppName())
+		return 0
+	case "cancel":
+		if fs.NArg() != 1 {
+			fmt.Fprintf(stderr, "Usage: %s schedule cancel PROFILE\n", effectiveAppName())
+			return 2
+		}
+		t, err := cancelConnectFor(fs.Arg(0))
+		if err != nil {
+			return fail(err)
+		}
+		if t == nil {
+			return fail(fmt.Errorf("no time limit set for %s", fs.Arg(0)))
+		}
+		fmt.Fprintf(stdout, "Cancelled the time limit of %s; it stays connected.\n", t.Name)
+		return 0
+	case "add":
+		if fs.NArg() != 2 {
+			fmt.Fprintf(stderr, "Usage: %s schedule add [--days mon-fri] [--disconnect] PROFILE HH:MM-HH:MM\n", effectiveAppName())
+			return 2
+		}
+		uuid, name, err := lookup(fs.Arg(0))
+		if err != nil {
+			return fail(err)
+		}
+		rule, err := newScheduleRule(uuid, name, fs.Arg(1), *days, *disconnect)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		err = updateSchedule(func(s *scheduleState) error {
+			s.Rules = slices.DeleteFunc(s.Rules, func(r scheduleRule) bool { return r.UUID == uuid })
+			s.Rules = append(s.Rules, rule)
+			return nil
+		})
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(stdout, "%s: %s.\n", name, rule.describe())
+		return 0
+	case "remove":
+		if fs.NArg() != 1 {
+			fmt.Fprintf(stderr, "Usage: %s schedule remove PROFILE\n", effectiveAppName())
+			return 2
+		}
+		removed := false
+		err := updateSchedule(func(s *scheduleState) error {
+			n := len(s.Rules)
+			s.Rules = slices.DeleteFunc(s.Rules, func(r scheduleRule) bool { return r.UUID == fs.Arg(0) || r.Name == fs.Arg(0) })
+			removed = len(s.Rules) < n
+			return nil
+		})
+		if err != nil {
+			return fail(err)
+		}
+		if !removed {
+			return fail(fmt.Errorf("no rule for %s", fs.Arg(0)))
+		}
+		fmt.Fprintf(stdout, "Removed the rule for %s; its autoconnect setting is left as it is.\n", fs.Arg(0))
+		return 0
+	case "run":
+		res, err := runSchedule(time.Now())
+		if err != nil {
+			return fail(err)
+		}
+		failed := false
+		for _, e := range res.Events {
+			mark := "✓"
+			if strings.Contains(e, " failed: ") {
+				mark, failed = "✗", true
+			}
+			fmt.Fprintf(stdout, "  %s %s\n", mark, e)
+		}
+		if failed {
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown schedule command: %s\n", sub)
+		return 2
+	}
+}
+
+func printSchedule(w io.Writer, s *scheduleState, now time.Time) {
+	if len(s.Timers) == 0 && len(s.Rules) == 0 {
+		fmt.Fprintln(w, "No timers or schedule rules.")
+		return
+	}
+	if len(s.Timers) > 0 {
+		fmt.Fprintln(w, "Time limits:")
+		for _, t := range s.Timers {
+			fmt.Fprintf(w, "  %-24s until %s (%s left)\n", truncateRunes(t.Name, 24), t.Until.Local().Format("15:04"), formatRemaining(t.Until.Sub(now)))
+		}
+	}
+	if len(s.Rules) > 0 {
+		fmt.Fprintln(w, "Rules:")
+		for _, r := range s.Rules {
+			state := "outside window"
+			if r.contains(now) {
+				state = "inside window"
+			}
+			fmt.Fprintf(w, "  %-24s %s (now %s)\n", truncateRunes(r.Name, 24), r.describe(), state)
+		}
+	}
+}
+
+// --- TUI ---
+
+type scheduleTickMsg struct{}
+
+type scheduleRanMsg struct {
+	result scheduleResult
+	err    error
+}
+
+type connectForDoneMsg struct {
+	timer connectTimer
+	err   error
+}
+
+type connectForCancelledMsg struct {
+	timer *connectTimer
+	err   error
+}
+
+type connectForPrompt struct {
+	uuid, name string
+	input      textinput.Model
+	returnTo   viewState
+}
+
+func scheduleTickCmd() tea.Cmd {
+	return tea.Tick(scheduleTickInterval, func(time.Time) tea.Msg { return scheduleTickMsg{} })
+}
+
+func scheduleRunCmd() tea.Cmd {
+	return func() tea.Msg {
+		res, err := runSchedule(time.Now())
+		if err != nil {
+			log.Printf("Schedule: %v", err)
+		}
+		return scheduleRanMsg{result: res, err: err}
+	}
+}
+
+func (m *model) handleScheduleRan(msg scheduleRanMsg) []tea.Cmd {
+	cmds := []tea.Cmd{scheduleTickCmd()}
+	if msg.err != nil {
+		return cmds
+	}
+	m.timers = msg.result.Timers
+	if len(msg.result.Events) == 0 {
+		return cmds
+	}
+	if m.watchdog != nil && m.watchdog.target != nil && slices.Contains(msg.result.Disconnected, m.watchdog.target.UUID) {
+		m.watchdog.forget("schedule")
+	}
+	if m.state == viewNetworksList || m.state == viewKnownNetworksList {
+		m.setStatus("Schedule: "+strings.Join(msg.result.Events, "; "), toggleHiddenStatusMsgStyle)
+	}
+	cmds = append(cmds, fetchKnownNetworksCmd())
+	if m.state == viewKnownNetworksList {
+		cmds = append(cmds, fetchKnownWifiApsCmd())
+	}
+	return cmds
+}
+
+func (m *model) timerFor(uuid string) *connectTimer {
+	for i := range m.timers {
+		if m.timers[i].UUID == uuid {
+			return &m.timers[i]
+		}
+	}
+	return nil
+}
+
+// openConnectFor asks how long to stay on the selected saved network, or
+// cancels its time limit if it already has one.
+func (m *model) openConnectFor(l *list.Model) []tea.Cmd {
+	ap, ok := l.SelectedItem().(wifiAP)
+	uuid := ""
+	if ok && ap.WifiAccessPoint != nil {
+		uuid = ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionUUID]
+	}
+	if uuid == "" {
+		m.setStatus("Only saved networks can be connected for a limited time.", toggleHiddenStatusMsgStyle)
+		return nil
+	}
+	if m.timerFor(uuid) != nil {
+		return []tea.Cmd{func() tea.Msg {
+			t, err := cancelConnectFor(uuid)
+			return connectForCancelledMsg{timer: t, err: err}
+		}}
+	}
+	name := ap.WifiAccessPoint[gonetworkmanager.NmcliFieldConnectionName]
+	if name == "" {
+		name = ap.getSSIDFromScannedAP()
+	}
+	ti := textinput.New()
+	ti.Placeholder = strconv.Itoa(int(defaultConnectFor / time.Minute))
+	ti.CharLimit = 8
+	ti.Prompt = passwordPromptStyle.Render("Minutes: ")
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	ti.Focus()
+	m.connectFor = &connectForPrompt{uuid: uuid, name: name, input: ti, returnTo: m.state}
+	m.state = viewConnectFor
+	m.clearStatus()
+	return []tea.Cmd{textinput.Blink}
+}
+
+func (m *model) closeConnectFor() {
+	if m.connectFor != nil {
+		m.state = m.connectFor.returnTo
+	}
+	m.connectFor = nil
+	m.resizeComponents()
+}
+
+func (m *model) handleConnectForKeys(msg tea.KeyMsg) []tea.Cmd {
+	p := m.connectFor
+	if p == nil || m.isLoading {
+		return nil
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeConnectFor()
+		m.clearStatus()
+		return nil
+	case tea.KeyEnter:
+		raw := p.input.Value()
+		if strings.TrimSpace(raw) == "" {
+			raw = p.input.Placeholder
+		}
+		d, err := parseConnectDuration(raw)
+		if err != nil {
+			m.setStatus(err.Error(), errorStyle)
+			return nil
+		}
+		m.isLoading = true
+		m.setStatus(fmt.Sprintf("Connecting to %s for %s...", p.name, formatRemaining(d)), connectingStyle)
+		uuid, name := p.uuid, p.name
+		return []tea.Cmd{func() tea.Msg {
+			t, err := connectFor(uuid, name, d, time.Now())
+			return connectForDoneMsg{timer: t, err: err}
+		}, m.spinner.Tick}
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return []tea.Cmd{cmd}
+}
+
+func (m *model) handleConnectForDone(msg connectForDoneMsg) []tea.Cmd {
+	m.isLoading = false
+	m.closeConnectFor()
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not connect to %s: %v", msg.timer.Name, msg.err), errorStyle)
+		return nil
+	}
+	m.timers = slices.DeleteFunc(m.timers, func(t connectTimer) bool { return t.UUID == msg.timer.UUID })
+	m.timers = append(m.timers, msg.timer)
+	m.setStatus(fmt.Sprintf("Connected to %s until %s. T on it again cancels the limit.", msg.timer.Name, msg.timer.Until.Format("15:04")), successStyle)
+	return []tea.Cmd{fetchKnownNetworksCmd()}
+}
+
+func (m *model) handleConnectForCancelled(msg connectForCancelledMsg) {
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Could not cancel the time limit: %v", msg.err), errorStyle)
+		return
+	}
+	if msg.timer == nil {
+		return
+	}
+	m.timers = slices.DeleteFunc(m.timers, func(t connectTimer) bool { return t.UUID == msg.timer.UUID })
+	m.setStatus(fmt.Sprintf("Time limit of %s cancelled; it stays connected.", msg.timer.Name), successStyle)
+}
+
+func (m model) connectForView(width, height int) string {
+	p := m.connectFor
+	if p == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{
+		titleStyle.Render("Connect for a Limited Time"),
+		label.Render("Connect to ") + p.name + label.Render(" and disconnect it automatically afterwards."),
+		"",
+		p.input.View(),
+		"",
+		label.Render("Minutes or a duration such as 1h30m. The app or `watch` must be running to disconnect."),
+		label.Render("Enter: connect  Esc: cancel"),
+	}
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
+
+func (m model) timerBannerView(width int) string {
+	if len(m.timers) == 0 {
+		return ""
+	}
+	t := m.timers[0]
+	for _, other := range m.timers[1:] {
+		if other.Until.Before(t.Until) {
+			t = other
+		}
+	}
+	text := fmt.Sprintf("⏱ %s disconnects in %s", t.Name, formatRemaining(time.Until(t.Until)))
+	if n := len(m.timers) - 1; n > 0 {
+		text += fmt.Sprintf(" (+%d more)", n)
+	}
+	style := lipgloss.NewStyle().Foreground(ansAccentColor).Bold(true).MaxWidth(width)
+	return style.Render(truncateRunes(text, width))
+}
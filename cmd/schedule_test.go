@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestScheduleRuleWindows(t *testing.T) {
+	at := func(day time.Weekday, hhmm string) time.Time {
+		clock, _ := time.Parse("15:04", hhmm)
+		// 2026-10-18 is a Sunday.
+		return time.Date(2026, 10, 18+int(day), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	}
+	office := scheduleRule{Window: "09:00-18:00", Days: "mon-fri"}
+	night := scheduleRule{Window: "22:00-06:00", Days: "fri"}
+	for _, tc := range []struct {
+		rule scheduleRule
+		at   time.Time
+		want bool
+	}{
+		{office, at(time.Monday, "09:00"), true},
+		{office, at(time.Monday, "18:00"), false},
+		{office, at(time.Saturday, "12:00"), false},
+		{night, at(time.Friday, "23:30"), true},
+		{night, at(time.Saturday, "05:59"), true},
+		{night, at(time.Saturday, "23:00"), false},
+		{night, at(time.Friday, "05:00"), false},
+	} {
+		if got := tc.rule.contains(tc.at); got != tc.want {
+			t.Errorf("%s %s at %s: got %v", tc.rule.Window, tc.rule.Days, tc.at.Format("Mon 15:04"), got)
+		}
+	}
+
+	if days, err := parseDays("fri-mon"); err != nil || !days[5] || !days[0] || !days[1] || days[2] {
+		t.Fatalf("unexpected wrap-around range %v %v", days, err)
+	}
+	for _, bad := range [][2]string{{"9-18", ""}, {"09:00-09:00", ""}, {"09:00-18:00", "someday"}} {
+		if _, err := newScheduleRule("u", "X", bad[0], bad[1], false); err == nil {
+			t.Errorf("newScheduleRule(%q, %q) should fail", bad[0], bad[1])
+		}
+	}
+}
+
+func TestSchedulePlan(t *testing.T) {
+	now := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local) // Monday
+	inside := true
+	s := &scheduleState{
+		Timers: []connectTimer{
+			{UUID: "u-vendor", Name: "Vendor", Until: now.Add(-time.Second)},
+			{UUID: "u-lab", Name: "Lab", Until: now.Add(time.Minute)},
+			{UUID: "u-gone", Name: "Gone", Until: now.Add(time.Minute)},
+		},
+		Rules: []scheduleRule{
+			{UUID: "u-office", Name: "Office", Window: "09:00-18:00", Disconnect: true, InWindow: &inside},
+			{UUID: "u-cafe", Name: "Cafe", Window: "17:00-19:00"},
+		},
+	}
+	profiles := []gonetworkmanager.KnownWifiProfile{
+		{Name: "Vendor", UUID: "u-vendor", Device: "wlan0"},
+		{Name: "Lab", UUID: "u-lab", Device: "wlan1"},
+		{Name: "Office", UUID: "u-office", Autoconnect: true, Device: "wlan2"},
+		{Name: "Cafe", UUID: "u-cafe"},
+	}
+	var events []string
+	for _, a := range s.plan(profiles, now) {
+		events = append(events, a.event(nil))
+	}
+	want := []string{
+		"disconnected Vendor (time limit reached)",
+		"autoconnect off for Office (outside 09:00-18:00)",
+		"disconnected Office (09:00-18:00 ended)",
+		"autoconnect on for Cafe (inside 17:00-19:00)",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(events, "\n"))
+	}
+	if len(s.Timers) != 2 || s.Timers[0].Name != "Vendor" || s.Timers[1].Name != "Lab" {
+		t.Fatalf("timers of deleted profiles should be dropped, and expired ones kept until disconnected: %+v", s.Timers)
+	}
+
+	// Once outside the window, using Office again is left alone, and the
+	// disconnected Vendor's timer goes.
+	profiles[0].Device = ""
+	profiles[1].Device = ""
+	profiles[2].Autoconnect = false
+	if actions := s.plan(profiles, now.Add(time.Hour)); len(actions) != 0 {
+		t.Fatalf("the window end should disconnect only once, got %+v", actions)
+	}
+	if len(s.Timers) != 0 {
+		t.Fatalf("expired timers of inactive profiles should be dropped: %+v", s.Timers)
+	}
+}
+
+const scheduleFakeNmcli = `
+case "$*" in
+  "-m multiline -f NAME,UUID,TYPE,DEVICE,AUTOCONNECT,AUTOCONNECT-PRIORITY,TIMESTAMP connection show --order name")
+    printf 'NAME: Vendor\nUUID: u-vendor\nTYPE: 802-11-wireless\nDEVICE: wlan0\nAUTOCONNECT: no\n'
+    printf 'NAME: Office\nUUID: u-office\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\n' ;;
+  "-m multiline connection show Office")
+    printf 'connection.id: Office\nconnection.uuid: u-office\nconnection.type: 802-11-wireless\n' ;;
+  "-m multiline connection show corp-vpn")
+    printf 'connection.id: corp-vpn\nconnection.uuid: u-vpn\nconnection.type: vpn\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless.ssid connection show "*)
+    printf 'connection.uuid: u-vendor\n802-11-wireless.ssid: Vendor\nconnection.uuid: u-office\n802-11-wireless.ssid: Office\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestScheduleCLI(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	installFakeCommand(t, "nmcli", scheduleFakeNmcli)
+
+	// A window that is certainly not now.
+	start := time.Now().Add(2 * time.Hour)
+	window := start.Format("15:04") + "-" + start.Add(time.Hour).Format("15:04")
+	var out, errOut bytes.Buffer
+	if code := runScheduleCLI([]string{"add", "--disconnect", "Office", window}, &out, &errOut); code != 0 {
+		t.Fatalf("add failed (%d): %s", code, errOut.String())
+	}
+	if code := runScheduleCLI([]string{"add", "Office", "25:00-26:00"}, &out, &errOut); code != 2 {
+		t.Fatalf("expected a usage error for a bad window, got %d", code)
+	}
+	for _, args := range [][]string{{"connect", "corp-vpn", "30"}, {"add", "corp-vpn", window}} {
+		errOut.Reset()
+		if code := runScheduleCLI(args, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "only Wi-Fi profiles") {
+			t.Fatalf("%v: expected a non-Wi-Fi profile to be refused, got %d: %s", args, code, errOut.String())
+		}
+	}
+	if s, _ := loadSchedule(); len(s.Timers) != 0 || len(s.Rules) != 1 {
+		t.Fatalf("the VPN profile must not be scheduled: %+v", s)
+	}
+	err := updateSchedule(func(s *scheduleState) error {
+		s.Timers = append(s.Timers, connectTimer{UUID: "u-vendor", Name: "Vendor", Until: time.Now().Add(-time.Minute)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if code := runScheduleCLI([]string{"run"}, &out, &errOut); code != 0 {
+		t.Fatalf("run failed (%d): %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "✓ disconnected Vendor (time limit reached)") || !strings.Contains(out.String(), "✓ autoconnect off for Office") {
+		t.Fatalf("unexpected run output:\n%s", out.String())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if string(calls) != "connection down u-vendor\nconnection modify u-office connection.autoconnect no\n" {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+
+	out.Reset()
+	runScheduleCLI(nil, &out, &errOut)
+	if !strings.Contains(out.String(), "Office") || !strings.Contains(out.String(), "disconnect when it ends") || strings.Contains(out.String(), "Time limits") {
+		t.Fatalf("unexpected list:\n%s", out.String())
+	}
+	if code := runScheduleCLI([]string{"remove", "Office"}, &out, &errOut); code != 0 {
+		t.Fatalf("remove failed: %s", errOut.String())
+	}
+	if s, _ := loadSchedule(); len(s.Rules) != 0 {
+		t.Fatalf("expected the rule to be gone: %+v", s.Rules)
+	}
+}
+
+func TestScheduleKeepsTimerWhenDisconnectFails(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	installFakeCommand(t, "nmcli", `
+if [ "$*" = "connection down u-vendor" ]; then
+  echo "Error: Connection deactivation failed." >&2
+  exit 1
+fi
+`+scheduleFakeNmcli)
+
+	err := updateSchedule(func(s *scheduleState) error {
+		s.Timers = append(s.Timers, connectTimer{UUID: "u-vendor", Name: "Vendor", Until: time.Now().Add(-time.Minute)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out, errOut bytes.Buffer
+	runScheduleCLI([]string{"run"}, &out, &errOut)
+	if !strings.Contains(out.String(), "Vendor") {
+		t.Fatalf("expected the failure to be reported:\n%s", out.String())
+	}
+	if s, _ := loadSchedule(); len(s.Timers) != 1 || s.Timers[0].UUID != "u-vendor" {
+		t.Fatalf("the timer should survive a failed disconnect: %+v", s.Timers)
+	}
+}
+
+func TestConnectForFromTheList(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	installFakeCommand(t, "nmcli", scheduleFakeNmcli)
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	m.isLoading = false
+	m.knownWifiList.SetItems([]list.Item{wifiAP{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{
+		gonetworkmanager.NmcliFieldWifiSSID:       "Vendor",
+		gonetworkmanager.NmcliFieldConnectionName: "Vendor",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-vendor",
+	}, IsKnown: true}})
+
+	m, _ = press(t, m, runeKey('T'))
+	if m.state != viewConnectFor || !m.isTextInputActive() {
+		t.Fatalf("expected the duration prompt")
+	}
+	m, _ = press(t, m, runeKey('x'), enterKey)
+	if !strings.Contains(m.connectionStatusMsg, "at least one minute") {
+		t.Fatalf("expected a validation error, got %q", m.connectionStatusMsg)
+	}
+	m.connectFor.input.SetValue("")
+	m, cmd := press(t, m, enterKey) // the 30 minute default
+	updated, _ := m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if m.state != viewKnownNetworksList || !strings.Contains(m.View(), "⏱ Vendor disconnects in 30m") {
+		t.Fatalf("expected the time limit banner:\n%s", m.View())
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if string(calls) != "connection up u-vendor\n" {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+	if s, _ := loadSchedule(); len(s.Timers) != 1 || s.Timers[0].UUID != "u-vendor" {
+		t.Fatalf("the timer should be persisted: %+v", s)
+	}
+
+	m, cmd = press(t, m, runeKey('T'))
+	updated, _ = m.Update(cmd())
+	m = updated.(model)
+	if len(m.timers) != 0 || !strings.Contains(m.connectionStatusMsg, "cancelled") {
+		t.Fatalf("T on a limited profile should cancel the limit, got %q", m.connectionStatusMsg)
+	}
+}
@@ -0,0 +1,194 @@
+// nmtui/cmd/secrets.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+// secretProviderTimeout bounds one run of the secret provider; a password
+// manager may wait for its agent to be unlocked.
+const secretProviderTimeout = 30 * time.Second
+
+// pskStorages are the profile form's password storage choices, in the
+// order the form cycles through them, and the psk-flags each stands for.
+var pskStorages = []struct{ name, flags, desc string }{
+	{"all-users", gonetworkmanager.PSKFlagsSystem, "all users (stored in the profile)"},
+	{"this-user", gonetworkmanager.PSKFlagsAgentOwned, "this user only (agent-owned)"},
+	{"ask", gonetworkmanager.PSKFlagsNotSaved, "always ask (not saved)"},
+}
+
+func parsePSKStorage(v string) (flags string, err error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, s := range pskStorages {
+		if v == s.name {
+			return s.flags, nil
+		}
+	}
+	return "", errors.New("password storage must be all-users, this-user or ask")
+}
+
+// defaultPSKStorage is the storage new profiles get; secret_agent_owned
+// keeps passwords out of profiles.
+func defaultPSKStorage(agentOwned bool) string {
+	if agentOwned {
+		return pskStorages[1].name
+	}
+	return pskStorages[0].name
+}
+
+// pskStorageFor names the choice that psk-flags, as nmcli prints them,
+// stand for.
+func pskStorageFor(flags string) (name, desc string) {
+	switch {
+	case gonetworkmanager.PSKStoredBySystem(flags):
+		return pskStorages[0].name, pskStorages[0].desc
+	case strings.HasPrefix(flags, gonetworkmanager.PSKFlagsAgentOwned):
+		return pskStorages[1].name, pskStorages[1].desc
+	}
+	return pskStorages[2].name, pskStorages[2].desc
+}
+
+// secretLookupMsg is the secret provider's answer for ssid.
+type secretLookupMsg struct {
+	ssid    string
+	secret  string
+	err     error
+	forForm bool // asked for the profile form rather than a connect
+}
+
+// lookupSecret runs the secret provider command for ssid and returns the
+// first line of its output, as `pass show` prints it. The command runs
+// under sh with {ssid} replaced by a quoted reference to the SSID, so no
+// network name can change the command line.
+func lookupSecret(command, ssid string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretProviderTimeout)
+	defer cancel()
+	script := strings.ReplaceAll(command, "{ssid}", `"$1"`)
+	cmd := exec.CommandContext(ctx, "sh", "-c", script, "sh", ssid)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("secret provider timed out after %s", secretProviderTimeout)
+		}
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("secret provider: %s", msg)
+		}
+		return "", fmt.Errorf("secret provider: %w", err)
+	}
+	secret, _, _ := strings.Cut(stdout.String(), "\n")
+	secret = strings.TrimSuffix(secret, "\r")
+	if secret == "" {
+		return "", errors.New("secret provider printed no password")
+	}
+	return secret, nil
+}
+
+func secretLookupCmd(command, ssid string, forForm bool) tea.Cmd {
+	return func() tea.Msg {
+		secret, err := lookupSecret(command, ssid)
+		return secretLookupMsg{ssid: ssid, secret: secret, err: err, forForm: forForm}
+	}
+}
+
+// askForPassword gets the password of the selected network from the secret
+// provider when one is configured, and from the password prompt otherwise
+// or when the provider has none.
+func (m *model) askForPassword(status string) []tea.Cmd {
+	if m.secretCommand == "" {
+		return m.promptForPassword(status)
+	}
+	ssid := m.selectedAP.getSSIDFromScannedAP()
+	m.isLoading = true
+	m.state = viewConnecting
+	m.setStatus(fmt.Sprintf("Looking up the password for %s...", ssid), connectingStyle)
+	return []tea.Cmd{secretLookupCmd(m.secretCommand, ssid, false), m.spinner.Tick}
+}
+
+func (m *model) promptForPassword(status string) []tea.Cmd {
+	m.isLoading = false
+	m.state = viewPasswordInput
+	m.passwordInput.SetValue("")
+	m.passwordInput.Err = nil
+	m.passwordInput.Focus()
+	m.connectionStatusMsg = status
+	return []tea.Cmd{textinput.Blink}
+}
+
+// connectWithPasswordCmd connects to ssid with a password. A saved profile
+// that does not store its password is activated with it as it is; a new
+// one is saved as agent-owned when secret_agent_owned is set.
+func (m model) connectWithPasswordCmd(ssid, password string) tea.Cmd {
+	var connect func() (string, error)
+	switch uuid, ok := m.unsavedPSKProfile(ssid); {
+	case ok:
+		connect = func() (string, error) { return gonetworkmanager.ConnectionUpWithPSK(uuid, password) }
+	case m.agentOwnedSecrets:
+		connect = func() (string, error) { return gonetworkmanager.ConnectWifiAgentOwned(ssid, password) }
+	default:
+		return connectToWifiCmd(ssid, password, false)
+	}
+	return func() tea.Msg {
+		_, err := connect()
+		return connectionAttemptMsg{ssid: ssid, success: err == nil, err: err}
+	}
+}
+
+// unsavedPSKProfile returns the UUID of the saved profile of ssid when its
+// password is not stored in it, so it has to be given on every connect.
+func (m model) unsavedPSKProfile(ssid string) (string, bool) {
+	p, ok := m.knownProfiles[ssid]
+	if !ok || gonetworkmanager.PSKStoredBySystem(p[gonetworkmanager.NmcliFieldWifiPSKFlags]) {
+		return "", false
+	}
+	return p[gonetworkmanager.NmcliFieldConnectionUUID], true
+}
+
+func (m *model) handleSecretLookup(msg secretLookupMsg) []tea.Cmd {
+	if msg.forForm {
+		if m.state != viewProfileCreate && m.state != viewProfileEdit {
+			return nil
+		}
+		m.isLoading = false
+		if msg.err != nil {
+			m.profileForm.statusMsg = errorStyle.Render(fmt.Sprintf("%v; enter the password.", msg.err))
+			m.focusProfileInput(profileFieldPassword)
+			return nil
+		}
+		m.profileForm.inputs[profileFieldPassword].SetValue(msg.secret)
+		return m.saveProfileForm()
+	}
+
+	if m.state != viewConnecting || m.selectedAP.getSSIDFromScannedAP() != msg.ssid {
+		return nil
+	}
+	if msg.err == nil && m.selectedAP.usesWPAPSK() {
+		msg.err = gonetworkmanager.ValidateWifiPSK(msg.secret)
+	}
+	if msg.err != nil {
+		return m.promptForPassword(toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("%v. Enter the password:", msg.err)))
+	}
+	m.setStatus(fmt.Sprintf("Connecting to %s...", m.selectedAP.StyledTitle()), connectingStyle)
+	return []tea.Cmd{m.connectWithPasswordCmd(msg.ssid, msg.secret), m.spinner.Tick}
+}
+
+// profileFormNeedsSecret reports whether saving the profile form should
+// first ask the secret provider for the password left empty.
+func (m model) profileFormNeedsSecret() bool {
+	f := m.profileForm
+	flags, err := parsePSKStorage(f.inputs[profileFieldPSKStorage].Value())
+	return m.secretCommand != "" && err == nil && flags == gonetworkmanager.PSKFlagsSystem && f.mode == profileFormCreate &&
+		normalizeSecurity(f.inputs[profileFieldSecurity].Value()) == "wpa-psk" &&
+		f.inputs[profileFieldPassword].Value() == "" && strings.TrimSpace(f.inputs[profileFieldSSID].Value()) != ""
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+// installFakeProvider puts a `fakepass` secret provider on PATH that knows
+// the passwords of Home and of a network with an awkward name.
+func installFakeProvider(t *testing.T) {
+	t.Helper()
+	installFakeCommand(t, "fakepass", `case "$2" in
+  wifi/Home) printf 'hunter22\nurl: https://example.org\n' ;;
+  "wifi/Cafe \"Bar\"; false") echo 'caf3s3cret' ;;
+  wifi/Empty) ;;
+  *) echo "Error: $2 is not in the password store." >&2; exit 1 ;;
+esac
+`)
+}
+
+func TestLookupSecret(t *testing.T) {
+	installFakeProvider(t)
+	for ssid, want := range map[string]string{"Home": "hunter22", `Cafe "Bar"; false`: "caf3s3cret"} {
+		if got, err := lookupSecret("fakepass show wifi/{ssid}", ssid); err != nil || got != want {
+			t.Errorf("lookupSecret(%q) = %q, %v; want %q", ssid, got, err, want)
+		}
+	}
+	if _, err := lookupSecret("fakepass show wifi/{ssid}", "Office"); err == nil || err.Error() != "secret provider: Error: wifi/Office is not in the password store." {
+		t.Errorf("expected the provider's error, got %v", err)
+	}
+	if _, err := lookupSecret("fakepass show wifi/{ssid}", "Empty"); err == nil || !strings.Contains(err.Error(), "printed no password") {
+		t.Errorf("expected an empty-output error, got %v", err)
+	}
+}
+
+func secretsTestModel(t *testing.T, agentOwned bool) model {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_RUNTIME_DIR", state)
+	installFakeProvider(t)
+	installFakeCommand(t, "nmcli", `echo "$*" >> "$STATE/calls"
+case "$*" in
+  "connection add "*) echo "Connection '$6' (u-new) successfully added." ;;
+  "connection up "*) cat "$5" >> "$STATE/calls" ;;
+esac
+`)
+	m := windowedModel(t)
+	m.isLoading = false
+	m.secretCommand = "fakepass show wifi/{ssid}"
+	m.agentOwnedSecrets = agentOwned
+	updated, _ := m.Update(wifiListLoadedMsg{allAps: []wifiAP{surveyAP("Home", "aa:01", "80", "6"), surveyAP("Office", "aa:02", "60", "11")}})
+	return updated.(model)
+}
+
+// runCmds runs a command and feeds every message it produces back into
+// the model, following batches, until nothing is left.
+func runCmds(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = runCmds(t, m, c)
+		}
+	case secretLookupMsg, connectionAttemptMsg, profileSaveResultMsg:
+		updated, next := m.Update(msg)
+		m = updated.(model)
+		if _, done := msg.(secretLookupMsg); done {
+			m = runCmds(t, m, next)
+		}
+	}
+	return m
+}
+
+func nmcliCalls(t *testing.T) string {
+	t.Helper()
+	calls, _ := os.ReadFile(filepath.Join(os.Getenv("STATE"), "calls"))
+	return string(calls)
+}
+
+func TestConnectAsksSecretProvider(t *testing.T) {
+	m := secretsTestModel(t, false)
+	m, cmd := press(t, m, enterKey)
+	if m.state != viewConnecting || !strings.Contains(m.connectionStatusMsg, "Looking up the password for Home") {
+		t.Fatalf("expected a provider lookup, state %v status %q", m.state, m.connectionStatusMsg)
+	}
+	m = runCmds(t, m, cmd)
+	if calls := nmcliCalls(t); !strings.Contains(calls, "device wifi connect Home password hunter22\n") {
+		t.Fatalf("expected a connect with the provider's password:\n%s", calls)
+	}
+
+	// A network the provider does not know falls back to the prompt.
+	m.state = viewNetworksList
+	m, cmd = press(t, m, downKey, enterKey)
+	m = runCmds(t, m, cmd)
+	if m.state != viewPasswordInput || !strings.Contains(m.connectionStatusMsg, "wifi/Office is not in the password store") {
+		t.Fatalf("expected the password prompt, state %v status %q", m.state, m.connectionStatusMsg)
+	}
+}
+
+func TestAgentOwnedSecrets(t *testing.T) {
+	m := secretsTestModel(t, true)
+	m, cmd := press(t, m, enterKey)
+	m = runCmds(t, m, cmd)
+	calls := nmcliCalls(t)
+	for _, want := range []string{"wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 1 ", "802-11-wireless-security.psk:hunter22\n"} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("expected %q in the nmcli calls:\n%s", want, calls)
+		}
+	}
+	if strings.Contains(calls, "wifi-sec.psk hunter22") {
+		t.Errorf("the password must not be stored in the profile:\n%s", calls)
+	}
+
+	// The profile form saves agent-owned profiles without a password.
+	m.initProfileForm(profileFormCreate, nil)
+	m.state = viewProfileCreate
+	m.profileForm.inputs[profileFieldName].SetValue("Lab")
+	m.profileForm.inputs[profileFieldSSID].SetValue("Lab")
+	m.profileForm.inputs[profileFieldSecurity].SetValue("wpa-psk")
+	spec, _, _, err := m.validateProfileForm()
+	if err != nil || spec.PSKFlags != "1" || spec.Password != "" {
+		t.Fatalf("unexpected spec %+v, %v", spec, err)
+	}
+}
+
+func TestProfileFormAsksSecretProvider(t *testing.T) {
+	m := secretsTestModel(t, false)
+	m.initProfileForm(profileFormCreate, nil)
+	m.state = viewProfileCreate
+	m.profileForm.inputs[profileFieldName].SetValue("Home")
+	m.profileForm.inputs[profileFieldSSID].SetValue("Home")
+	m.profileForm.inputs[profileFieldSecurity].SetValue("wpa-psk")
+	m, cmd := press(t, m, enterKey)
+	m = runCmds(t, m, cmd)
+	if calls := nmcliCalls(t); !strings.Contains(calls, "ssid Home wifi-sec.key-mgmt wpa-psk wifi-sec.psk hunter22 ") {
+		t.Fatalf("expected the provider's password in the new profile:\n%s", calls)
+	}
+
+	m.initProfileForm(profileFormCreate, nil)
+	m.state = viewProfileCreate
+	m.profileForm.inputs[profileFieldName].SetValue("Office")
+	m.profileForm.inputs[profileFieldSSID].SetValue("Office")
+	m.profileForm.inputs[profileFieldSecurity].SetValue("wpa-psk")
+	m, cmd = press(t, m, enterKey)
+	m = runCmds(t, m, cmd)
+	if m.state != viewProfileCreate || m.profileForm.focusIndex != profileFieldPassword ||
+		!strings.Contains(m.profileForm.statusMsg, "enter the password") {
+		t.Fatalf("expected to be asked for the password, status %q", m.profileForm.statusMsg)
+	}
+}
+
+func TestProfilesThatDoNotStoreTheirPassword(t *testing.T) {
+	m := secretsTestModel(t, false)
+	m.knownProfiles = map[string]gonetworkmanager.ConnectionProfile{
+		"Home": {gonetworkmanager.NmcliFieldConnectionUUID: "u-home", gonetworkmanager.NmcliFieldWifiPSKFlags: "2 (not-saved)"},
+	}
+	updated, _ := m.Update(wifiListLoadedMsg{allAps: []wifiAP{surveyAP("Home", "aa:01", "80", "6")}})
+	m = updated.(model)
+
+	// The provider's password activates the profile as it is.
+	m, cmd := press(t, m, enterKey)
+	m = runCmds(t, m, cmd)
+	calls := nmcliCalls(t)
+	if !strings.Contains(calls, "connection up u-home passwd-file ") || !strings.Contains(calls, "802-11-wireless-security.psk:hunter22\n") ||
+		strings.Contains(calls, "device wifi connect") || strings.Contains(calls, "connection modify") {
+		t.Fatalf("expected the profile to be activated with the password:\n%s", calls)
+	}
+
+	// Without a provider the password is asked for up front.
+	m.secretCommand = ""
+	m.state = viewNetworksList
+	m, _ = press(t, m, enterKey)
+	if m.state != viewPasswordInput || !strings.Contains(m.connectionStatusMsg, "does not store its password") {
+		t.Fatalf("expected the password prompt, state %v status %q", m.state, m.connectionStatusMsg)
+	}
+
+	// The form shows the profile's storage and writes it only when changed.
+	m.initProfileForm(profileFormEdit, gonetworkmanager.ConnectionProfile{
+		gonetworkmanager.NmcliFieldConnectionName: "Home",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-home",
+		"802-11-wireless.ssid":                    "Home",
+		"802-11-wireless-security.key-mgmt":       "wpa-psk",
+		gonetworkmanager.NmcliFieldWifiPSKFlags:   "2 (not-saved)",
+	})
+	if v := m.profileForm.inputs[profileFieldPSKStorage].Value(); v != "ask" {
+		t.Fatalf("expected the storage to be ask, got %q", v)
+	}
+	if spec, _, _, err := m.validateProfileForm(); err != nil || spec.PSKFlags != "" {
+		t.Fatalf("unchanged storage should not be written: %+v %v", spec, err)
+	}
+	m.profileForm.inputs[profileFieldPSKStorage].SetValue("all-users")
+	if _, _, _, err := m.validateProfileForm(); err == nil || !strings.Contains(err.Error(), "password is required") {
+		t.Fatalf("storing the password for all users needs one, got %v", err)
+	}
+	m.profileForm.inputs[profileFieldPassword].SetValue("hunter22")
+	if spec, provided, _, err := m.validateProfileForm(); err != nil || spec.PSKFlags != "0" || !provided {
+		t.Fatalf("unexpected spec %+v, %v", spec, err)
+	}
+	m.profileForm.inputs[profileFieldPSKStorage].SetValue("sometimes")
+	if _, _, _, err := m.validateProfileForm(); err == nil {
+		t.Fatalf("expected an invalid storage to be rejected")
+	}
+
+	// The details show the storage and have no password to reveal.
+	m.state, m.profileDetailsID = viewProfileDetails, "u-home"
+	updated, _ = m.Update(profileLoadedMsg{profile: gonetworkmanager.ConnectionProfile{
+		gonetworkmanager.NmcliFieldConnectionName: "Home",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-home",
+		"802-11-wireless-security.key-mgmt":       "wpa-psk",
+		gonetworkmanager.NmcliFieldWifiPSKFlags:   "1 (agent-owned)",
+	}})
+	m = updated.(model)
+	if v := m.View(); !strings.Contains(v, "Password storage: this user only (agent-owned)") || strings.Contains(v, "Password: ") {
+		t.Fatalf("unexpected details:\n%s", v)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Constants ---
+
+const (
+	statsSampleInterval = time.Second
+	statsHistoryLen     = 40
+	statsBitrateEvery   = 5 // query the (nmcli-backed) bitrate every Nth sample
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// --- Types ---
+
+type statsSampleMsg struct {
+	device  string
+	gen     int
+	at      time.Time
+	stats   *gonetworkmanager.InterfaceStats
+	link    *gonetworkmanager.WirelessLink
+	bitrate string
+	err     error
+}
+
+// statsTickMsg schedules the next sample; gen tells the sampling loops of
+// successive visits to the info view apart.
+type statsTickMsg struct {
+	device string
+	gen    int
+}
+
+// liveStats accumulates interface counter samples for the active connection panel.
+type liveStats struct {
+	device    string
+	gen       int
+	isWifi    bool
+	first     *gonetworkmanager.InterfaceStats
+	last      *gonetworkmanager.InterfaceStats
+	lastAt    time.Time
+	startedAt time.Time
+	rxRate    float64
+	txRate    float64
+	history   []float64
+	link      *gonetworkmanager.WirelessLink
+	bitrate   string
+	samples   int
+	err       error
+}
+
+func newLiveStats(device string, isWifi bool) *liveStats {
+	return &liveStats{device: device, isWifi: isWifi}
+}
+
+// --- Sampling ---
+
+// add folds a new counter sample into the running rates and history.
+func (ls *liveStats) add(st *gonetworkmanager.InterfaceStats, at time.Time) {
+	if ls.first == nil {
+		ls.first = st
+		ls.startedAt = at
+	} else if ls.last != nil {
+		if dt := at.Sub(ls.lastAt).Seconds(); dt > 0 {
+			ls.rxRate = float64(counterDelta(ls.last.RxBytes, st.RxBytes)) / dt
+			ls.txRate = float64(counterDelta(ls.last.TxBytes, st.TxBytes)) / dt
+			ls.history = append(ls.history, ls.rxRate+ls.txRate)
+			if len(ls.history) > statsHistoryLen {
+				ls.history = ls.history[len(ls.history)-statsHistoryLen:]
+			}
+		}
+	}
+	ls.last = st
+	ls.lastAt = at
+	ls.samples++
+}
+
+// counterDelta tolerates counter resets (e.g. the interface was re-created).
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+func (ls *liveStats) sessionTotals() (rx, tx uint64) {
+	if ls.first == nil || ls.last == nil {
+		return 0, 0
+	}
+	return counterDelta(ls.first.RxBytes, ls.last.RxBytes), counterDelta(ls.first.TxBytes, ls.last.TxBytes)
+}
+
+func sampleStatsCmd(device string, gen int, isWifi bool, withBitrate bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := statsSampleMsg{device: device, gen: gen, at: time.Now()}
+		msg.stats, msg.err = gonetworkmanager.ReadInterfaceStats(device)
+		if msg.err != nil {
+			log.Printf("Cmd: Error reading interface stats for %s: %v", device, msg.err)
+			return msg
+		}
+		if isWifi {
+			link, err := gonetworkmanager.ReadWirelessLink(device)
+			if err != nil {
+				log.Printf("Cmd: Error reading wireless link for %s: %v", device, err)
+			}
+			msg.link = link
+			if withBitrate {
+				rate, err := gonetworkmanager.WifiLinkBitrate(device)
+				if err != nil {
+					log.Printf("Cmd: Error reading bitrate for %s: %v", device, err)
+				}
+				msg.bitrate = rate
+			}
+		}
+		return msg
+	}
+}
+
+func statsTickCmd(device string, gen int) tea.Cmd {
+	return tea.Tick(statsSampleInterval, func(time.Time) tea.Msg { return statsTickMsg{device: device, gen: gen} })
+}
+
+// --- Model integration ---
+
+func (m *model) startLiveStats(device string) tea.Cmd {
+	m.statsGen++
+	m.liveStats = newLiveStats(device, true)
+	m.liveStats.gen = m.statsGen
+	return sampleStatsCmd(device, m.statsGen, true, true)
+}
+
+// ownsStats reports whether a tick or sample belongs to the current
+// sampling loop; loops of earlier visits to the info view end there.
+func (m *model) ownsStats(device string, gen int) bool {
+	return m.state == viewActiveConnectionInfo && m.liveStats != nil && m.liveStats.device == device && m.liveStats.gen == gen
+}
+
+func (m *model) handleStatsTick(msg statsTickMsg) tea.Cmd {
+	if !m.ownsStats(msg.device, msg.gen) {
+		return nil
+	}
+	return sampleStatsCmd(msg.device, msg.gen, m.liveStats.isWifi, m.liveStats.samples%statsBitrateEvery == 0)
+}
+
+func (m *model) handleStatsSample(msg statsSampleMsg) tea.Cmd {
+	if !m.ownsStats(msg.device, msg.gen) {
+		return nil
+	}
+	ls := m.liveStats
+	ls.err = msg.err
+	if msg.err == nil {
+		ls.add(msg.stats, msg.at)
+		if msg.link != nil {
+			ls.link = msg.link
+		}
+		if msg.bitrate != "" {
+			ls.bitrate = msg.bitrate
+		}
+	}
+	m.refreshActiveConnInfoContent()
+	return statsTickCmd(msg.device, msg.gen)
+}
+
+// refreshActiveConnInfoContent redraws the info viewport from the static IP
+// details plus the live statistics panel.
+func (m *model) refreshActiveConnInfoContent() {
+	content := m.activeConnInfoText
+	if panel := m.liveStatsView(); panel != "" {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += panel
+	}
+	m.activeConnInfoViewport.SetContent(content)
+}
+
+func (m model) liveStatsView() string {
+	ls := m.liveStats
+	if ls == nil {
+		return ""
+	}
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	lines := []string{listTitleStyle.Render(fmt.Sprintf("Live statistics (%s)", ls.device))}
+	if ls.err != nil {
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("Statistics unavailable: %v", ls.err)))
+		return strings.Join(lines, "\n")
+	}
+	if ls.last == nil {
+		return strings.Join(append(lines, label.Render("Sampling...")), "\n")
+	}
+	rxTotal, txTotal := ls.sessionTotals()
+	lines = append(lines,
+		fmt.Sprintf("%s ↓ %s  ↑ %s", label.Render("Rate:   "), formatRate(ls.rxRate), formatRate(ls.txRate)),
+		fmt.Sprintf("%s ↓ %s  ↑ %s  (%s)", label.Render("Session:"), formatBytes(rxTotal), formatBytes(txTotal), ls.lastAt.Sub(ls.startedAt).Round(time.Second)),
+		fmt.Sprintf("%s ↓ %d  ↑ %d", label.Render("Packets:"), ls.last.RxPackets, ls.last.TxPackets),
+		fmt.Sprintf("%s rx %d / tx %d   %s rx %d / tx %d", label.Render("Errors: "), ls.last.RxErrors, ls.last.TxErrors, label.Render("Drops:"), ls.last.RxDropped, ls.last.TxDropped),
+	)
+	if ls.isWifi {
+		linkLine := label.Render("n/a")
+		if ls.link != nil {
+			linkLine = fmt.Sprintf("%d%% (signal %.0f dBm)", ls.link.QualityPercent(), ls.link.LevelDBm)
+		}
+		bitrate := ls.bitrate
+		if bitrate == "" {
+			bitrate = "n/a"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s   %s %s", label.Render("Link:   "), linkLine, label.Render("Bitrate:"), bitrate))
+	}
+	if len(ls.history) > 0 {
+		lines = append(lines, "", connectingStyle.Render(sparkline(ls.history)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// --- Formatting helpers ---
+
+func sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if max > 0 {
+			idx = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatRate(bytesPerSec float64) string {
+	return formatBytes(uint64(bytesPerSec)) + "/s"
+}
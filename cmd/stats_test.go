@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestLiveStatsRatesAndTotals(t *testing.T) {
+	ls := newLiveStats("wlan0", true)
+	t0 := time.Unix(100, 0)
+	ls.add(&gonetworkmanager.InterfaceStats{RxBytes: 1000, TxBytes: 500}, t0)
+	ls.add(&gonetworkmanager.InterfaceStats{RxBytes: 3000, TxBytes: 1500}, t0.Add(2*time.Second))
+
+	if ls.rxRate != 1000 || ls.txRate != 500 {
+		t.Fatalf("unexpected rates rx=%v tx=%v", ls.rxRate, ls.txRate)
+	}
+	rx, tx := ls.sessionTotals()
+	if rx != 2000 || tx != 1000 {
+		t.Fatalf("unexpected session totals rx=%d tx=%d", rx, tx)
+	}
+	if len(ls.history) != 1 {
+		t.Fatalf("expected one history point, got %d", len(ls.history))
+	}
+
+	// A counter reset must not produce a huge bogus rate.
+	ls.add(&gonetworkmanager.InterfaceStats{RxBytes: 10, TxBytes: 10}, t0.Add(3*time.Second))
+	if ls.rxRate != 0 {
+		t.Fatalf("expected zero rate after counter reset, got %v", ls.rxRate)
+	}
+}
+
+func TestFormatBytesAndSparkline(t *testing.T) {
+	cases := map[uint64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 * 1024 * 1024: "5.0 MiB"}
+	for in, want := range cases {
+		if got := formatBytes(in); got != want {
+			t.Fatalf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+	if got := sparkline([]float64{0, 50, 100}); got != "▁▄█" {
+		t.Fatalf("unexpected sparkline %q", got)
+	}
+}
+
+func TestStatsSampleRendersPanelInInfoView(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewActiveConnectionInfo
+	m.liveStats = newLiveStats("wlan0", true)
+
+	updated, _ := m.Update(activeConnInfoMsg{details: &gonetworkmanager.DeviceIPDetail{Device: "wlan0", IPv4: "10.0.0.2"}})
+	m2 := updated.(model)
+	updated, cmd := m2.Update(statsSampleMsg{device: "wlan0", at: time.Now(), stats: &gonetworkmanager.InterfaceStats{RxBytes: 1}, bitrate: "270 Mbit/s"})
+	m2 = updated.(model)
+	if cmd == nil {
+		t.Fatalf("expected next stats tick to be scheduled")
+	}
+	v := m2.View()
+	for _, want := range []string{"10.0.0.2", "Live statistics", "270 Mbit/s"} {
+		if !strings.Contains(v, want) {
+			t.Fatalf("info view missing %q", want)
+		}
+	}
+
+	// Leaving the view stops sampling.
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m3 := updated.(model)
+	if m3.liveStats != nil {
+		t.Fatalf("expected live stats to be cleared when leaving info view")
+	}
+	if _, cmd := m3.Update(statsTickMsg{device: "wlan0"}); cmd != nil {
+		t.Fatalf("expected stale tick to be ignored")
+	}
+}
+
+func TestStatsTicksFromAnEarlierVisitAreIgnored(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewActiveConnectionInfo
+	m.startLiveStats("wlan0")
+	old := m.liveStats.gen
+	m.liveStats = nil
+	m.startLiveStats("wlan0")
+
+	if _, cmd := m.Update(statsTickMsg{device: "wlan0", gen: old}); cmd != nil {
+		t.Fatalf("a tick of the earlier loop should be ignored")
+	}
+	if _, cmd := m.Update(statsSampleMsg{device: "wlan0", gen: old, stats: &gonetworkmanager.InterfaceStats{}}); cmd != nil {
+		t.Fatalf("a sample of the earlier loop should be ignored")
+	}
+	if _, cmd := m.Update(statsTickMsg{device: "wlan0", gen: m.liveStats.gen}); cmd == nil {
+		t.Fatalf("a tick of the current loop should sample")
+	}
+}
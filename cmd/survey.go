@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Constants ---
+
+const (
+	surveyScanInterval    = 15 * time.Second
+	surveyDefaultLocation = "unlabelled"
+	surveyFilePrefix      = "nmtui-survey-"
+)
+
+// --- Types ---
+
+// surveySample is a single observation of one BSSID during a site survey.
+type surveySample struct {
+	Time     time.Time `json:"time"`
+	Location string    `json:"location"`
+	SSID     string    `json:"ssid"`
+	BSSID    string    `json:"bssid"`
+	Signal   int       `json:"signal"`
+	Channel  string    `json:"channel"`
+	Security string    `json:"security"`
+}
+
+type surveyLocationStat struct {
+	Location  string  `json:"location"`
+	AvgSignal float64 `json:"avgSignal"`
+	Samples   int     `json:"samples"`
+}
+
+// surveySSIDSummary reports where an SSID was received best and worst.
+type surveySSIDSummary struct {
+	SSID  string             `json:"ssid"`
+	Best  surveyLocationStat `json:"best"`
+	Worst surveyLocationStat `json:"worst"`
+}
+
+type surveySession struct {
+	startedAt   time.Time
+	interval    time.Duration
+	location    string
+	samples     []surveySample
+	scans       int
+	gen         int // tells this session's ticks from those of earlier ones
+	tickPending bool
+	exported    bool
+	discardArm  bool
+}
+
+type surveyTickMsg struct{ gen int }
+
+func newSurveySession(now time.Time) *surveySession {
+	return &surveySession{
+		startedAt: now,
+		interval:  surveyScanInterval,
+		location:  surveyDefaultLocation,
+	}
+}
+
+// --- Recording ---
+
+// record appends one sample per access point using the current location label.
+func (s *surveySession) record(aps []wifiAP, at time.Time) int {
+	added := 0
+	for _, ap := range aps {
+		if ap.WifiAccessPoint == nil {
+			continue
+		}
+		ssid := ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSSID]
+		if ssid == "--" {
+			ssid = ""
+		}
+		security := ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSecurity]
+		if security == "" || security == "--" {
+			security = "Open"
+		}
+		signal, _ := strconv.Atoi(ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiSignal])
+		s.samples = append(s.samples, surveySample{
+			Time:     at,
+			Location: s.location,
+			SSID:     ssid,
+			BSSID:    ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiBSSID],
+			Signal:   signal,
+			Channel:  ap.WifiAccessPoint[gonetworkmanager.NmcliFieldWifiChannel],
+			Security: security,
+		})
+		added++
+	}
+	s.scans++
+	s.exported = false
+	return added
+}
+
+// summary computes, per SSID, the location with the best and worst average signal.
+// Hidden networks are skipped since they cannot be told apart by name.
+func (s *surveySession) summary() []surveySSIDSummary {
+	type acc struct {
+		total, count int
+	}
+	bySSID := make(map[string]map[string]*acc)
+	for _, smp := range s.samples {
+		if smp.SSID == "" {
+			continue
+		}
+		locs, ok := bySSID[smp.SSID]
+		if !ok {
+			locs = make(map[string]*acc)
+			bySSID[smp.SSID] = locs
+		}
+		a, ok := locs[smp.Location]
+		if !ok {
+			a = &acc{}
+			locs[smp.Location] = a
+		}
+		a.total += smp.Signal
+		a.count++
+	}
+
+	var out []surveySSIDSummary
+	for ssid, locs := range bySSID {
+		var stats []surveyLocationStat
+		for loc, a := range locs {
+			stats = append(stats, surveyLocationStat{Location: loc, AvgSignal: float64(a.total) / float64(a.count), Samples: a.count})
+		}
+		sort.Slice(stats, func(i, j int) bool {
+			if stats[i].AvgSignal != stats[j].AvgSignal {
+				return stats[i].AvgSignal > stats[j].AvgSignal
+			}
+			return stats[i].Location < stats[j].Location
+		})
+		out = append(out, surveySSIDSummary{SSID: ssid, Best: stats[0], Worst: stats[len(stats)-1]})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i].SSID) < strings.ToLower(out[j].SSID)
+	})
+	return out
+}
+
+// --- Export ---
+
+func (s *surveySession) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "location", "ssid", "bssid", "signal", "channel", "security"}); err != nil {
+		return err
+	}
+	for _, smp := range s.samples {
+		row := []string{smp.Time.Format(time.RFC3339), smp.Location, smp.SSID, smp.BSSID, strconv.Itoa(smp.Signal), smp.Channel, smp.Security}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *surveySession) writeJSON(w io.Writer) error {
+	doc := struct {
+		StartedAt       time.Time           `json:"startedAt"`
+		IntervalSeconds int                 `json:"intervalSeconds"`
+		Scans           int                 `json:"scans"`
+		Samples         []surveySample      `json:"samples"`
+		Summary         []surveySSIDSummary `json:"summary"`
+	}{
+		StartedAt:       s.startedAt,
+		IntervalSeconds: int(s.interval / time.Second),
+		Scans:           s.scans,
+		Samples:         s.samples,
+		Summary:         s.summary(),
+	}
+	if doc.Samples == nil {
+		doc.Samples = []surveySample{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// export writes the session as CSV and JSON into dir and returns both paths.
+func (s *surveySession) export(dir string) (string, string, error) {
+	base := filepath.Join(dir, surveyFilePrefix+s.startedAt.Format("20060102-150405"))
+	csvPath, jsonPath := base+".csv", base+".json"
+
+	if err := writeSurveyFile(csvPath, s.writeCSV); err != nil {
+		return "", "", fmt.Errorf("writing survey CSV: %w", err)
+	}
+	if err := writeSurveyFile(jsonPath, s.writeJSON); err != nil {
+		return "", "", fmt.Errorf("writing survey JSON: %w", err)
+	}
+	s.exported = true
+	log.Printf("Survey: exported %d samples to %s and %s", len(s.samples), csvPath, jsonPath)
+	return csvPath, jsonPath, nil
+}
+
+func writeSurveyFile(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// --- Commands ---
+
+func surveyTickCmd(interval time.Duration, gen int) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg { return surveyTickMsg{gen: gen} })
+}
+
+// --- Model integration ---
+
+func newSurveyLocationInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "e.g. 2nd floor, room 204"
+	ti.CharLimit = 64
+	ti.Prompt = passwordPromptStyle.Render("📍 Location: ")
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(ansAccentColor)
+	return ti
+}
+
+func (m *model) startSurvey() []tea.Cmd {
+	m.surveyGen++
+	m.survey = newSurveySession(time.Now())
+	m.survey.gen = m.surveyGen
+	m.state = viewSurvey
+	m.clearStatus()
+	m.surveyLocationInput.SetValue("")
+	m.surveyLocationInput.Focus()
+	m.isScanning = true
+	return []tea.Cmd{textinput.Blink, fetchWifiNetworksCmd(true), m.spinner.Tick}
+}
+
+// recordSurveyScan is called for every successful scan while a survey is running,
+// and keeps the periodic rescan going.
+func (m *model) recordSurveyScan(aps []wifiAP) tea.Cmd {
+	if m.survey == nil {
+		return nil
+	}
+	n := m.survey.record(aps, time.Now())
+	log.Printf("Survey: recorded %d samples at %q", n, m.survey.location)
+	return m.scheduleSurveyTick()
+}
+
+func (m *model) scheduleSurveyTick() tea.Cmd {
+	if m.survey == nil || m.survey.tickPending {
+		return nil
+	}
+	m.survey.tickPending = true
+	return surveyTickCmd(m.survey.interval, m.survey.gen)
+}
+
+// handleSurveyTick rescans for the running survey; a tick left over from an
+// earlier survey is dropped so it cannot start a second scan loop.
+func (m *model) handleSurveyTick(msg surveyTickMsg) []tea.Cmd {
+	if m.survey == nil || m.survey.gen != msg.gen {
+		return nil
+	}
+	m.survey.tickPending = false
+	if m.isScanning {
+		return []tea.Cmd{m.scheduleSurveyTick()}
+	}
+	m.isScanning = true
+	return []tea.Cmd{fetchWifiNetworksCmd(true), m.spinner.Tick}
+}
+
+func (m *model) handleSurveyKeys(msg tea.KeyMsg) []tea.Cmd {
+	if m.survey == nil {
+		m.state = viewNetworksList
+		return nil
+	}
+	if m.surveyLocationInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			if loc := strings.TrimSpace(m.surveyLocationInput.Value()); loc != "" {
+				m.survey.location = loc
+			}
+			m.surveyLocationInput.Blur()
+			m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("Recording at %q.", m.survey.location))
+			return nil
+		case "esc":
+			m.surveyLocationInput.Blur()
+			return nil
+		}
+		var cmd tea.Cmd
+		m.surveyLocationInput, cmd = m.surveyLocationInput.Update(msg)
+		return []tea.Cmd{cmd}
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		if !m.survey.exported && len(m.survey.samples) > 0 && !m.survey.discardArm {
+			m.survey.discardArm = true
+			m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("Survey not exported. Press %s again to discard.", m.keys.Back.Help().Key))
+			return nil
+		}
+		m.survey = nil
+		m.state = viewNetworksList
+		m.clearStatus()
+		return nil
+	case key.Matches(msg, m.keys.Connect):
+		m.survey.discardArm = false
+		m.surveyLocationInput.SetValue(m.survey.location)
+		m.surveyLocationInput.CursorEnd()
+		m.surveyLocationInput.Focus()
+		return []tea.Cmd{textinput.Blink}
+	case key.Matches(msg, m.keys.Refresh):
+		m.survey.discardArm = false
+		if m.isScanning {
+			return nil
+		}
+		m.isScanning = true
+		return []tea.Cmd{fetchWifiNetworksCmd(true), m.spinner.Tick}
+	case key.Matches(msg, m.keys.Export):
+		m.survey.discardArm = false
+		dir, err := os.Getwd()
+		if err != nil {
+			dir = "."
+		}
+		csvPath, jsonPath, err := m.survey.export(dir)
+		if err != nil {
+			m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Export failed: %v", err))
+			return nil
+		}
+		m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Exported %s and %s", filepath.Base(csvPath), filepath.Base(jsonPath)))
+	}
+	return nil
+}
+
+func (m model) surveyView(width, height int) string {
+	if m.survey == nil {
+		return ""
+	}
+	s := m.survey
+	label := lipgloss.NewStyle().Foreground(ansFaintTextColor)
+	var lines []string
+	lines = append(lines, titleStyle.Render("Site Survey"))
+	lines = append(lines, fmt.Sprintf("%s %s", label.Render("Location:"), lipgloss.NewStyle().Foreground(ansAccentColor).Render(s.location)))
+	lines = append(lines, fmt.Sprintf("%s %d  %s %d  %s every %s",
+		label.Render("Scans:"), s.scans, label.Render("Samples:"), len(s.samples), label.Render("Rescan:"), s.interval))
+	if m.surveyLocationInput.Focused() {
+		lines = append(lines, "", m.surveyLocationInput.View())
+	}
+
+	lines = append(lines, "")
+	summary := s.summary()
+	if len(summary) == 0 {
+		lines = append(lines, label.Render("No samples yet. Waiting for the first scan..."))
+	} else {
+		ssidW := 24
+		header := fmt.Sprintf("%-*s  %-22s  %-22s", ssidW, "SSID", "Best location", "Worst location")
+		lines = append(lines, label.Render(header))
+		maxRows := height - len(lines) - 6
+		if maxRows < 1 {
+			maxRows = 1
+		}
+		for i, sum := range summary {
+			if i >= maxRows {
+				lines = append(lines, label.Render(fmt.Sprintf("… %d more SSIDs (see export)", len(summary)-i)))
+				break
+			}
+			ssid := truncateRunes(sum.SSID, ssidW)
+			best := fmt.Sprintf("%s (%.0f%%)", sum.Best.Location, sum.Best.AvgSignal)
+			worst := fmt.Sprintf("%s (%.0f%%)", sum.Worst.Location, sum.Worst.AvgSignal)
+			lines = append(lines, fmt.Sprintf("%-*s  %-22s  %-22s", ssidW, ssid, best, worst))
+		}
+	}
+
+	hint := label.Render(fmt.Sprintf("%s: set location  %s: scan now  %s: export CSV/JSON  %s: stop",
+		m.keys.Connect.Help().Key, m.keys.Refresh.Help().Key, m.keys.Export.Help().Key, m.keys.Back.Help().Key))
+	lines = append(lines, "", hint)
+	if m.connectionStatusMsg != "" {
+		lines = append(lines, m.connectionStatusMsg)
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(infoBoxStyle.Render(strings.Join(lines, "\n")))
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n || n < 1 {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func surveyAP(ssid, bssid, signal, channel string) wifiAP {
+	return wifiAP{WifiAccessPoint: gonetworkmanager.WifiAccessPoint{
+		gonetworkmanager.NmcliFieldWifiSSID:     ssid,
+		gonetworkmanager.NmcliFieldWifiBSSID:    bssid,
+		gonetworkmanager.NmcliFieldWifiSignal:   signal,
+		gonetworkmanager.NmcliFieldWifiChannel:  channel,
+		gonetworkmanager.NmcliFieldWifiSecurity: "WPA2",
+	}}
+}
+
+func TestSurveyRecordAndSummary(t *testing.T) {
+	s := newSurveySession(time.Unix(0, 0))
+	s.location = "lobby"
+	s.record([]wifiAP{surveyAP("Office", "aa:aa", "80", "6"), surveyAP("--", "cc:cc", "30", "11")}, time.Unix(10, 0))
+	s.location = "basement"
+	s.record([]wifiAP{surveyAP("Office", "aa:aa", "20", "6"), surveyAP("Office", "bb:bb", "40", "36")}, time.Unix(20, 0))
+
+	if len(s.samples) != 4 {
+		t.Fatalf("expected 4 samples, got %d", len(s.samples))
+	}
+	if s.samples[1].SSID != "" || s.samples[1].Security != "WPA2" {
+		t.Fatalf("hidden SSID should be normalized, got %+v", s.samples[1])
+	}
+
+	sum := s.summary()
+	if len(sum) != 1 {
+		t.Fatalf("expected 1 SSID summary (hidden skipped), got %d", len(sum))
+	}
+	if sum[0].Best.Location != "lobby" || sum[0].Worst.Location != "basement" {
+		t.Fatalf("unexpected best/worst: %+v", sum[0])
+	}
+	if sum[0].Worst.AvgSignal != 30 {
+		t.Fatalf("expected basement average 30, got %v", sum[0].Worst.AvgSignal)
+	}
+}
+
+func TestSurveyExportWritesCSVAndJSON(t *testing.T) {
+	s := newSurveySession(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	s.location = "room, 204"
+	s.record([]wifiAP{surveyAP("Lab", "aa:bb", "55", "1")}, time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC))
+
+	dir := t.TempDir()
+	csvPath, jsonPath, err := s.export(dir)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if filepath.Base(csvPath) != "nmtui-survey-20260102-030405.csv" {
+		t.Fatalf("unexpected csv name %q", csvPath)
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatalf("open csv: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 2 || rows[1][1] != "room, 204" || rows[1][5] != "1" {
+		t.Fatalf("unexpected csv rows: %v", rows)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	var doc struct {
+		Samples []surveySample      `json:"samples"`
+		Summary []surveySSIDSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(doc.Samples) != 1 || len(doc.Summary) != 1 || doc.Summary[0].Best.Location != "room, 204" {
+		t.Fatalf("unexpected json document: %s", data)
+	}
+	if !s.exported {
+		t.Fatalf("expected session to be marked exported")
+	}
+}
+
+func TestSurveyWriteJSONEmptySession(t *testing.T) {
+	var b bytes.Buffer
+	if err := newSurveySession(time.Now()).writeJSON(&b); err != nil {
+		t.Fatalf("writeJSON: %v", err)
+	}
+	if !strings.Contains(b.String(), `"samples": []`) {
+		t.Fatalf("expected empty samples array, got %s", b.String())
+	}
+}
+
+func TestSurveyKeyStartsSurveyAndLabelsSamples(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewNetworksList
+	m.isLoading = false
+	m.wifiEnabled = true
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m2 := updated.(model)
+	if m2.state != viewSurvey || m2.survey == nil {
+		t.Fatalf("expected survey view, got %v", m2.state)
+	}
+	if !m2.isTextInputActive() {
+		t.Fatalf("expected location input to be active")
+	}
+
+	for _, r := range "hall" {
+		updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m2 = updated.(model)
+	}
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 = updated.(model)
+	if m2.survey.location != "hall" {
+		t.Fatalf("expected location hall, got %q", m2.survey.location)
+	}
+
+	updated, cmd := m2.Update(wifiListLoadedMsg{allAps: []wifiAP{surveyAP("Cafe", "aa", "70", "6")}})
+	m2 = updated.(model)
+	if len(m2.survey.samples) != 1 || m2.survey.samples[0].Location != "hall" {
+		t.Fatalf("expected one sample at hall, got %+v", m2.survey.samples)
+	}
+	if cmd == nil || !m2.survey.tickPending {
+		t.Fatalf("expected next survey scan to be scheduled")
+	}
+	if !strings.Contains(m2.View(), "Site Survey") {
+		t.Fatalf("survey view not rendered")
+	}
+}
+
+func TestSurveyTicksFromAnEarlierSessionAreIgnored(t *testing.T) {
+	m := windowedModel(t)
+	m.isLoading = false
+	m.startSurvey()
+	old := m.survey.gen
+	m.survey = nil
+	m.startSurvey()
+	m.isScanning = false
+	m.survey.tickPending = true
+
+	updated, cmd := m.Update(surveyTickMsg{gen: old})
+	m = updated.(model)
+	if cmd != nil || m.isScanning || !m.survey.tickPending {
+		t.Fatalf("a tick of the earlier survey must not scan or clear the pending tick")
+	}
+	updated, cmd = m.Update(surveyTickMsg{gen: m.survey.gen})
+	m = updated.(model)
+	if cmd == nil || !m.isScanning || m.survey.tickPending {
+		t.Fatalf("the current survey's tick should start a scan")
+	}
+}
+
+func TestSurveyEscRequiresConfirmWhenUnexported(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewSurvey
+	m.survey = newSurveySession(time.Now())
+	m.survey.record([]wifiAP{surveyAP("Cafe", "aa", "70", "6")}, time.Now())
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m2 := updated.(model)
+	if m2.state != viewSurvey {
+		t.Fatalf("first esc should keep survey open, got %v", m2.state)
+	}
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m3 := updated.(model)
+	if m3.state != viewNetworksList || m3.survey != nil {
+		t.Fatalf("second esc should discard survey, got state %v", m3.state)
+	}
+}
@@ -0,0 +1,120 @@
+// nmtui/cmd/theme.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const defaultThemeName = "default"
+
+// themeRoles are the color roles a theme defines and config.toml's [colors]
+// table can override, in display order.
+var themeRoles = []string{"primary", "secondary", "accent", "success", "error", "warning", "faint", "text", "border"}
+
+// theme maps every role to a color: an ANSI index ("0"-"255"), a hex
+// color ("#rrggbb") or "" for the terminal's default (no color).
+type theme map[string]string
+
+var themes = map[string]theme{
+	"default": {
+		"primary": "5", "secondary": "4", "accent": "6", "success": "2", "error": "1",
+		"warning": "3", "faint": "8", "text": "7", "border": "62",
+	},
+	// Darker tones that stay readable on a light background.
+	"light": {
+		"primary": "90", "secondary": "25", "accent": "30", "success": "28", "error": "160",
+		"warning": "130", "faint": "243", "text": "235", "border": "61",
+	},
+	// Bright colors only, and no dim text.
+	"high-contrast": {
+		"primary": "13", "secondary": "14", "accent": "14", "success": "10", "error": "9",
+		"warning": "11", "faint": "15", "text": "15", "border": "15",
+	},
+	// The Okabe-Ito palette: success and error differ in hue and lightness
+	// (blue vs. vermilion) for red-green color vision deficiencies.
+	"colorblind-safe": {
+		"primary": "#CC79A7", "secondary": "#56B4E9", "accent": "#56B4E9", "success": "#0072B2", "error": "#D55E00",
+		"warning": "#E69F00", "faint": "#999999", "text": "#F0F0F0", "border": "#56B4E9",
+	},
+	// No colors at all; emphasis comes from bold, italics and the ▸ cursor.
+	"monochrome": {
+		"primary": "", "secondary": "", "accent": "", "success": "", "error": "",
+		"warning": "", "faint": "", "text": "", "border": "",
+	},
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseThemeColor validates a color written in config.toml. "none" is the
+// terminal's default color.
+func parseThemeColor(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if strings.EqualFold(v, "none") {
+		return "", nil
+	}
+	if hexColorRe.MatchString(v) {
+		return v, nil
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 255 {
+		return v, nil
+	}
+	return "", fmt.Errorf("%q is not an ANSI color 0-255, #rrggbb or none", v)
+}
+
+func isThemeRole(role string) bool {
+	for _, r := range themeRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTheme makes a built-in theme, with per-role overrides, the active
+// palette and rebuilds the styles derived from it.
+func applyTheme(name string, overrides map[string]string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (use %s)", name, strings.Join(themeNames(), ", "))
+	}
+	color := func(role string) lipgloss.TerminalColor {
+		v := t[role]
+		if o, ok := overrides[role]; ok {
+			v = o
+		}
+		if v == "" {
+			return lipgloss.NoColor{}
+		}
+		return lipgloss.Color(v)
+	}
+	ansPrimaryColor = color("primary")
+	ansSecondaryColor = color("secondary")
+	ansAccentColor = color("accent")
+	ansSuccessColor = color("success")
+	ansErrorColor = color("error")
+	ansWarningColor = color("warning")
+	ansFaintTextColor = color("faint")
+	ansTextColor = color("text")
+	ansBorderColor = color("border")
+	buildStyles()
+	return nil
+}
+
+func init() {
+	_ = applyTheme(defaultThemeName, nil)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestApplyTheme(t *testing.T) {
+	t.Cleanup(func() { _ = applyTheme(defaultThemeName, nil) })
+
+	if err := applyTheme("monochrome", map[string]string{"error": "9"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ansSuccessColor.(lipgloss.NoColor); !ok {
+		t.Fatalf("monochrome should not color success, got %v", ansSuccessColor)
+	}
+	if ansErrorColor != lipgloss.Color("9") {
+		t.Fatalf("the override should apply, got %v", ansErrorColor)
+	}
+	if got := errorStyle.GetForeground(); got != lipgloss.Color("9") {
+		t.Fatalf("styles should be rebuilt from the palette, got %v", got)
+	}
+
+	if err := applyTheme("solarized", nil); err == nil || !strings.Contains(err.Error(), "high-contrast") {
+		t.Fatalf("expected an unknown theme error listing the themes, got %v", err)
+	}
+	for _, bad := range []string{"256", "#12345", "red"} {
+		if _, err := parseThemeColor(bad); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestThemeConfig(t *testing.T) {
+	writeAppConfig(t, "theme = \"light\"\n\n[colors]\naccent = \"#00ff00\"\nfaint = \"none\"\n")
+	t.Setenv("NO_COLOR", "1")
+	configFlags = []string{"colors.success=10"}
+
+	c, err := loadAppConfig()
+	if err != nil {
+		t.Fatalf("loadAppConfig: %v", err)
+	}
+	if c.Theme != "light" {
+		t.Fatalf("NO_COLOR must yield to a configured theme, got %q (%s)", c.Theme, c.sources["theme"])
+	}
+	if c.Colors["accent"] != "#00ff00" || c.Colors["faint"] != "" || c.Colors["success"] != "10" {
+		t.Fatalf("unexpected overrides %v", c.Colors)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runConfigCLI([]string{"show"}, &out, &errOut); code != 0 {
+		t.Fatalf("config show failed: %s", errOut.String())
+	}
+	for _, want := range []string{`theme = "light"  # file`, "[colors]", `accent = "#00ff00"  # file`, `faint = "none"  # file`, `success = "10"  # flag`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("config show lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	writeAppConfig(t, "")
+	if c, _ := loadAppConfig(); c.Theme != "monochrome" || c.sources["theme"] != "env NO_COLOR" {
+		t.Fatalf("NO_COLOR should select monochrome, got %q", c.Theme)
+	}
+
+	writeAppConfig(t, "[colors]\nlink = \"4\"\n")
+	if _, err := loadAppConfig(); err == nil || !strings.Contains(err.Error(), `unknown role "link"`) {
+		t.Fatalf("expected an unknown role error, got %v", err)
+	}
+}
@@ -117,12 +117,11 @@ func isPrerelease(tag string) bool {
 }
 
 func getAllowPrereleaseConfig() bool {
-	return os.Getenv("NMTUI_UPDATE_PRERELEASE") == "1"
+	return currentConfig().UpdatePrerelease
 }
 
 func getKeepBackupConfig() bool {
-	v := os.Getenv("NMTUI_UPDATE_KEEP_BACKUP")
-	return v != "0" && strings.ToLower(v) != "false"
+	return currentConfig().UpdateKeepBackup
 }
 
 func fetchLatestStableOrPrerelease(ctx context.Context, allowPrerelease bool) (*ghRelease, error) {
@@ -534,8 +533,8 @@ func saveUpdateCache(c *updateCheckCache) error {
 // checkForUpdate checks if a newer version is available, using the 24h cache.
 // Returns nil result if disabled via env var or on error.
 func checkForUpdate() (*updateCheckResult, error) {
-	if os.Getenv("NMTUI_NO_UPDATE_CHECK") == "1" {
-		log.Printf("Update: check disabled via NMTUI_NO_UPDATE_CHECK")
+	if !currentConfig().UpdateCheck {
+		log.Printf("Update: check disabled by update_check / NMTUI_NO_UPDATE_CHECK")
 		return nil, nil
 	}
 
@@ -0,0 +1,489 @@
+// nmtui/cmd/usage.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"nmtui/gonetworkmanager"
+)
+
+const (
+	usageFileName       = "usage.json"
+	usageSampleInterval = 30 * time.Second
+	// usageMaxGap bounds how old a counter baseline may be and still be
+	// attributed to the same profile; across longer gaps (the app was closed,
+	// the laptop slept) the profile may have changed in between, so the
+	// interval is dropped rather than guessed.
+	usageMaxGap = 10 * time.Minute
+	usageMonth  = "2006-01"
+)
+
+// usageCounters is the traffic of one profile in one calendar month.
+type usageCounters struct {
+	Rx uint64 `json:"rx"`
+	Tx uint64 `json:"tx"`
+}
+
+func (c usageCounters) total() uint64 { return c.Rx + c.Tx }
+
+type profileUsage struct {
+	Name   string                    `json:"name"`
+	Quota  uint64                    `json:"quota,omitempty"` // bytes per month, 0 = none
+	Months map[string]*usageCounters `json:"months,omitempty"`
+}
+
+func (pu *profileUsage) month(t time.Time) usageCounters {
+	if c := pu.Months[t.Format(usageMonth)]; c != nil {
+		return *c
+	}
+	return usageCounters{}
+}
+
+// usageBaseline is the last counter sample seen on a device. It lives in the
+// store rather than in memory so the TUI and `watch` can sample the same
+// interface without counting its traffic twice.
+type usageBaseline struct {
+	UUID string    `json:"uuid"`
+	Rx   uint64    `json:"rx"`
+	Tx   uint64    `json:"tx"`
+	At   time.Time `json:"at"`
+}
+
+type usageStore struct {
+	Profiles map[string]*profileUsage  `json:"profiles"` // by UUID
+	Devices  map[string]*usageBaseline `json:"devices,omitempty"`
+}
+
+func (s *usageStore) profile(uuid, name string) *profileUsage {
+	if s.Profiles == nil {
+		s.Profiles = make(map[string]*profileUsage)
+	}
+	pu := s.Profiles[uuid]
+	if pu == nil {
+		pu = &profileUsage{}
+		s.Profiles[uuid] = pu
+	}
+	if name != "" {
+		pu.Name = name
+	}
+	return pu
+}
+
+// record attributes the traffic since the device's previous sample to the
+// profile and returns the amounts added.
+func (s *usageStore) record(uuid, name, device string, st gonetworkmanager.InterfaceStats, now time.Time) (rx, tx uint64) {
+	if s.Devices == nil {
+		s.Devices = make(map[string]*usageBaseline)
+	}
+	base := s.Devices[device]
+	s.Devices[device] = &usageBaseline{UUID: uuid, Rx: st.RxBytes, Tx: st.TxBytes, At: now}
+	if base == nil || base.UUID != uuid || now.Sub(base.At) > usageMaxGap || now.Before(base.At) {
+		return 0, 0
+	}
+	// Counters restart when the interface is recreated; skip that interval.
+	if st.RxBytes < base.Rx || st.TxBytes < base.Tx {
+		return 0, 0
+	}
+	rx, tx = counterDelta(base.Rx, st.RxBytes), counterDelta(base.Tx, st.TxBytes)
+	pu := s.profile(uuid, name)
+	if pu.Months == nil {
+		pu.Months = make(map[string]*usageCounters)
+	}
+	key := now.Format(usageMonth)
+	c := pu.Months[key]
+	if c == nil {
+		c = &usageCounters{}
+		pu.Months[key] = c
+	}
+	c.Rx += rx
+	c.Tx += tx
+	return rx, tx
+}
+
+type quotaWarning struct {
+	UUID, Name  string
+	Used, Quota uint64
+}
+
+func (q quotaWarning) String() string {
+	return fmt.Sprintf("%s: %s of %s this month", q.Name, formatBytes(q.Used), formatBytes(q.Quota))
+}
+
+// quotaWarnings lists every profile over its quota this month, largest
+// overrun first.
+func (s *usageStore) quotaWarnings(now time.Time) []quotaWarning {
+	var out []quotaWarning
+	for uuid, pu := range s.Profiles {
+		if pu.Quota == 0 {
+			continue
+		}
+		if used := pu.month(now).total(); used > pu.Quota {
+			name := pu.Name
+			if name == "" {
+				name = uuid
+			}
+			out = append(out, quotaWarning{UUID: uuid, Name: name, Used: used, Quota: pu.Quota})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return float64(out[i].Used)/float64(out[i].Quota) > float64(out[j].Used)/float64(out[j].Quota)
+	})
+	return out
+}
+
+// --- Persistence ---
+
+// usageMu serialises read-modify-write of the usage file.
+var usageMu sync.Mutex
+
+func usagePath() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, usageFileName), nil
+}
+
+func loadUsage() (*usageStore, error) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	return loadUsageLocked()
+}
+
+func loadUsageLocked() (*usageStore, error) {
+	s := &usageStore{}
+	path, err := usagePath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return &usageStore{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func saveUsageLocked(s *usageStore) error {
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// updateUsage loads the store, applies fn and writes it back. An unreadable
+// store is an error, whatever the cause: writing over it would lose every
+// month of usage and every quota.
+func updateUsage(fn func(*usageStore)) (*usageStore, error) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	s, err := loadUsageLocked()
+	if err != nil {
+		return nil, err
+	}
+	fn(s)
+	return s, saveUsageLocked(s)
+}
+
+func setUsageQuota(uuid, name string, quota uint64) error {
+	_, err := updateUsage(func(s *usageStore) {
+		s.profile(uuid, name).Quota = quota
+	})
+	return err
+}
+
+// sampleUsage reads the device counters and records them against the
+// profile. It returns the store as written.
+func sampleUsage(uuid, name, device string, now time.Time) (*usageStore, error) {
+	st, err := gonetworkmanager.ReadInterfaceStats(device)
+	if err != nil {
+		return nil, err
+	}
+	return updateUsage(func(s *usageStore) {
+		s.record(uuid, name, device, *st, now)
+	})
+}
+
+// parseByteSize accepts sizes like "500M", "5GB" or "1.5 GiB". Units are
+// binary either way, matching how sizes are displayed.
+func parseByteSize(s string) (uint64, error) {
+	raw := strings.ToUpper(strings.TrimSpace(s))
+	num := strings.TrimRight(raw, "KMGTIB ")
+	unit := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(raw[len(num):]), "B"), "I")
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || !(v >= 0 && v < 1<<50) {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500M, 5G)", s)
+	}
+	exp := 0
+	if unit != "" {
+		exp = strings.Index("KMGT", unit) + 1
+		if len(unit) > 1 || exp == 0 {
+			return 0, fmt.Errorf("invalid size unit in %q (use K, M, G or T)", s)
+		}
+	}
+	for ; exp > 0; exp-- {
+		v *= 1024
+	}
+	return uint64(v), nil
+}
+
+// --- CLI ---
+
+func runUsageCLI(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch fs.Arg(0) {
+	case "", "list":
+		s, err := loadUsage()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		printUsageReport(stdout, s, time.Now())
+		return 0
+	case "quota":
+		if fs.NArg() != 3 {
+			fmt.Fprintf(stderr, "Usage: %s usage quota PROFILE SIZE|off\n", effectiveAppName())
+			return 2
+		}
+		var quota uint64
+		if v := fs.Arg(2); v != "off" && v != "none" && v != "0" {
+			var err error
+			if quota, err = parseByteSize(v); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 2
+			}
+		}
+		p, err := gonetworkmanager.GetConnectionProfileByID(fs.Arg(1))
+		if err == nil && p == nil {
+			err = fmt.Errorf("profile %s not found", fs.Arg(1))
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		uuid, name := profileIdentity(p)
+		if err := setUsageQuota(uuid, name, quota); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		if quota == 0 {
+			fmt.Fprintf(stdout, "Removed the data quota of %s.\n", name)
+		} else {
+			fmt.Fprintf(stdout, "%s: warn above %s per month.\n", name, formatBytes(quota))
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown usage command: %s\n", fs.Arg(0))
+		return 2
+	}
+}
+
+func printUsageReport(w io.Writer, s *usageStore, now time.Time) {
+	type row struct {
+		name string
+		pu   *profileUsage
+	}
+	var rows []row
+	for uuid, pu := range s.Profiles {
+		if pu.month(now).total() == 0 && pu.Quota == 0 {
+			continue
+		}
+		name := pu.Name
+		if name == "" {
+			name = uuid
+		}
+		rows = append(rows, row{name, pu})
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No data usage recorded this month.")
+		return
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].pu.month(now).total() > rows[j].pu.month(now).total() })
+	fmt.Fprintf(w, "Data usage for %s:\n", now.Format("January 2006"))
+	for _, r := range rows {
+		c := r.pu.month(now)
+		line := fmt.Sprintf("  %-24s %10s  (↓ %s, ↑ %s)", truncateRunes(r.name, 24), formatBytes(c.total()), formatBytes(c.Rx), formatBytes(c.Tx))
+		if r.pu.Quota > 0 {
+			line += "  quota " + formatBytes(r.pu.Quota)
+			if c.total() > r.pu.Quota {
+				line += "  ✗ exceeded"
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// --- TUI ---
+
+type usageTickMsg struct{}
+
+type usageSampledMsg struct {
+	warnings []quotaWarning
+	err      error
+}
+
+func usageTickCmd() tea.Cmd {
+	return tea.Tick(usageSampleInterval, func(time.Time) tea.Msg { return usageTickMsg{} })
+}
+
+// usageSampleCmd records the active profile's traffic, if any, and reports
+// the quota warnings that result.
+func usageSampleCmd(uuid, name, device string) tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		var s *usageStore
+		var err error
+		if uuid != "" && device != "" {
+			s, err = sampleUsage(uuid, name, device, now)
+		} else {
+			s, err = loadUsage()
+		}
+		if err != nil {
+			log.Printf("Usage: %v", err)
+		}
+		if s == nil {
+			return usageSampledMsg{err: err}
+		}
+		return usageSampledMsg{warnings: s.quotaWarnings(now), err: err}
+	}
+}
+
+func (m *model) sampleUsageCmd() tea.Cmd {
+	if m.activeWifiConnection == nil || m.activeWifiDevice == "" {
+		return usageSampleCmd("", "", "")
+	}
+	p := *m.activeWifiConnection
+	return usageSampleCmd(p[gonetworkmanager.NmcliFieldConnectionUUID], p[gonetworkmanager.NmcliFieldConnectionName], m.activeWifiDevice)
+}
+
+// withUsageQuota runs a profile save and, if it succeeds, stores the profile's
+// data quota. The quota lives in the usage store, not in NetworkManager.
+func withUsageQuota(save tea.Cmd, profileID string, quota uint64) tea.Cmd {
+	return func() tea.Msg {
+		msg := save()
+		res, ok := msg.(profileSaveResultMsg)
+		if !ok || !res.success {
+			return msg
+		}
+		ref := profileID
+		if ref == "" {
+			ref = res.profileRef
+		}
+		p, err := gonetworkmanager.GetConnectionProfileByID(ref)
+		if err == nil && p == nil {
+			err = fmt.Errorf("profile %s not found", ref)
+		}
+		if err == nil {
+			uuid, name := profileIdentity(p)
+			err = setUsageQuota(uuid, name, quota)
+		}
+		if err != nil {
+			log.Printf("Usage: could not save the quota of %s: %v", ref, err)
+		}
+		return msg
+	}
+}
+
+// profileIdentity returns the UUID and name of a single profile as read by
+// GetConnectionProfileByID, whose keys are setting names rather than the
+// list columns.
+func profileIdentity(p gonetworkmanager.ConnectionProfile) (uuid, name string) {
+	uuid, name = p["connection.uuid"], p["connection.id"]
+	if uuid == "" {
+		uuid = p[gonetworkmanager.NmcliFieldConnectionUUID]
+	}
+	if name == "" {
+		name = p[gonetworkmanager.NmcliFieldConnectionName]
+	}
+	return uuid, name
+}
+
+// profileUsageDetails returns the lines the profile details view shows about
+// data usage.
+func profileUsageDetails(pu *profileUsage, now time.Time) []string {
+	if pu == nil {
+		return []string{"Data this month: none recorded"}
+	}
+	c := pu.month(now)
+	line := fmt.Sprintf("Data this month: %s (↓ %s, ↑ %s)", formatBytes(c.total()), formatBytes(c.Rx), formatBytes(c.Tx))
+	if pu.Quota > 0 {
+		line += fmt.Sprintf(", quota %s", formatBytes(pu.Quota))
+		if c.total() > pu.Quota {
+			line += " — exceeded"
+		}
+	}
+	return []string{line}
+}
+
+func (m model) quotaBannerView(width int) string {
+	if len(m.quotaWarnings) == 0 {
+		return ""
+	}
+	text := "⚠ Data quota exceeded — " + m.quotaWarnings[0].String()
+	if n := len(m.quotaWarnings) - 1; n > 0 {
+		text += fmt.Sprintf(" (+%d more)", n)
+	}
+	style := lipgloss.NewStyle().Foreground(ansErrorColor).Bold(true).MaxWidth(width)
+	return style.Render(truncateRunes(text, width))
+}
+
+// meteredLabel shows connection.metered as the profile form takes it: yes,
+// no, or auto when NetworkManager decides ("unknown" or not read).
+func meteredLabel(raw string) string {
+	switch v := strings.TrimSpace(raw); v {
+	case "yes", "no":
+		return v
+	default:
+		return "auto"
+	}
+}
+
+// meteredProfiles returns the UUIDs of the profiles marked metered. Errors are
+// logged and yield no marks; the lists are still usable without them.
+func meteredProfiles(uuids []string) map[string]bool {
+	props, err := gonetworkmanager.GetProfileProperties(uuids, gonetworkmanager.NmcliFieldConnectionMetered)
+	if err != nil {
+		log.Printf("Cmd: Error reading metered state: %v", err)
+		return nil
+	}
+	metered := make(map[string]bool)
+	for uuid, values := range props {
+		if values[gonetworkmanager.NmcliFieldConnectionMetered] == "yes" {
+			metered[uuid] = true
+		}
+	}
+	return metered
+}
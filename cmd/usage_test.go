@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+func TestUsageRecordAttributesTrafficPerDevice(t *testing.T) {
+	s := &usageStore{}
+	t0 := time.Date(2026, 3, 31, 23, 59, 0, 0, time.Local)
+	sample := func(rx, tx uint64) gonetworkmanager.InterfaceStats {
+		return gonetworkmanager.InterfaceStats{RxBytes: rx, TxBytes: tx}
+	}
+
+	if rx, tx := s.record("u-phone", "Phone", "wlan0", sample(1000, 100), t0); rx != 0 || tx != 0 {
+		t.Fatalf("the first sample is only a baseline")
+	}
+	s.record("u-phone", "Phone", "wlan0", sample(3000, 600), t0.Add(30*time.Second))
+	if got := s.Profiles["u-phone"].month(t0); got != (usageCounters{Rx: 2000, Tx: 500}) {
+		t.Fatalf("unexpected March usage %+v", got)
+	}
+
+	// Another profile on the same device starts from its own baseline.
+	if rx, _ := s.record("u-home", "Home", "wlan0", sample(9000, 900), t0.Add(time.Minute)); rx != 0 {
+		t.Fatalf("traffic must not carry over between profiles")
+	}
+	// So does a long gap or a counter reset.
+	if rx, _ := s.record("u-home", "Home", "wlan0", sample(9500, 950), t0.Add(time.Hour)); rx != 0 {
+		t.Fatalf("a stale baseline must be dropped")
+	}
+	if rx, _ := s.record("u-home", "Home", "wlan0", sample(10, 10), t0.Add(time.Hour+time.Minute)); rx != 0 {
+		t.Fatalf("a counter reset must be dropped")
+	}
+
+	// Usage lands in the month the sample was taken in.
+	april := t0.Add(time.Hour + 2*time.Minute)
+	s.record("u-home", "Home", "wlan0", sample(1010, 10), april)
+	if got := s.Profiles["u-home"].month(april); got.total() != 1000 {
+		t.Fatalf("unexpected April usage %+v", got)
+	}
+
+	s.Profiles["u-home"].Quota = 500
+	s.Profiles["u-phone"].Quota = 10 << 30
+	warnings := s.quotaWarnings(april)
+	if len(warnings) != 1 || warnings[0].String() != "Home: 1000 B of 500 B this month" {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]uint64{
+		"500":     500,
+		"2K":      2048,
+		"500M":    500 << 20,
+		"5gb":     5 << 30,
+		"1.5 GiB": 3 << 29,
+		"1T":      1 << 40,
+	} {
+		if got, err := parseByteSize(in); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "lots", "-1G", "5X", "5 PB", "inf"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("parseByteSize(%q) should fail", in)
+		}
+	}
+}
+
+func fakeCounters(t *testing.T, root string, rx, tx uint64) {
+	t.Helper()
+	dir := filepath.Join(root, "wlan0", "statistics")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]uint64{"rx_bytes": rx, "tx_bytes": tx, "rx_packets": 0, "tx_packets": 0, "rx_errors": 0, "tx_errors": 0, "rx_dropped": 0, "tx_dropped": 0} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strconv.FormatUint(v, 10)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUsageSamplingWarnsInHeader(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	old := gonetworkmanager.SysfsNetRoot
+	gonetworkmanager.SysfsNetRoot = root
+	t.Cleanup(func() { gonetworkmanager.SysfsNetRoot = old })
+	if err := setUsageQuota("u-phone", "Phone", 1<<20); err != nil {
+		t.Fatalf("setUsageQuota: %v", err)
+	}
+
+	m := windowedModel(t)
+	m.activeWifiConnection = &gonetworkmanager.ConnectionProfile{
+		gonetworkmanager.NmcliFieldConnectionName: "Phone",
+		gonetworkmanager.NmcliFieldConnectionUUID: "u-phone",
+	}
+	m.activeWifiDevice = "wlan0"
+	fakeCounters(t, root, 0, 0)
+	updated, _ := m.Update(m.sampleUsageCmd()())
+	m = updated.(model)
+	fakeCounters(t, root, 2<<20, 1<<20)
+	updated, _ = m.Update(m.sampleUsageCmd()())
+	m = updated.(model)
+
+	if v := m.View(); !strings.Contains(v, "⚠ Data quota exceeded — Phone: 3.0 MiB of 1.0 MiB this month") {
+		t.Fatalf("expected a quota banner:\n%s", v)
+	}
+	path, _ := usagePath()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("usage file should be private: %v", err)
+	}
+
+	var out bytes.Buffer
+	if code := runUsageCLI(nil, &out, &out); code != 0 || !strings.Contains(out.String(), "3.0 MiB") || !strings.Contains(out.String(), "exceeded") {
+		t.Fatalf("unexpected report (%d):\n%s", code, out.String())
+	}
+}
+
+const usageFormFakeNmcli = `
+case "$*" in
+  "-m multiline connection show u-phone")
+    printf 'NAME: Phone\nUUID: u-phone\nconnection.id: Phone\nconnection.uuid: u-phone\nconnection.type: 802-11-wireless\nconnection.metered: yes\n802-11-wireless.ssid: Phone\n' ;;
+  *) echo "$*" >> "$STATE/calls" ;;
+esac
+`
+
+func TestProfileFormMeteredAndQuota(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE", state)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	installFakeCommand(t, "nmcli", usageFormFakeNmcli)
+
+	m := windowedModel(t)
+	m.state = viewKnownNetworksList
+	updated, _ := m.Update(fetchProfileByIDCmd("u-phone", false)())
+	m = updated.(model)
+	if v := m.View(); !strings.Contains(v, "Metered: yes") || !strings.Contains(v, "Data this month: none recorded") {
+		t.Fatalf("expected metered state in the details:\n%s", v)
+	}
+
+	updated, _ = m.Update(fetchProfileByIDCmd("u-phone", true)())
+	m = updated.(model)
+	if got := m.profileForm.inputs[profileFieldMetered].Value(); got != "yes" {
+		t.Fatalf("expected the form to show metered=yes, got %q", got)
+	}
+	m.profileForm.inputs[profileFieldQuota].SetValue("lots")
+	m, _ = press(t, m, enterKey)
+	if !strings.Contains(m.profileForm.statusMsg, "quota") {
+		t.Fatalf("expected a quota validation error, got %q", m.profileForm.statusMsg)
+	}
+	m.profileForm.inputs[profileFieldQuota].SetValue("2G")
+	m.profileForm.inputs[profileFieldMetered].SetValue("auto")
+	m, cmd := press(t, m, enterKey)
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(model)
+	if !strings.Contains(m.connectionStatusMsg, "Profile Phone updated.") {
+		t.Fatalf("unexpected status %q", m.connectionStatusMsg)
+	}
+	calls, _ := os.ReadFile(filepath.Join(state, "calls"))
+	if !strings.Contains(string(calls), "connection.metered unknown") {
+		t.Fatalf("expected metered to be reset to automatic:\n%s", calls)
+	}
+	s, _ := loadUsage()
+	if pu := s.Profiles["u-phone"]; pu == nil || pu.Quota != 2<<30 || pu.Name != "Phone" {
+		t.Fatalf("expected the quota to be stored, got %+v", s.Profiles)
+	}
+
+	// Reopening shows the stored quota, and saving it unchanged leaves
+	// metered alone.
+	updated, _ = m.Update(fetchProfileByIDCmd("u-phone", true)())
+	m = updated.(model)
+	if got := m.profileForm.inputs[profileFieldQuota].Value(); got != "2.0 GiB" {
+		t.Fatalf("expected the stored quota in the form, got %q", got)
+	}
+	spec, _, _, err := m.validateProfileForm()
+	if _, changed, _ := m.profileFormQuota(); err != nil || spec.Metered != "" || changed {
+		t.Fatalf("unchanged fields should not be written: %+v %v", spec, err)
+	}
+}
+
+func TestKnownListShowsMetered(t *testing.T) {
+	installFakeCommand(t, "nmcli", `
+case "$*" in
+  "-m multiline connection show --order name")
+    printf 'NAME: Phone\nUUID: u-phone\nTYPE: wifi\nDEVICE: --\nNAME: Home\nUUID: u-home\nTYPE: wifi\nDEVICE: --\n' ;;
+  "-m multiline -f connection.uuid,connection.metered connection show "*)
+    printf 'connection.uuid: u-phone\nconnection.metered: yes\nconnection.uuid: u-home\nconnection.metered: unknown\n' ;;
+esac
+`)
+	msg := fetchKnownWifiApsCmd()().(knownWifiApsListMsg)
+	if msg.err != nil || len(msg.aps) != 2 {
+		t.Fatalf("unexpected result %+v", msg)
+	}
+	if d := msg.aps[1].Description(); !strings.Contains(d, "Metered") {
+		t.Fatalf("expected Phone to be marked metered: %q", d)
+	}
+	if d := msg.aps[0].Description(); strings.Contains(d, "Metered") {
+		t.Fatalf("Home is not metered: %q", d)
+	}
+}
+
+func TestUnreadableUsageIsNotOverwritten(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path, err := usagePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setUsageQuota("u-phone", "Phone", 1<<20); err == nil {
+		t.Fatalf("expected the load error to be returned")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{not json" {
+		t.Fatalf("the usage data was overwritten: %q", data)
+	}
+}
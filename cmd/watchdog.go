@@ -0,0 +1,456 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"nmtui/gonetworkmanager"
+)
+
+// --- Constants ---
+
+const (
+	watchdogDefaultInterval = 10 * time.Second
+	watchdogDefaultGrace    = 60 * time.Second
+	watchdogEventHistory    = 20
+)
+
+// --- Types ---
+
+type watchdogConfig struct {
+	Device   string        // empty: first Wi-Fi device
+	Interval time.Duration // how often state is sampled
+	Grace    time.Duration // how long limited/none is tolerated; also the retry backoff
+	Fallback bool          // try other known in-range networks when re-activation fails
+}
+
+type watchedProfile struct {
+	Name string
+	UUID string
+}
+
+type watchdogObservation struct {
+	Device       string
+	DeviceState  string
+	Connectivity string
+	Active       *watchedProfile
+}
+
+type watchdogDecision struct {
+	Reactivate bool
+	Target     watchedProfile
+	Reason     string
+}
+
+// watchdog decides when the watched connection needs to be recovered. It is
+// fed observations by either the TUI tick loop or the `watch` subcommand.
+type watchdog struct {
+	cfg         watchdogConfig
+	target      *watchedProfile
+	badSince    time.Time
+	lastAttempt time.Time
+	busy        bool // a recovery is running (TUI only)
+	events      []string
+	out         io.Writer // optional: headless mode echoes events here
+}
+
+type watchdogTickMsg struct{ wd *watchdog }
+
+type watchdogObservedMsg struct {
+	wd  *watchdog
+	obs watchdogObservation
+	err error
+}
+
+type watchdogRecoveredMsg struct {
+	wd        *watchdog
+	events    []string
+	connected *watchedProfile
+	err       error
+}
+
+// defaultWatchdogConfig takes the interval and grace period from the user
+// configuration (watchdog_interval / NMTUI_WATCHDOG_INTERVAL and
+// watchdog_grace / NMTUI_WATCHDOG_GRACE).
+func defaultWatchdogConfig() watchdogConfig {
+	c := currentConfig()
+	return watchdogConfig{Interval: c.WatchdogInterval, Grace: c.WatchdogGrace, Fallback: true}
+}
+
+func newWatchdog(cfg watchdogConfig) *watchdog {
+	return &watchdog{cfg: cfg}
+}
+
+// --- Decision logic ---
+
+func (w *watchdog) logf(now time.Time, format string, args ...any) {
+	line := fmt.Sprintf(format, args...)
+	log.Printf("Watchdog: %s", line)
+	if w.out != nil {
+		fmt.Fprintf(w.out, "%s watchdog: %s\n", now.Format(time.RFC3339), line)
+	}
+	w.events = append(w.events, now.Format("15:04:05")+" "+line)
+	if len(w.events) > watchdogEventHistory {
+		w.events = w.events[len(w.events)-watchdogEventHistory:]
+	}
+}
+
+func (w *watchdog) lastEvent() string {
+	if len(w.events) == 0 {
+		return ""
+	}
+	return w.events[len(w.events)-1]
+}
+
+// forget stops recovering the current profile, e.g. after a user disconnect.
+func (w *watchdog) forget(by string) {
+	if w.target != nil {
+		w.logf(time.Now(), "no longer watching %s (disconnected by %s)", w.target.Name, by)
+	}
+	w.target = nil
+	w.badSince = time.Time{}
+}
+
+// observe folds one sample into the watchdog state and reports whether the
+// target profile should be re-activated now.
+func (w *watchdog) observe(obs watchdogObservation, now time.Time) watchdogDecision {
+	state := strings.ToLower(obs.DeviceState)
+	switch {
+	case state == "unavailable" || state == "unmanaged":
+		// Radio off or rfkill: nothing we can fix by re-activating.
+		w.badSince = time.Time{}
+		return watchdogDecision{}
+	case strings.HasPrefix(state, "connecting") || state == "deactivating":
+		return watchdogDecision{}
+	case obs.Active == nil:
+		if w.target == nil || now.Sub(w.lastAttempt) < w.cfg.Grace {
+			return watchdogDecision{}
+		}
+		return w.decide(now, fmt.Sprintf("%s dropped (device %s is %s)", w.target.Name, obs.Device, obs.DeviceState))
+	}
+
+	if w.target == nil || w.target.UUID != obs.Active.UUID {
+		w.logf(now, "watching %s on %s", obs.Active.Name, obs.Device)
+		active := *obs.Active
+		w.target = &active
+		w.badSince = time.Time{}
+	}
+
+	if obs.Connectivity != "limited" && obs.Connectivity != "none" {
+		if !w.badSince.IsZero() {
+			w.logf(now, "connectivity on %s recovered (%s)", w.target.Name, obs.Connectivity)
+			w.badSince = time.Time{}
+		}
+		return watchdogDecision{}
+	}
+	if w.badSince.IsZero() {
+		w.badSince = now
+		w.logf(now, "connectivity on %s is %s; recovering if it persists for %s", w.target.Name, obs.Connectivity, w.cfg.Grace)
+	}
+	if now.Sub(w.badSince) < w.cfg.Grace || now.Sub(w.lastAttempt) < w.cfg.Grace {
+		return watchdogDecision{}
+	}
+	return w.decide(now, fmt.Sprintf("connectivity on %s has been %s for %s", w.target.Name, obs.Connectivity, now.Sub(w.badSince).Round(time.Second)))
+}
+
+func (w *watchdog) decide(now time.Time, reason string) watchdogDecision {
+	w.lastAttempt = now
+	w.badSince = time.Time{}
+	w.logf(now, "%s; re-activating", reason)
+	return watchdogDecision{Reactivate: true, Target: *w.target, Reason: reason}
+}
+
+// record appends events produced by a recovery run.
+func (w *watchdog) record(now time.Time, events []string) {
+	for _, e := range events {
+		w.logf(now, "%s", e)
+	}
+}
+
+// --- nmcli side ---
+
+// observeWatchdog samples device state, connectivity and the active profile.
+func observeWatchdog(device string) (watchdogObservation, error) {
+	var obs watchdogObservation
+	statuses, err := gonetworkmanager.DeviceStatus()
+	if err != nil {
+		return obs, err
+	}
+	for _, st := range statuses {
+		if (device != "" && st.Device == device) || (device == "" && st.Type == gonetworkmanager.ConnectionTypeWifi) {
+			obs.Device, obs.DeviceState = st.Device, st.State
+			break
+		}
+	}
+	if obs.Device == "" {
+		if device != "" {
+			return obs, fmt.Errorf("device %s not found", device)
+		}
+		return obs, fmt.Errorf("no Wi-Fi device found")
+	}
+
+	connectivity, err := gonetworkmanager.GetNetworkConnectivityState(false)
+	if err != nil {
+		return obs, err
+	}
+	obs.Connectivity = strings.TrimSpace(connectivity)
+
+	actives, err := gonetworkmanager.GetConnectionProfilesList(true)
+	if err != nil {
+		return obs, err
+	}
+	for _, p := range actives {
+		if strings.TrimSpace(p[gonetworkmanager.NmcliFieldConnectionDevice]) == obs.Device {
+			obs.Active = &watchedProfile{Name: p[gonetworkmanager.NmcliFieldConnectionName], UUID: p[gonetworkmanager.NmcliFieldConnectionUUID]}
+			break
+		}
+	}
+	return obs, nil
+}
+
+// watchdogFallbackCandidates returns autoconnect-enabled known profiles whose
+// SSID is currently in range, highest priority (then most recently used) first.
+func watchdogFallbackCandidates(excludeUUID string) ([]gonetworkmanager.KnownWifiProfile, error) {
+	aps, err := gonetworkmanager.GetWifiList(true)
+	if err != nil {
+		return nil, err
+	}
+	inRange := inRangeSSIDs(aps)
+	known, err := gonetworkmanager.GetKnownWifiProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []gonetworkmanager.KnownWifiProfile
+	for _, p := range known {
+		if p.UUID != excludeUUID && p.Autoconnect && inRange[p.SSID] {
+			candidates = append(candidates, p)
+		}
+	}
+	sortByAutoconnectPreference(candidates)
+	return candidates, nil
+}
+
+// runWatchdogRecovery re-activates target and, if that fails and fallback is
+// enabled, walks the in-range known networks by priority.
+func runWatchdogRecovery(target watchedProfile, fallback bool) (events []string, connected *watchedProfile, err error) {
+	if _, err = gonetworkmanager.ConnectionUp(target.UUID); err == nil {
+		return []string{fmt.Sprintf("re-activated %s", target.Name)}, &target, nil
+	}
+	events = append(events, fmt.Sprintf("re-activating %s failed: %v", target.Name, err))
+	if !fallback {
+		return events, nil, err
+	}
+
+	candidates, cerr := watchdogFallbackCandidates(target.UUID)
+	if cerr != nil {
+		events = append(events, fmt.Sprintf("could not list fallback networks: %v", cerr))
+		return events, nil, cerr
+	}
+	if len(candidates) == 0 {
+		events = append(events, "no other known network in range")
+		return events, nil, err
+	}
+	for _, c := range candidates {
+		events = append(events, fmt.Sprintf("falling back to %s (priority %d)", c.Name, c.Priority))
+		if _, cerr := gonetworkmanager.ConnectionUp(c.UUID); cerr != nil {
+			events = append(events, fmt.Sprintf("fallback to %s failed: %v", c.Name, cerr))
+			err = cerr
+			continue
+		}
+		events = append(events, fmt.Sprintf("connected to %s", c.Name))
+		return events, &watchedProfile{Name: c.Name, UUID: c.UUID}, nil
+	}
+	return events, nil, err
+}
+
+// --- CLI ---
+
+// runWatchCLI is the entry point for the `watch` subcommand.
+func runWatchCLI(args []string, stdout, stderr io.Writer) int {
+	cfg := defaultWatchdogConfig()
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.Device, "device", "", "Wi-Fi device to watch (default: first Wi-Fi device)")
+	fs.DurationVar(&cfg.Interval, "interval", cfg.Interval, "how often to sample connectivity")
+	fs.DurationVar(&cfg.Grace, "grace", cfg.Grace, "how long limited/none connectivity is tolerated before recovering")
+	noFallback := fs.Bool("no-fallback", false, "only re-activate the current profile; never switch networks")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if cfg.Interval <= 0 {
+		fmt.Fprintln(stderr, "Error: --interval must be positive")
+		return 2
+	}
+	cfg.Fallback = !*noFallback
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	w := newWatchdog(cfg)
+	w.out = stdout
+	runWatchLoop(ctx, w)
+	return 0
+}
+
+// runWatchLoop samples and recovers until ctx is cancelled.
+func runWatchLoop(ctx context.Context, w *watchdog) {
+	w.logf(time.Now(), "started (interval %s, grace %s, fallback %t)", w.cfg.Interval, w.cfg.Grace, w.cfg.Fallback)
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	var lastErr string
+	overQuota := make(map[string]bool)
+	for {
+		// Scheduled disconnects go first so the watchdog does not undo them.
+		if res, err := runSchedule(time.Now()); err != nil {
+			log.Printf("Schedule: %v", err)
+		} else {
+			for _, e := range res.Events {
+				w.logf(time.Now(), "schedule: %s", e)
+			}
+			if w.target != nil && slices.Contains(res.Disconnected, w.target.UUID) {
+				w.forget("schedule")
+			}
+		}
+		obs, err := observeWatchdog(w.cfg.Device)
+		now := time.Now()
+		if err != nil {
+			if err.Error() != lastErr {
+				w.logf(now, "sampling failed: %v", err)
+			}
+			lastErr = err.Error()
+		} else {
+			lastErr = ""
+			if obs.Active != nil {
+				recordWatchUsage(w, obs, now, overQuota)
+			}
+			if dec := w.observe(obs, now); dec.Reactivate {
+				events, _, _ := runWatchdogRecovery(dec.Target, w.cfg.Fallback)
+				w.record(time.Now(), events)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			w.logf(time.Now(), "stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordWatchUsage counts the active profile's traffic toward its data usage
+// and logs each quota once when it is first exceeded.
+func recordWatchUsage(w *watchdog, obs watchdogObservation, now time.Time, overQuota map[string]bool) {
+	s, err := sampleUsage(obs.Active.UUID, obs.Active.Name, obs.Device, now)
+	if err != nil {
+		log.Printf("Usage: %v", err)
+		return
+	}
+	current := make(map[string]bool)
+	for _, q := range s.quotaWarnings(now) {
+		current[q.UUID] = true
+		if !overQuota[q.UUID] {
+			w.logf(now, "data quota exceeded: %s", q)
+		}
+	}
+	clear(overQuota)
+	for uuid := range current {
+		overQuota[uuid] = true
+	}
+}
+
+// --- TUI ---
+
+func watchdogTickCmd(w *watchdog, after time.Duration) tea.Cmd {
+	return tea.Tick(after, func(time.Time) tea.Msg { return watchdogTickMsg{wd: w} })
+}
+
+func watchdogObserveCmd(w *watchdog) tea.Cmd {
+	device := w.cfg.Device
+	return func() tea.Msg {
+		obs, err := observeWatchdog(device)
+		return watchdogObservedMsg{wd: w, obs: obs, err: err}
+	}
+}
+
+func watchdogRecoverCmd(w *watchdog, target watchedProfile) tea.Cmd {
+	fallback := w.cfg.Fallback
+	return func() tea.Msg {
+		events, connected, err := runWatchdogRecovery(target, fallback)
+		return watchdogRecoveredMsg{wd: w, events: events, connected: connected, err: err}
+	}
+}
+
+func (m *model) toggleWatchdog() tea.Cmd {
+	if m.watchdog != nil {
+		m.watchdog.logf(time.Now(), "stopped")
+		m.watchdog = nil
+		m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render("Watchdog off.")
+		return nil
+	}
+	m.watchdog = newWatchdog(defaultWatchdogConfig())
+	m.watchdog.cfg.Device = m.activeWifiDevice
+	m.watchdog.logf(time.Now(), "started (interval %s, grace %s)", m.watchdog.cfg.Interval, m.watchdog.cfg.Grace)
+	m.connectionStatusMsg = toggleHiddenStatusMsgStyle.Render(fmt.Sprintf("Watchdog on: re-activating after drops or %s of limited connectivity.", m.watchdog.cfg.Grace))
+	return watchdogObserveCmd(m.watchdog)
+}
+
+func (m *model) handleWatchdogTick(msg watchdogTickMsg) tea.Cmd {
+	if msg.wd == nil || msg.wd != m.watchdog || m.watchdog.busy {
+		return nil
+	}
+	return watchdogObserveCmd(m.watchdog)
+}
+
+func (m *model) handleWatchdogObserved(msg watchdogObservedMsg) tea.Cmd {
+	w := m.watchdog
+	if msg.wd == nil || msg.wd != w {
+		return nil
+	}
+	if msg.err != nil {
+		log.Printf("Watchdog: sampling failed: %v", msg.err)
+		return watchdogTickCmd(w, w.cfg.Interval)
+	}
+	if w.cfg.Device == "" {
+		w.cfg.Device = msg.obs.Device
+	}
+	// Don't fight the user while they are connecting by hand.
+	if m.state == viewConnecting {
+		return watchdogTickCmd(w, w.cfg.Interval)
+	}
+	dec := w.observe(msg.obs, time.Now())
+	if !dec.Reactivate {
+		return watchdogTickCmd(w, w.cfg.Interval)
+	}
+	w.busy = true
+	if m.state == viewNetworksList {
+		m.connectionStatusMsg = connectingStyle.Render(fmt.Sprintf("Watchdog: %s", dec.Reason))
+	}
+	return watchdogRecoverCmd(w, dec.Target)
+}
+
+func (m *model) handleWatchdogRecovered(msg watchdogRecoveredMsg) tea.Cmd {
+	w := m.watchdog
+	if msg.wd == nil || msg.wd != w {
+		return nil
+	}
+	w.busy = false
+	w.record(time.Now(), msg.events)
+	if m.state == viewNetworksList {
+		if msg.connected != nil {
+			m.connectionStatusMsg = successStyle.Render(fmt.Sprintf("Watchdog: connected to %s.", msg.connected.Name))
+		} else {
+			m.connectionStatusMsg = errorStyle.Render(fmt.Sprintf("Watchdog: recovery failed (%s).", w.lastEvent()))
+		}
+	}
+	return tea.Batch(fetchKnownNetworksCmd(), fetchWifiNetworksCmd(false), watchdogTickCmd(w, w.cfg.Interval))
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func watchObs(state, connectivity string, active *watchedProfile) watchdogObservation {
+	return watchdogObservation{Device: "wlan0", DeviceState: state, Connectivity: connectivity, Active: active}
+}
+
+func TestWatchdogObserveLimitedConnectivity(t *testing.T) {
+	w := newWatchdog(watchdogConfig{Interval: time.Second, Grace: 30 * time.Second, Fallback: true})
+	home := &watchedProfile{Name: "Home", UUID: "u-home"}
+	t0 := time.Unix(1000, 0)
+
+	if dec := w.observe(watchObs("connected", "full", home), t0); dec.Reactivate {
+		t.Fatalf("healthy connection should not be recovered")
+	}
+	if w.target == nil || w.target.UUID != "u-home" {
+		t.Fatalf("expected Home to become the watched profile")
+	}
+	if dec := w.observe(watchObs("connected", "limited", home), t0.Add(time.Second)); dec.Reactivate {
+		t.Fatalf("limited connectivity should be tolerated during the grace period")
+	}
+	if dec := w.observe(watchObs("connected", "limited", home), t0.Add(20*time.Second)); dec.Reactivate {
+		t.Fatalf("still inside the grace period")
+	}
+	dec := w.observe(watchObs("connected", "none", home), t0.Add(32*time.Second))
+	if !dec.Reactivate || dec.Target.UUID != "u-home" || !strings.Contains(dec.Reason, "none") {
+		t.Fatalf("expected re-activation after grace, got %+v", dec)
+	}
+	// The grace period doubles as a retry backoff.
+	if dec := w.observe(watchObs("connected", "limited", home), t0.Add(40*time.Second)); dec.Reactivate {
+		t.Fatalf("retry should wait for the backoff")
+	}
+	if dec := w.observe(watchObs("connected", "full", home), t0.Add(45*time.Second)); dec.Reactivate || !w.badSince.IsZero() {
+		t.Fatalf("recovered connectivity should reset the timer")
+	}
+	if !strings.Contains(strings.Join(w.events, "\n"), "recovered (full)") {
+		t.Fatalf("expected recovery to be logged, got %v", w.events)
+	}
+}
+
+func TestWatchdogObserveDropAndUserDisconnect(t *testing.T) {
+	w := newWatchdog(watchdogConfig{Interval: time.Second, Grace: 10 * time.Second})
+	home := &watchedProfile{Name: "Home", UUID: "u-home"}
+	t0 := time.Unix(1000, 0)
+
+	if dec := w.observe(watchObs("disconnected", "none", nil), t0); dec.Reactivate {
+		t.Fatalf("nothing to recover before a connection was seen")
+	}
+	w.observe(watchObs("connected", "full", home), t0)
+	if dec := w.observe(watchObs("unavailable", "none", nil), t0.Add(time.Second)); dec.Reactivate {
+		t.Fatalf("radio off must not trigger recovery")
+	}
+	if dec := w.observe(watchObs("connecting (configuring)", "none", nil), t0.Add(2*time.Second)); dec.Reactivate {
+		t.Fatalf("activation in progress must not trigger recovery")
+	}
+	if dec := w.observe(watchObs("disconnected", "none", nil), t0.Add(3*time.Second)); !dec.Reactivate || dec.Target.Name != "Home" {
+		t.Fatalf("expected immediate re-activation after a drop, got %+v", dec)
+	}
+	if dec := w.observe(watchObs("disconnected", "none", nil), t0.Add(5*time.Second)); dec.Reactivate {
+		t.Fatalf("expected backoff between attempts")
+	}
+
+	w.forget("user")
+	if dec := w.observe(watchObs("disconnected", "none", nil), t0.Add(time.Minute)); dec.Reactivate {
+		t.Fatalf("user disconnect should stop recovery")
+	}
+}
+
+const watchdogFakeNmcli = `
+case "$*" in
+  "connection up u-home") echo "Error: Connection activation failed: No suitable device found." >&2; exit 4 ;;
+  "connection up u-office") echo "Connection successfully activated"; exit 0 ;;
+  *"device wifi list"*) printf 'SSID: Cafe\nSIGNAL: 50\nSSID: Office\nSIGNAL: 70\nSSID: Home\nSIGNAL: 10\n' ;;
+  *"802-11-wireless.ssid connection show"*) printf 'connection.uuid: u-office\n802-11-wireless.ssid: Office\n' ;;
+  *"connection show"*) printf 'NAME: Cafe\nUUID: u-cafe\nTYPE: wifi\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 1\nTIMESTAMP: 5\n'
+    printf 'NAME: Home\nUUID: u-home\nTYPE: wifi\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 99\nTIMESTAMP: 9\n'
+    printf 'NAME: Library\nUUID: u-lib\nTYPE: wifi\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 50\nTIMESTAMP: 9\n'
+    printf 'NAME: Lounge\nUUID: u-lounge\nTYPE: wifi\nDEVICE: --\nAUTOCONNECT: no\nAUTOCONNECT-PRIORITY: 80\nTIMESTAMP: 9\n'
+    printf 'NAME: Work\nUUID: u-office\nTYPE: wifi\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 10\nTIMESTAMP: 1\n' ;;
+  *) exit 9 ;;
+esac
+`
+
+func TestWatchdogRecoveryFallsBackByPriority(t *testing.T) {
+	installFakeCommand(t, "nmcli", watchdogFakeNmcli)
+
+	events, connected, err := runWatchdogRecovery(watchedProfile{Name: "Home", UUID: "u-home"}, true)
+	if err != nil || connected == nil || connected.UUID != "u-office" {
+		t.Fatalf("expected fallback to Work, got %+v err %v (events %v)", connected, err, events)
+	}
+	log := strings.Join(events, "\n")
+	for _, want := range []string{"re-activating Home failed", "falling back to Work (priority 10)", "connected to Work"} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("missing event %q in:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "Library") || strings.Contains(log, "Lounge") || strings.Contains(log, "Cafe") {
+		t.Fatalf("out-of-range, non-autoconnect or lower-priority networks should not be tried first:\n%s", log)
+	}
+
+	_, connected, err = runWatchdogRecovery(watchedProfile{Name: "Home", UUID: "u-home"}, false)
+	if err == nil || connected != nil {
+		t.Fatalf("expected failure without fallback")
+	}
+}
+
+func TestRunWatchLoopLogsActions(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	installFakeCommand(t, "nmcli", `
+case "$*" in
+  *"-t -f DEVICE,TYPE,STATE,CONNECTION device"*) echo "wlan0:wifi:connected:Home" ;;
+  "networking connectivity") echo full ;;
+  *"connection show"*"--active"*) printf 'NAME: Home\nUUID: u-home\nTYPE: wifi\nDEVICE: wlan0\n' ;;
+  *) exit 9 ;;
+esac
+`)
+	var out bytes.Buffer
+	w := newWatchdog(watchdogConfig{Interval: 5 * time.Millisecond, Grace: time.Minute})
+	w.out = &out
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+	runWatchLoop(ctx, w)
+
+	got := out.String()
+	if strings.Count(got, "watching Home on wlan0") != 1 || !strings.Contains(got, "watchdog: started") || !strings.Contains(got, "watchdog: stopped") {
+		t.Fatalf("unexpected watch output:\n%s", got)
+	}
+}
+
+func TestRunWatchCLIRejectsBadInterval(t *testing.T) {
+	var errOut bytes.Buffer
+	if code := runWatchCLI([]string{"--interval", "0s"}, &bytes.Buffer{}, &errOut); code != 2 {
+		t.Fatalf("expected exit 2, got %d", code)
+	}
+}
+
+func TestWatchdogTUIFlow(t *testing.T) {
+	m := windowedModel(t)
+	m.state = viewNetworksList
+	m.isLoading = false
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'W'}})
+	m2 := updated.(model)
+	if m2.watchdog == nil || cmd == nil {
+		t.Fatalf("expected W to enable the watchdog")
+	}
+	if !strings.Contains(m2.View(), "watchdog") {
+		t.Fatalf("expected watchdog indicator in header")
+	}
+	wd := m2.watchdog
+	home := &watchedProfile{Name: "Home", UUID: "u-home"}
+
+	updated, _ = m2.Update(watchdogObservedMsg{wd: wd, obs: watchObs("connected", "full", home)})
+	m2 = updated.(model)
+	updated, cmd = m2.Update(watchdogObservedMsg{wd: wd, obs: watchObs("disconnected", "none", nil)})
+	m2 = updated.(model)
+	if !wd.busy || cmd == nil || !strings.Contains(m2.connectionStatusMsg, "Home dropped") {
+		t.Fatalf("expected recovery to start, status %q", m2.connectionStatusMsg)
+	}
+	if cmd := m2.handleWatchdogTick(watchdogTickMsg{wd: wd}); cmd != nil {
+		t.Fatalf("ticks must not overlap a running recovery")
+	}
+
+	updated, _ = m2.Update(watchdogRecoveredMsg{wd: wd, events: []string{"re-activated Home"}, connected: home})
+	m2 = updated.(model)
+	if wd.busy || !strings.Contains(m2.connectionStatusMsg, "connected to Home") {
+		t.Fatalf("expected recovery result in status, got %q", m2.connectionStatusMsg)
+	}
+
+	// Turning it off drops messages from the old instance.
+	updated, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'W'}})
+	m2 = updated.(model)
+	if m2.watchdog != nil {
+		t.Fatalf("expected W to disable the watchdog")
+	}
+	if _, cmd := m2.Update(watchdogTickMsg{wd: wd}); cmd != nil {
+		t.Fatalf("stale tick should be ignored")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io" // For ActivityMonitor
 	"log"
 	"net" // For GetIPv4
+	"os"
 	"os/exec"
 	"strconv" // For parseDeviceState and others
 	"strings"
@@ -16,7 +17,9 @@ import (
 	"time"
 )
 
-const nmcliCommandTimeout = 45 * time.Second
+// NmcliCommandTimeout bounds every nmcli invocation. Callers may change it
+// before the first call, e.g. from user configuration.
+var NmcliCommandTimeout = 45 * time.Second
 
 // --- Constants for nmcli field names ---
 const (
@@ -36,10 +39,17 @@ const (
 	NmcliFieldConnectionType     = "TYPE"
 	NmcliFieldConnectionDevice   = "DEVICE"
 	NmcliFieldConnectionState    = "STATE"
+	NmcliFieldConnectionAutocon  = "AUTOCONNECT"
+	NmcliFieldConnectionPriority = "AUTOCONNECT-PRIORITY"
+	NmcliFieldConnectionTime     = "TIMESTAMP"
+	NmcliFieldConnectionMetered  = "connection.metered"
+	NmcliFieldWifiPSKFlags       = "802-11-wireless-security.psk-flags"
 	NmcliFieldWifiSSID           = "SSID"
 	NmcliFieldWifiBSSID          = "BSSID"
 	NmcliFieldWifiSignal         = "SIGNAL"
 	NmcliFieldWifiSecurity       = "SECURITY"
+	NmcliFieldWifiChannel        = "CHAN"
+	NmcliFieldWifiRate           = "RATE"
 	NmcliFieldWifiInUse          = "IN-USE"
 	NmcliFieldDeviceStatusDevice = "DEVICE"
 	NmcliFieldDeviceStatusType   = "TYPE"
@@ -97,6 +107,38 @@ type WifiProfileSpec struct {
 	Hidden      bool
 	Autoconnect bool
 	Priority    *int
+	Metered     string // connection.metered: "yes", "no" or "unknown"; empty leaves it alone
+	PSKFlags    string // 802-11-wireless-security.psk-flags, e.g. PSKFlagsAgentOwned; empty leaves it alone
+}
+
+// Values of 802-11-wireless-security.psk-flags. Only PSKFlagsSystem keeps
+// the PSK in NetworkManager's profile, readable by every user allowed to
+// see it. With PSKFlagsAgentOwned a secret agent, or ConnectionUpWithPSK,
+// supplies it on activation; with PSKFlagsNotSaved it is asked for on
+// every activation.
+const (
+	PSKFlagsSystem     = "0"
+	PSKFlagsAgentOwned = "1"
+	PSKFlagsNotSaved   = "2"
+)
+
+// PSKStoredBySystem reports whether psk-flags, as nmcli prints them (e.g.
+// "1 (agent-owned)"), keep the PSK in the profile. Empty flags mean the
+// default, which stores it.
+func PSKStoredBySystem(flags string) bool {
+	n, err := strconv.Atoi(strings.Fields(flags + " 0")[0])
+	return err != nil || n&3 == 0
+}
+
+// KnownWifiProfile summarises a saved Wi-Fi profile's autoconnect settings.
+type KnownWifiProfile struct {
+	Name        string `json:"name"`
+	UUID        string `json:"uuid"`
+	SSID        string `json:"ssid"`
+	Device      string `json:"device,omitempty"`
+	Autoconnect bool   `json:"autoconnect"`
+	Priority    int    `json:"priority"`
+	Timestamp   int64  `json:"timestamp"` // last activation (unix seconds), 0 if never
 }
 
 // --- Core nmcli Interaction ---
@@ -147,7 +189,7 @@ func parseNmcliMultilineOutput(output string) ([]map[string]string, error) {
 }
 
 func runNmcli(args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), nmcliCommandTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), NmcliCommandTimeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "nmcli", args...)
@@ -162,7 +204,7 @@ func runNmcli(args ...string) (string, error) {
 	argLine := strings.Join(redactNmcliArgs(args), " ")
 
 	if ctx.Err() == context.DeadlineExceeded {
-		return stdoutStr, fmt.Errorf("nmcli command '%s' timed out after %s", argLine, nmcliCommandTimeout)
+		return stdoutStr, fmt.Errorf("nmcli command '%s' timed out after %s", argLine, NmcliCommandTimeout)
 	}
 
 	if err != nil {
@@ -232,10 +274,10 @@ func redactNmcliArgs(args []string) []string {
 
 func isSecretArgKey(k string) bool {
 	switch k {
-	case "password", "wifi-sec.psk", "psk", "pin":
+	case "password", "wifi-sec.psk", "psk", "pin", "vpn.secrets", "+vpn.secrets":
 		return true
 	default:
-		return strings.HasSuffix(k, ".psk")
+		return strings.HasSuffix(k, ".psk") || strings.HasSuffix(k, ".password") || strings.HasSuffix(k, ".private-key")
 	}
 }
 func cliInternal(args ...string) (string, error) { return runNmcli(args...) }
@@ -384,6 +426,9 @@ func ConnectionDelete(profileIdentifier string) (string, error) {
 	if strings.TrimSpace(profileIdentifier) == "" {
 		return "", fmt.Errorf("profile identifier cannot be empty")
 	}
+	if err := beforeProfileChange(profileIdentifier, ProfileChangeDelete); err != nil {
+		return "", err
+	}
 	return cliInternal("connection", "delete", profileIdentifier)
 }
 
@@ -404,6 +449,100 @@ func GetConnectionProfilesList(activeOnly bool) ([]ConnectionProfile, error) {
 	return profiles, nil
 }
 
+// GetKnownWifiProfiles lists saved Wi-Fi profiles with their autoconnect
+// priority, last-used timestamp and configured SSID. The SSID falls back to
+// the profile name if it cannot be read.
+func GetKnownWifiProfiles() ([]KnownWifiProfile, error) {
+	fields := strings.Join([]string{NmcliFieldConnectionName, NmcliFieldConnectionUUID, NmcliFieldConnectionType, NmcliFieldConnectionDevice, NmcliFieldConnectionAutocon, NmcliFieldConnectionPriority, NmcliFieldConnectionTime}, ",")
+	rawProfiles, err := clibInternal("-m", "multiline", "-f", fields, "connection", "show", "--order", "name")
+	if err != nil {
+		return nil, err
+	}
+	var profiles []KnownWifiProfile
+	for _, rp := range rawProfiles {
+		if !isWifiConnectionType(rp[NmcliFieldConnectionType]) {
+			continue
+		}
+		p := KnownWifiProfile{
+			Name:        rp[NmcliFieldConnectionName],
+			UUID:        rp[NmcliFieldConnectionUUID],
+			Autoconnect: strings.TrimSpace(rp[NmcliFieldConnectionAutocon]) == "yes",
+		}
+		p.SSID = p.Name
+		if dev := strings.TrimSpace(rp[NmcliFieldConnectionDevice]); dev != "--" {
+			p.Device = dev
+		}
+		p.Priority, _ = strconv.Atoi(strings.TrimSpace(rp[NmcliFieldConnectionPriority]))
+		p.Timestamp, _ = strconv.ParseInt(strings.TrimSpace(rp[NmcliFieldConnectionTime]), 10, 64)
+		profiles = append(profiles, p)
+	}
+	if len(profiles) == 0 {
+		return profiles, nil
+	}
+	uuids := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		uuids = append(uuids, p.UUID)
+	}
+	ssids, err := GetWifiProfileSSIDs(uuids)
+	if err != nil {
+		log.Printf("GetKnownWifiProfiles: could not read SSIDs, using profile names: %v", err)
+	}
+	for i := range profiles {
+		if ssid, ok := ssids[profiles[i].UUID]; ok {
+			profiles[i].SSID = ssid
+		}
+	}
+	return profiles, nil
+}
+
+// GetProfileProperties reads the given properties of several profiles with
+// a single nmcli call, keyed by UUID. Unset ("--") values are left out.
+func GetProfileProperties(uuids []string, props ...string) (map[string]map[string]string, error) {
+	out := make(map[string]map[string]string, len(uuids))
+	if len(uuids) == 0 {
+		return out, nil
+	}
+	fields := strings.Join(append([]string{"connection.uuid"}, props...), ",")
+	args := append([]string{"-m", "multiline", "-f", fields, "connection", "show"}, uuids...)
+	records, err := clibInternal(args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		values := make(map[string]string, len(props))
+		for _, prop := range props {
+			if v := strings.TrimSpace(r[prop]); v != "" && v != "--" {
+				values[prop] = v
+			}
+		}
+		out[r["connection.uuid"]] = values
+	}
+	return out, nil
+}
+
+// GetWifiProfileSSIDs returns the configured SSID of each given profile UUID,
+// fetched with a single nmcli call. Profiles without an SSID are omitted.
+func GetWifiProfileSSIDs(uuids []string) (map[string]string, error) {
+	props, err := GetProfileProperties(uuids, eightZeroTwo11SSID)
+	if err != nil {
+		return nil, err
+	}
+	ssids := make(map[string]string, len(props))
+	for uuid, values := range props {
+		if ssid := values[eightZeroTwo11SSID]; ssid != "" {
+			ssids[uuid] = ssid
+		}
+	}
+	return ssids, nil
+}
+
+// isWifiConnectionType accepts both the short and the setting-name form nmcli
+// prints depending on output mode.
+func isWifiConnectionType(t string) bool {
+	t = strings.TrimSpace(t)
+	return t == ConnectionTypeWifi || t == "802-11-wireless"
+}
+
 func GetConnectionProfileByID(profileIdentifier string) (ConnectionProfile, error) {
 	if strings.TrimSpace(profileIdentifier) == "" {
 		return nil, fmt.Errorf("profile identifier cannot be empty")
@@ -426,6 +565,98 @@ func ChangeDnsConnection(profileIdentifier string, dnsServers string) (string, e
 	return cliInternal("connection", "modify", profileIdentifier, "ipv4.dns", dnsServers)
 }
 
+// Operations reported to BeforeProfileChange.
+const (
+	ProfileChangeDelete = "delete"
+	ProfileChangeModify = "modify"
+)
+
+// BeforeProfileChange, when set, runs before ConnectionDelete,
+// ModifyConnection and UpdateWifiProfile touch an existing profile, so callers
+// can snapshot it first. Returning an error aborts the change.
+var BeforeProfileChange func(profileIdentifier, operation string) error
+
+func beforeProfileChange(profileIdentifier, operation string) error {
+	if BeforeProfileChange == nil {
+		return nil
+	}
+	if err := BeforeProfileChange(profileIdentifier, operation); err != nil {
+		return fmt.Errorf("not changing %s: snapshot failed: %w", profileIdentifier, err)
+	}
+	return nil
+}
+
+// ConnectionSetting is a single nmcli property assignment. Settings are kept
+// as an ordered slice so generated command lines are stable.
+type ConnectionSetting struct {
+	Key   string
+	Value string
+}
+
+func appendSettings(args []string, settings []ConnectionSetting) []string {
+	for _, s := range settings {
+		args = append(args, s.Key, s.Value)
+	}
+	return args
+}
+
+// AddConnection creates a profile of any type from raw nmcli properties.
+// An empty ifname binds the profile to no particular interface.
+func AddConnection(connType, name, ifname string, settings []ConnectionSetting) (string, error) {
+	if strings.TrimSpace(connType) == "" {
+		return "", fmt.Errorf("connection type cannot be empty")
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("connection name cannot be empty")
+	}
+	if ifname == "" {
+		ifname = "*"
+	}
+	args := []string{"connection", "add", "type", connType, "con-name", name, "ifname", ifname}
+	return cliInternal(appendSettings(args, settings)...)
+}
+
+// ModifyConnection applies raw nmcli property changes to an existing profile.
+func ModifyConnection(profileIdentifier string, settings []ConnectionSetting) (string, error) {
+	if strings.TrimSpace(profileIdentifier) == "" {
+		return "", fmt.Errorf("profile identifier cannot be empty")
+	}
+	if len(settings) == 0 {
+		return "", nil
+	}
+	if err := beforeProfileChange(profileIdentifier, ProfileChangeModify); err != nil {
+		return "", err
+	}
+	args := []string{"connection", "modify", profileIdentifier}
+	return cliInternal(appendSettings(args, settings)...)
+}
+
+// ModifyConnectionUnrecorded is ModifyConnection for changes the app makes
+// in bulk or on its own: scheduled autoconnect toggles, saving a join order,
+// switching locations. It skips BeforeProfileChange so that they do not
+// crowd real edits out of the snapshot history.
+func ModifyConnectionUnrecorded(profileIdentifier string, settings []ConnectionSetting) (string, error) {
+	if strings.TrimSpace(profileIdentifier) == "" {
+		return "", fmt.Errorf("profile identifier cannot be empty")
+	}
+	if len(settings) == 0 {
+		return "", nil
+	}
+	args := []string{"connection", "modify", profileIdentifier}
+	return cliInternal(appendSettings(args, settings)...)
+}
+
+// SetAutoconnect turns connection.autoconnect on or off for a profile.
+func SetAutoconnect(profileIdentifier string, enabled bool) (string, error) {
+	return ModifyConnection(profileIdentifier, []ConnectionSetting{{Key: "connection.autoconnect", Value: map[bool]string{true: "yes", false: "no"}[enabled]}})
+}
+
+// SetAutoconnectPriority sets connection.autoconnect-priority; NetworkManager
+// prefers higher values when several profiles could autoconnect.
+func SetAutoconnectPriority(profileIdentifier string, priority int) (string, error) {
+	return ModifyConnection(profileIdentifier, []ConnectionSetting{{Key: "connection.autoconnect-priority", Value: strconv.Itoa(priority)}})
+}
+
 // AddEthernetConnection adds an Ethernet connection profile with static IP.
 func AddEthernetConnection(connectionName, interfaceName, ipv4Address, gateway string, cidrPrefix int) (string, error) {
 	if strings.TrimSpace(connectionName) == "" {
@@ -484,6 +715,15 @@ func DeviceDisconnect(deviceInterface string) (string, error) {
 	return cliInternal("device", "disconnect", deviceInterface)
 }
 
+// DeviceReapply applies the current settings of the profile active on a
+// device without taking the connection down.
+func DeviceReapply(deviceInterface string) (string, error) {
+	if strings.TrimSpace(deviceInterface) == "" {
+		return "", fmt.Errorf("device interface cannot be empty")
+	}
+	return cliInternal("device", "reapply", deviceInterface)
+}
+
 var deviceStateMap = map[int]string{
 	0: "unknown", 10: "unmanaged", 20: "unavailable", 30: "disconnected", 40: "prepare", 50: "config",
 	60: "need-auth", 70: "ip-config", 80: "ip-check", 90: "secondaries", 100: "activated",
@@ -632,6 +872,33 @@ func WifiEnable() (string, error)    { return cliInternal("radio", "wifi", "on")
 func WifiDisable() (string, error)   { return cliInternal("radio", "wifi", "off") }
 func GetWifiStatus() (string, error) { return cliInternal("radio", "wifi") }
 
+// ValidateWifiPSK checks a WPA-PSK password: a passphrase of 8 to 63
+// printable ASCII characters, or a raw key of exactly 64 hex digits.
+// Lengths are counted in characters, so multi-byte input cannot slip
+// through a byte count.
+func ValidateWifiPSK(psk string) error {
+	if psk == "" {
+		return fmt.Errorf("password is empty")
+	}
+	// The error names the position only: it is shown under a masked input.
+	for i, r := range []rune(psk) {
+		if r < 0x20 || r > 0x7e {
+			return fmt.Errorf("password may only contain printable ASCII characters; character %d is not one", i+1)
+		}
+	}
+	switch n := len(psk); {
+	case n < 8:
+		return fmt.Errorf("password is too short: %d characters, at least 8 are needed", n)
+	case n == 64:
+		if strings.Trim(psk, "0123456789abcdefABCDEF") != "" {
+			return fmt.Errorf("password of 64 characters must be a raw key of hex digits (0-9, a-f)")
+		}
+	case n > 63:
+		return fmt.Errorf("password is too long: %d characters, at most 63 are allowed (or a 64-digit hex key)", n)
+	}
+	return nil
+}
+
 func WifiHotspot(interfaceName, ssid, password string) ([]map[string]string, error) {
 	if strings.TrimSpace(interfaceName) == "" {
 		return nil, fmt.Errorf("hotspot interface name empty")
@@ -639,8 +906,8 @@ func WifiHotspot(interfaceName, ssid, password string) ([]map[string]string, err
 	if strings.TrimSpace(ssid) == "" {
 		return nil, fmt.Errorf("hotspot SSID empty")
 	}
-	if len(password) < 8 || len(password) > 63 {
-		return nil, fmt.Errorf("hotspot password must be 8-63 chars")
+	if err := ValidateWifiPSK(password); err != nil {
+		return nil, fmt.Errorf("hotspot %w", err)
 	}
 	return clibInternal("device", "wifi", "hotspot", "ifname", interfaceName, "ssid", ssid, "password", password)
 }
@@ -659,6 +926,71 @@ func WifiCredentials(interfaceName string) (WifiCredentialsType, error) {
 	return WifiCredentialsType(data[0]), nil
 }
 
+// SavedWifiPSK reads the pre-shared key stored in a saved Wi-Fi profile
+// (`nmcli -s -g 802-11-wireless-security.psk connection show`). Unlike
+// WifiCredentials it works for any profile, active or not, but it needs
+// permission to read secrets. An empty key means the profile stores none,
+// e.g. because a secret agent owns it.
+func SavedWifiPSK(profileIdentifier string) (string, error) {
+	if strings.TrimSpace(profileIdentifier) == "" {
+		return "", fmt.Errorf("profile identifier cannot be empty")
+	}
+	out, err := cliInternal("-s", "-g", "802-11-wireless-security.psk", "connection", "show", profileIdentifier)
+	if err != nil {
+		return "", err
+	}
+	return unescapeTerseValue(out), nil
+}
+
+// unescapeTerseValue undoes the escaping of ':' and '\' in nmcli's terse
+// (-t, -g) output.
+func unescapeTerseValue(v string) string {
+	return strings.NewReplacer(`\\`, `\`, `\:`, ":").Replace(v)
+}
+
+// WifiShareURI returns the "WIFI:T:WPA;S:...;P:...;;" string that phones
+// understand when it is encoded as a QR code. It reads the profile with
+// secrets, so the caller needs permission to see the PSK.
+func WifiShareURI(profileIdentifier string) (string, error) {
+	p, err := GetConnectionProfileWithSecrets(profileIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if !isWifiConnectionType(p["connection.type"]) {
+		return "", fmt.Errorf("%s is not a Wi-Fi profile", profileIdentifier)
+	}
+	ssid := strings.TrimSpace(p[eightZeroTwo11SSID])
+	if ssid == "" || ssid == "--" {
+		return "", fmt.Errorf("%s has no SSID", profileIdentifier)
+	}
+	escape := strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", ":", "\\:", "\"", "\\\"").Replace
+	var auth string
+	switch km := strings.TrimSpace(p["802-11-wireless-security.key-mgmt"]); km {
+	case "", "--", "none":
+		auth = "nopass"
+	case "sae":
+		auth = "SAE"
+	case keyMgmtWPAPSK:
+		auth = "WPA"
+	default:
+		return "", fmt.Errorf("%s uses %s, which cannot be shared as a QR code", profileIdentifier, km)
+	}
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + auth + ";S:" + escape(ssid) + ";")
+	if auth != "nopass" {
+		psk := p["802-11-wireless-security.psk"]
+		if psk == "" || psk == "--" {
+			return "", fmt.Errorf("%s has no saved password (or it could not be read)", profileIdentifier)
+		}
+		b.WriteString("P:" + escape(psk) + ";")
+	}
+	if strings.TrimSpace(p["802-11-wireless.hidden"]) == "yes" {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+	return b.String(), nil
+}
+
 func GetWifiList(rescan bool) ([]WifiAccessPoint, error) {
 	rescanArg := "no"
 	if rescan {
@@ -719,11 +1051,16 @@ func CreateWifiProfile(spec WifiProfileSpec) (string, error) {
 	security := normalizeWifiSecurityMode(spec.Security)
 	args := []string{"connection", "add", "type", ConnectionTypeWifi, "con-name", name, "ifname", "*", "ssid", ssid}
 
-	if security == "wpa-psk" {
+	if security == "wpa-psk" && !PSKStoredBySystem(spec.PSKFlags) {
+		args = append(args, "wifi-sec.key-mgmt", keyMgmtWPAPSK, "wifi-sec.psk-flags", spec.PSKFlags)
+	} else if security == "wpa-psk" {
 		if strings.TrimSpace(spec.Password) == "" {
 			return "", fmt.Errorf("password cannot be empty for wpa-psk")
 		}
 		args = append(args, "wifi-sec.key-mgmt", keyMgmtWPAPSK, "wifi-sec.psk", spec.Password)
+		if spec.PSKFlags != "" {
+			args = append(args, "wifi-sec.psk-flags", spec.PSKFlags)
+		}
 	}
 
 	if spec.Hidden {
@@ -741,6 +1078,9 @@ func CreateWifiProfile(spec WifiProfileSpec) (string, error) {
 	if spec.Priority != nil {
 		args = append(args, "connection.autoconnect-priority", strconv.Itoa(*spec.Priority))
 	}
+	if spec.Metered != "" {
+		args = append(args, "connection.metered", spec.Metered)
+	}
 
 	return cliInternal(args...)
 }
@@ -771,12 +1111,18 @@ func UpdateWifiProfile(profileIdentifier string, spec WifiProfileSpec, passwordP
 	if spec.Priority != nil {
 		args = append(args, "connection.autoconnect-priority", strconv.Itoa(*spec.Priority))
 	}
+	if spec.Metered != "" {
+		args = append(args, "connection.metered", spec.Metered)
+	}
 
 	if security == "open" {
 		args = append(args, "wifi-sec.key-mgmt", "", "wifi-sec.psk", "")
 	} else {
 		args = append(args, "wifi-sec.key-mgmt", keyMgmtWPAPSK)
-		if clearPassword {
+		if spec.PSKFlags != "" {
+			args = append(args, "wifi-sec.psk-flags", spec.PSKFlags)
+		}
+		if clearPassword || !PSKStoredBySystem(spec.PSKFlags) {
 			args = append(args, "wifi-sec.psk", "")
 		} else if passwordProvided {
 			if strings.TrimSpace(spec.Password) == "" {
@@ -786,6 +1132,9 @@ func UpdateWifiProfile(profileIdentifier string, spec WifiProfileSpec, passwordP
 		}
 	}
 
+	if err := beforeProfileChange(id, ProfileChangeModify); err != nil {
+		return "", err
+	}
 	return cliInternal(args...)
 }
 
@@ -888,6 +1237,92 @@ func ConnectToWifiRobustly(profileNameBase, ifname, ssid, password string, hidde
 	return output, nil
 }
 
+// ConnectionUpWithPSK activates a Wi-Fi profile and hands NetworkManager the
+// PSK through nmcli's passwd-file, so the key works even when the profile
+// does not store it. The file is private to the user and removed again.
+func ConnectionUpWithPSK(profileIdentifier, psk string) (string, error) {
+	if strings.TrimSpace(profileIdentifier) == "" {
+		return "", fmt.Errorf("profile identifier cannot be empty")
+	}
+	if psk == "" || strings.ContainsAny(psk, "\r\n") {
+		return "", fmt.Errorf("password must be a single non-empty line")
+	}
+	f, err := os.CreateTemp(os.Getenv("XDG_RUNTIME_DIR"), "nmtui-psk-*")
+	if err != nil {
+		return "", fmt.Errorf("creating passwd-file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintf(f, "802-11-wireless-security.psk:%s\n", psk)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing passwd-file: %w", err)
+	}
+	return cliInternal("connection", "up", profileIdentifier, "passwd-file", f.Name())
+}
+
+// ConnectWifiAgentOwned connects to a WPA-PSK network without storing the
+// PSK in NetworkManager: the network's profile is created, or switched to,
+// psk-flags=agent-owned and activated with ConnectionUpWithPSK.
+func ConnectWifiAgentOwned(ssid, psk string) (string, error) {
+	if strings.TrimSpace(ssid) == "" {
+		return "", fmt.Errorf("SSID empty for Wi-Fi connect")
+	}
+	profiles, err := GetKnownWifiProfiles()
+	if err != nil {
+		return "", fmt.Errorf("could not list profiles: %w", err)
+	}
+	id := ""
+	for _, p := range profiles {
+		if p.SSID == ssid {
+			id = p.UUID
+			break
+		}
+	}
+	if id == "" {
+		spec := WifiProfileSpec{Name: ssid, SSID: ssid, Security: keyMgmtWPAPSK, Autoconnect: true, PSKFlags: PSKFlagsAgentOwned}
+		out, err := CreateWifiProfile(spec)
+		if err != nil {
+			return "", err
+		}
+		// The name alone may match an unrelated profile.
+		if id = addedConnectionUUID(out); id == "" {
+			return "", fmt.Errorf("created a profile for %s but nmcli did not report its UUID: %q", ssid, out)
+		}
+	} else {
+		props, err := GetProfileProperties([]string{id}, NmcliFieldWifiPSKFlags)
+		if err != nil {
+			return "", err
+		}
+		// A profile that asks for its PSK every time keeps doing so.
+		if PSKStoredBySystem(props[id][NmcliFieldWifiPSKFlags]) {
+			if _, err := ModifyConnection(id, []ConnectionSetting{
+				{Key: "wifi-sec.key-mgmt", Value: keyMgmtWPAPSK},
+				{Key: "wifi-sec.psk-flags", Value: PSKFlagsAgentOwned},
+				{Key: "wifi-sec.psk", Value: ""},
+			}); err != nil {
+				return "", err
+			}
+		}
+	}
+	return ConnectionUpWithPSK(id, psk)
+}
+
+// addedConnectionUUID picks the UUID out of `nmcli connection add` output:
+// "Connection 'Cafe' (5c1e...) successfully added.".
+func addedConnectionUUID(out string) string {
+	end := strings.LastIndex(out, ") successfully added")
+	if end < 0 {
+		return ""
+	}
+	start := strings.LastIndex(out[:end], "(")
+	if start < 0 {
+		return ""
+	}
+	return out[start+1 : end]
+}
+
 // GetSSIDFromProfile extracts the SSID from a connection profile map.
 // NetworkManager might store SSID under different keys depending on context.
 func GetSSIDFromProfile(profile ConnectionProfile) string {
@@ -1,9 +1,11 @@
 package gonetworkmanager
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
@@ -278,3 +280,267 @@ func containsAny(s string, needles []string) bool {
 	}
 	return false
 }
+
+// setupScriptedNmcli installs a POSIX shell nmcli stand-in with the given body.
+func setupScriptedNmcli(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("scripted nmcli stand-in requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "nmcli"), []byte("#!/bin/sh\n"+body), 0700); err != nil {
+		t.Fatalf("failed to write scripted nmcli: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestGetKnownWifiProfiles(t *testing.T) {
+	setupScriptedNmcli(t, `case "$*" in
+  "-m multiline -f connection.uuid,802-11-wireless.ssid connection show u-1 u-3")
+    printf 'connection.uuid: u-1\n802-11-wireless.ssid: corp-5g\nconnection.uuid: u-3\n802-11-wireless.ssid: Home\n' ;;
+  *)
+    printf 'NAME: Office\nUUID: u-1\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 5\nTIMESTAMP: 1700000000\n'
+    printf 'NAME: Wired\nUUID: u-2\nTYPE: ethernet\nDEVICE: eth0\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 0\n'
+    printf 'NAME: Home\nUUID: u-3\nTYPE: wifi\nDEVICE: wlan0\nAUTOCONNECT: no\nAUTOCONNECT-PRIORITY: -2\nTIMESTAMP: 0\n' ;;
+esac
+`)
+	profiles, err := GetKnownWifiProfiles()
+	if err != nil {
+		t.Fatalf("GetKnownWifiProfiles unexpected error: %v", err)
+	}
+	want := []KnownWifiProfile{
+		{Name: "Office", UUID: "u-1", SSID: "corp-5g", Autoconnect: true, Priority: 5, Timestamp: 1700000000},
+		{Name: "Home", UUID: "u-3", SSID: "Home", Device: "wlan0", Priority: -2},
+	}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Fatalf("GetKnownWifiProfiles = %+v, want %+v", profiles, want)
+	}
+}
+
+func TestBeforeProfileChangeGuardsDestructiveCalls(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	setupScriptedNmcli(t, `echo "$*" >> "`+calls+`"`)
+	var seen []string
+	BeforeProfileChange = func(id, op string) error {
+		seen = append(seen, op+" "+id)
+		if id == "locked" {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	t.Cleanup(func() { BeforeProfileChange = nil })
+
+	if _, err := ModifyConnection("u-1", []ConnectionSetting{{Key: "connection.autoconnect", Value: "no"}}); err != nil {
+		t.Fatalf("modify: %v", err)
+	}
+	if _, err := UpdateWifiProfile("u-2", WifiProfileSpec{Name: "Home", SSID: "Home", Security: "open"}, false, false); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := ConnectionDelete("locked"); err == nil {
+		t.Fatalf("expected the hook error to abort the delete")
+	}
+	want := []string{"modify u-1", "modify u-2", "delete locked"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Fatalf("hook calls = %v, want %v", seen, want)
+	}
+	data, _ := os.ReadFile(calls)
+	if strings.Contains(string(data), "delete") {
+		t.Fatalf("nmcli delete ran despite the hook error:\n%s", data)
+	}
+}
+
+func TestGetWifiProfileSSIDs(t *testing.T) {
+	setupScriptedNmcli(t, `[ "$*" = "-m multiline -f connection.uuid,802-11-wireless.ssid connection show u-1 u-2 u-3" ] || exit 9
+printf 'connection.uuid: u-1\n802-11-wireless.ssid: Hotel\nconnection.uuid: u-2\n802-11-wireless.ssid: Hotel\nconnection.uuid: u-3\n802-11-wireless.ssid: --\n'
+`)
+	got, err := GetWifiProfileSSIDs([]string{"u-1", "u-2", "u-3"})
+	if err != nil {
+		t.Fatalf("GetWifiProfileSSIDs: %v", err)
+	}
+	if want := map[string]string{"u-1": "Hotel", "u-2": "Hotel"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestWifiShareURI(t *testing.T) {
+	setupScriptedNmcli(t, `case "$*" in
+  "-s -m multiline connection show home") printf 'connection.id: home\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: My;Net\n802-11-wireless.hidden: yes\n802-11-wireless-security.key-mgmt: wpa-psk\n802-11-wireless-security.psk: pa:ss\n' ;;
+  "-s -m multiline connection show cafe") printf 'connection.id: cafe\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Cafe\n802-11-wireless.hidden: no\n' ;;
+  "-s -m multiline connection show corp") printf 'connection.id: corp\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: Corp\n802-11-wireless-security.key-mgmt: wpa-eap\n' ;;
+  "-s -m multiline connection show nopsk") printf 'connection.id: nopsk\nconnection.type: 802-11-wireless\n802-11-wireless.ssid: X\n802-11-wireless-security.key-mgmt: wpa-psk\n' ;;
+  *) exit 10 ;;
+esac
+`)
+	for id, want := range map[string]string{
+		"home": `WIFI:T:WPA;S:My\;Net;P:pa\:ss;H:true;;`,
+		"cafe": `WIFI:T:nopass;S:Cafe;;`,
+	} {
+		if got, err := WifiShareURI(id); err != nil || got != want {
+			t.Errorf("WifiShareURI(%s) = %q, %v; want %q", id, got, err, want)
+		}
+	}
+	for _, id := range []string{"corp", "nopsk", "missing"} {
+		if _, err := WifiShareURI(id); err == nil {
+			t.Errorf("WifiShareURI(%s) should fail", id)
+		}
+	}
+}
+
+func TestSavedWifiPSK(t *testing.T) {
+	setupScriptedNmcli(t, `case "$*" in
+  "-s -g 802-11-wireless-security.psk connection show u-home") printf 'pa\\:ss\\\\w0rd\n' ;;
+  "-s -g 802-11-wireless-security.psk connection show u-agent") printf '\n' ;;
+  *) echo "Error: not authorized" >&2; exit 4 ;;
+esac
+`)
+	if psk, err := SavedWifiPSK("u-home"); err != nil || psk != `pa:ss\w0rd` {
+		t.Fatalf("SavedWifiPSK(u-home) = %q, %v", psk, err)
+	}
+	if psk, err := SavedWifiPSK("u-agent"); err != nil || psk != "" {
+		t.Fatalf("SavedWifiPSK(u-agent) = %q, %v", psk, err)
+	}
+	if _, err := SavedWifiPSK("u-other"); err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("expected the nmcli error, got %v", err)
+	}
+}
+
+func TestConnectWifiAgentOwned(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	setupScriptedNmcli(t, `case "$*" in
+  "-m multiline -f NAME,UUID,TYPE,DEVICE,AUTOCONNECT,AUTOCONNECT-PRIORITY,TIMESTAMP connection show --order name")
+    printf 'NAME: Home\nUUID: u-home\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 0\n'
+    printf 'NAME: Office\nUUID: u-office\nTYPE: 802-11-wireless\nDEVICE: --\nAUTOCONNECT: yes\nAUTOCONNECT-PRIORITY: 0\nTIMESTAMP: 0\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless.ssid connection show u-home u-office")
+    printf 'connection.uuid: u-home\n802-11-wireless.ssid: Home\nconnection.uuid: u-office\n802-11-wireless.ssid: corp-5g\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless-security.psk-flags connection show u-home")
+    printf 'connection.uuid: u-home\n802-11-wireless-security.psk-flags: 0 (none)\n' ;;
+  "-m multiline -f connection.uuid,802-11-wireless-security.psk-flags connection show u-office")
+    printf 'connection.uuid: u-office\n802-11-wireless-security.psk-flags: 1 (agent-owned)\n' ;;
+  "connection add "*) echo "$*" >> "`+dir+`/calls"; echo "Connection 'Cafe' (u-cafe) successfully added." ;;
+  "connection up "*) echo "$*" >> "`+dir+`/calls"; cat "$5" >> "`+dir+`/calls" ;;
+  *) echo "$*" >> "`+dir+`/calls" ;;
+esac
+`)
+	if _, err := ConnectWifiAgentOwned("Home", "hunter22"); err != nil {
+		t.Fatalf("ConnectWifiAgentOwned(Home): %v", err)
+	}
+	if _, err := ConnectWifiAgentOwned("corp-5g", "office123"); err != nil {
+		t.Fatalf("ConnectWifiAgentOwned(corp-5g): %v", err)
+	}
+	if _, err := ConnectWifiAgentOwned("Cafe", "espresso"); err != nil {
+		t.Fatalf("ConnectWifiAgentOwned(Cafe): %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	got := regexp.MustCompile(`passwd-file \S+`).ReplaceAllString(string(calls), "passwd-file FILE")
+	want := "connection modify u-home wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 1 wifi-sec.psk \n" +
+		"connection up u-home passwd-file FILE\n802-11-wireless-security.psk:hunter22\n" +
+		"connection up u-office passwd-file FILE\n802-11-wireless-security.psk:office123\n" +
+		"connection add type wifi con-name Cafe ifname * ssid Cafe wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 1 802-11-wireless.hidden no connection.autoconnect yes\n" +
+		"connection up u-cafe passwd-file FILE\n802-11-wireless-security.psk:espresso\n"
+	if got != want {
+		t.Fatalf("unexpected calls:\n%s\nwant:\n%s", got, want)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "nmtui-psk-*")); len(left) != 0 {
+		t.Fatalf("passwd-files left behind: %v", left)
+	}
+}
+
+func TestValidateWifiPSK(t *testing.T) {
+	hexKey := strings.Repeat("0123456789abcDEF", 4)
+	for psk, want := range map[string]string{
+		"hunter22":              "",
+		"correct horse ~!":      "",
+		strings.Repeat("x", 63): "",
+		hexKey:                  "",
+		"":                      "password is empty",
+		"short":                 "too short: 5 characters",
+		"pässwörd":              "printable ASCII characters; character 2 is not one",
+		"tab\there":             "character 4 is not one",
+		strings.Repeat("x", 64): "must be a raw key of hex digits",
+		strings.Repeat("x", 65): "too long: 65 characters",
+	} {
+		err := ValidateWifiPSK(psk)
+		if want == "" && err != nil || want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("ValidateWifiPSK(%q) = %v, want %q", psk, err, want)
+		}
+	}
+	if err := ValidateWifiPSK("pässwörd"); strings.ContainsRune(err.Error(), 'ä') {
+		t.Errorf("the error must not quote the password: %v", err)
+	}
+	if _, err := WifiHotspot("wlan0", "Hotspot", "€uro€uro"); err == nil || !strings.HasPrefix(err.Error(), "hotspot password may only contain") {
+		t.Errorf("WifiHotspot accepted a non-ASCII password: %v", err)
+	}
+}
+
+func TestPSKStorageFlags(t *testing.T) {
+	for flags, want := range map[string]bool{"": true, "0 (none)": true, "1 (agent-owned)": false, "2 (not-saved)": false, "3": false, "4 (not-required)": true} {
+		if got := PSKStoredBySystem(flags); got != want {
+			t.Errorf("PSKStoredBySystem(%q) = %v, want %v", flags, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	setupScriptedNmcli(t, `echo "$*" >> "`+dir+`/calls"`)
+	if _, err := CreateWifiProfile(WifiProfileSpec{Name: "Lab", SSID: "Lab", Security: "wpa-psk", Password: "ignored1", PSKFlags: PSKFlagsNotSaved}); err != nil {
+		t.Fatalf("CreateWifiProfile: %v", err)
+	}
+	if _, err := UpdateWifiProfile("u-lab", WifiProfileSpec{Name: "Lab", SSID: "Lab", Security: "wpa-psk", PSKFlags: PSKFlagsNotSaved}, false, false); err != nil {
+		t.Fatalf("UpdateWifiProfile: %v", err)
+	}
+	if _, err := UpdateWifiProfile("u-lab", WifiProfileSpec{Name: "Lab", SSID: "Lab", Security: "wpa-psk", Password: "hunter22", PSKFlags: PSKFlagsSystem}, true, false); err != nil {
+		t.Fatalf("UpdateWifiProfile: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	want := "connection add type wifi con-name Lab ifname * ssid Lab wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 2 802-11-wireless.hidden no connection.autoconnect no\n" +
+		"connection modify u-lab con-name Lab 802-11-wireless.ssid Lab 802-11-wireless.hidden no connection.autoconnect no wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 2 wifi-sec.psk \n" +
+		"connection modify u-lab con-name Lab 802-11-wireless.ssid Lab 802-11-wireless.hidden no connection.autoconnect no wifi-sec.key-mgmt wpa-psk wifi-sec.psk-flags 0 wifi-sec.psk hunter22\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected calls:\n%s\nwant:\n%s", calls, want)
+	}
+}
+
+func TestSetAutoconnectAndPriority(t *testing.T) {
+	dir := t.TempDir()
+	setupScriptedNmcli(t, `echo "$*" >> "`+dir+`/calls"`)
+	if _, err := SetAutoconnect("u-1", false); err != nil {
+		t.Fatalf("SetAutoconnect: %v", err)
+	}
+	if _, err := SetAutoconnectPriority("u-1", -3); err != nil {
+		t.Fatalf("SetAutoconnectPriority: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	want := "connection modify u-1 connection.autoconnect no\nconnection modify u-1 connection.autoconnect-priority -3\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+}
+
+func TestGetProfilePropertiesAndMeteredSpec(t *testing.T) {
+	dir := t.TempDir()
+	setupScriptedNmcli(t, `case "$*" in
+  "-m multiline -f connection.uuid,connection.metered connection show u-1 u-2")
+    printf 'connection.uuid: u-1\nconnection.metered: yes\nconnection.uuid: u-2\nconnection.metered: --\n' ;;
+  *) echo "$*" >> "`+dir+`/calls" ;;
+esac
+`)
+	got, err := GetProfileProperties([]string{"u-1", "u-2"}, "connection.metered")
+	if err != nil {
+		t.Fatalf("GetProfileProperties: %v", err)
+	}
+	if want := map[string]map[string]string{"u-1": {"connection.metered": "yes"}, "u-2": {}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := UpdateWifiProfile("u-1", WifiProfileSpec{Name: "Phone", SSID: "Phone", Security: "open", Metered: "yes"}, false, false); err != nil {
+		t.Fatalf("UpdateWifiProfile: %v", err)
+	}
+	if _, err := UpdateWifiProfile("u-2", WifiProfileSpec{Name: "Home", SSID: "Home", Security: "open"}, false, false); err != nil {
+		t.Fatalf("UpdateWifiProfile: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "connection.metered yes") || strings.Contains(lines[1], "metered") {
+		t.Fatalf("unexpected calls:\n%s", calls)
+	}
+}
@@ -0,0 +1,430 @@
+// nmtui/gonetworkmanager/keyfile.go
+package gonetworkmanager
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyfileExtension is the suffix NetworkManager uses for keyfile profiles.
+const KeyfileExtension = ".nmconnection"
+
+// ErrProfileExists is returned by ImportKeyfile when a profile with the same
+// UUID already exists and replace was not requested.
+var ErrProfileExists = errors.New("a profile with this UUID already exists")
+
+// KeyfileEntry is one key=value line of a keyfile group.
+type KeyfileEntry struct {
+	Key   string
+	Value string
+}
+
+// KeyfileSection is a [group] of a keyfile, entries in file order.
+type KeyfileSection struct {
+	Name    string
+	Entries []KeyfileEntry
+}
+
+// Keyfile is a parsed NetworkManager .nmconnection file.
+type Keyfile struct {
+	Sections []KeyfileSection
+}
+
+// nmcli setting names and the group names keyfiles use for them.
+var keyfileGroupAliases = map[string]string{
+	"802-11-wireless":          "wifi",
+	"802-11-wireless-security": "wifi-security",
+	"802-3-ethernet":           "ethernet",
+}
+
+var keyfileGroupOrder = []string{"connection", "wifi", "wifi-security", "802-1x", "ethernet", "vpn", "vpn-secrets", "wireguard", "ipv4", "ipv6", "proxy"}
+
+// Properties that are runtime state rather than configuration.
+var keyfileSkipProps = map[string]bool{
+	"connection.timestamp":        true,
+	"connection.read-only":        true,
+	"802-11-wireless.seen-bssids": true,
+}
+
+var keyfileListKeys = map[string]bool{"dns": true, "dns-search": true, "dns-options": true}
+
+// nmcli prints enums as "-1 (default)" and flags in hex as "0x1 (default)";
+// keyfiles and `connection add` both want the bare decimal number.
+var enumValueRe = regexp.MustCompile(`^(-?\d+|0[xX][0-9a-fA-F]+) \(.*\)$`)
+
+// settingName splits "802-11-wireless.ssid" into its setting and property.
+// Runtime sections such as GENERAL or IP4 are upper case and rejected.
+func settingName(key string) (setting, prop string, ok bool) {
+	i := strings.Index(key, ".")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	setting, prop = key[:i], key[i+1:]
+	if strings.ToLower(setting) != setting {
+		return "", "", false
+	}
+	return setting, prop, true
+}
+
+func keyfileGroup(setting string) string {
+	if alias, ok := keyfileGroupAliases[setting]; ok {
+		return alias
+	}
+	return setting
+}
+
+func nmcliSetting(group string) string {
+	for setting, alias := range keyfileGroupAliases {
+		if alias == group {
+			return setting
+		}
+	}
+	return group
+}
+
+// splitNmcliKV parses nmcli's "key = value, key2 = value2" dictionary output.
+func splitNmcliKV(v string) []KeyfileEntry {
+	var entries []KeyfileEntry
+	for _, part := range strings.Split(v, ", ") {
+		kv := strings.SplitN(part, " = ", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		entries = append(entries, KeyfileEntry{Key: strings.TrimSpace(kv[0]), Value: strings.TrimSpace(kv[1])})
+	}
+	return entries
+}
+
+// KeyfileFromProfile converts the property map of `nmcli connection show <id>`
+// into keyfile groups. Hidden secrets (not fetched) are left out.
+func KeyfileFromProfile(p ConnectionProfile) *Keyfile {
+	groups := make(map[string]*KeyfileSection)
+	get := func(name string) *KeyfileSection {
+		if s, ok := groups[name]; ok {
+			return s
+		}
+		s := &KeyfileSection{Name: name}
+		groups[name] = s
+		return s
+	}
+
+	for key, raw := range p {
+		setting, prop, ok := settingName(key)
+		if !ok || keyfileSkipProps[key] {
+			continue
+		}
+		v := strings.TrimSpace(raw)
+		if v == "" || v == "--" || v == "<hidden>" {
+			continue
+		}
+		group := keyfileGroup(setting)
+		switch {
+		case setting == "vpn" && prop == "data":
+			sec := get("vpn")
+			sec.Entries = append(sec.Entries, splitNmcliKV(v)...)
+			continue
+		case setting == "vpn" && prop == "secrets":
+			sec := get("vpn-secrets")
+			sec.Entries = append(sec.Entries, splitNmcliKV(v)...)
+			continue
+		case (setting == "ipv4" || setting == "ipv6") && prop == "addresses":
+			sec := get(group)
+			for i, addr := range strings.Split(v, ",") {
+				sec.Entries = append(sec.Entries, KeyfileEntry{Key: fmt.Sprintf("address%d", i+1), Value: strings.TrimSpace(addr)})
+			}
+			continue
+		case setting == "connection" && prop == "type":
+			v = keyfileGroup(v)
+		case keyfileListKeys[prop]:
+			v = strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }), ";") + ";"
+		case v == "yes":
+			v = "true"
+		case v == "no":
+			v = "false"
+		default:
+			if m := enumValueRe.FindStringSubmatch(v); m != nil {
+				v = m[1]
+				if hex := strings.TrimPrefix(strings.ToLower(v), "0x"); hex != strings.ToLower(v) {
+					if n, err := strconv.ParseUint(hex, 16, 64); err == nil {
+						v = strconv.FormatUint(n, 10)
+					}
+				}
+			}
+		}
+		sec := get(group)
+		sec.Entries = append(sec.Entries, KeyfileEntry{Key: prop, Value: v})
+	}
+
+	kf := &Keyfile{}
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		for i, g := range keyfileGroupOrder {
+			if g == name {
+				return i
+			}
+		}
+		return len(keyfileGroupOrder)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ri, rj := rank(names[i]), rank(names[j]); ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	connFirst := map[string]int{"id": 0, "uuid": 1, "type": 2, "interface-name": 3}
+	for _, name := range names {
+		sec := groups[name]
+		sort.SliceStable(sec.Entries, func(i, j int) bool {
+			a, b := sec.Entries[i].Key, sec.Entries[j].Key
+			if name == "connection" {
+				ra, oka := connFirst[a]
+				rb, okb := connFirst[b]
+				switch {
+				case oka && okb:
+					return ra < rb
+				case oka != okb:
+					return oka
+				}
+			}
+			return a < b
+		})
+		kf.Sections = append(kf.Sections, *sec)
+	}
+	return kf
+}
+
+// Get returns the value of key in group, or "".
+func (k *Keyfile) Get(group, key string) string {
+	for _, s := range k.Sections {
+		if s.Name != group {
+			continue
+		}
+		for _, e := range s.Entries {
+			if e.Key == key {
+				return e.Value
+			}
+		}
+	}
+	return ""
+}
+
+// ID, UUID and Type return the [connection] identity of the keyfile.
+func (k *Keyfile) ID() string   { return k.Get("connection", "id") }
+func (k *Keyfile) UUID() string { return k.Get("connection", "uuid") }
+func (k *Keyfile) Type() string { return k.Get("connection", "type") }
+
+func escapeKeyfileValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, "\t", `\t`)
+	if strings.HasPrefix(v, " ") {
+		v = `\s` + v[1:]
+	}
+	return v
+}
+
+func unescapeKeyfileValue(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] != '\\' || i == len(v)-1 {
+			b.WriteByte(v[i])
+			continue
+		}
+		i++
+		switch v[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 's':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+	return b.String()
+}
+
+// Marshal renders the keyfile in NetworkManager's format.
+func (k *Keyfile) Marshal() []byte {
+	var b bytes.Buffer
+	for i, s := range k.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", s.Name)
+		for _, e := range s.Entries {
+			fmt.Fprintf(&b, "%s=%s\n", e.Key, escapeKeyfileValue(e.Value))
+		}
+	}
+	return b.Bytes()
+}
+
+// ParseKeyfile reads a .nmconnection file.
+func ParseKeyfile(data []byte) (*Keyfile, error) {
+	kf := &Keyfile{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			kf.Sections = append(kf.Sections, KeyfileSection{Name: strings.TrimSpace(line[1 : len(line)-1])})
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || len(kf.Sections) == 0 {
+			return nil, fmt.Errorf("line %d: expected key=value inside a [group]", lineNo)
+		}
+		sec := &kf.Sections[len(kf.Sections)-1]
+		sec.Entries = append(sec.Entries, KeyfileEntry{Key: strings.TrimSpace(kv[0]), Value: unescapeKeyfileValue(strings.TrimSpace(kv[1]))})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if kf.ID() == "" || kf.Type() == "" {
+		return nil, fmt.Errorf("keyfile has no [connection] id/type")
+	}
+	return kf, nil
+}
+
+// NmcliSettings converts the keyfile back into nmcli properties for
+// `connection add`. id, type and interface-name are returned separately.
+func (k *Keyfile) NmcliSettings() (connType, name, ifname string, settings []ConnectionSetting) {
+	connType, name, ifname = nmcliSetting(k.Type()), k.ID(), k.Get("connection", "interface-name")
+	for _, s := range k.Sections {
+		setting := nmcliSetting(s.Name)
+		switch s.Name {
+		case "vpn", "vpn-secrets":
+			var parts []string
+			for _, e := range s.Entries {
+				parts = append(parts, e.Key+" = "+e.Value)
+			}
+			prop := "vpn.data"
+			if s.Name == "vpn-secrets" {
+				prop = "vpn.secrets"
+			}
+			settings = append(settings, ConnectionSetting{Key: prop, Value: strings.Join(parts, ", ")})
+			continue
+		}
+		var addresses []string
+		for _, e := range s.Entries {
+			if s.Name == "connection" && (e.Key == "id" || e.Key == "type" || e.Key == "interface-name") {
+				continue
+			}
+			v := e.Value
+			switch {
+			case (s.Name == "ipv4" || s.Name == "ipv6") && strings.HasPrefix(e.Key, "address"):
+				addresses = append(addresses, strings.SplitN(v, ",", 2)[0])
+				continue
+			case keyfileListKeys[e.Key]:
+				v = strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == ';' }), ",")
+			case v == "true":
+				v = "yes"
+			case v == "false":
+				v = "no"
+			}
+			settings = append(settings, ConnectionSetting{Key: setting + "." + e.Key, Value: v})
+		}
+		if len(addresses) > 0 {
+			settings = append(settings, ConnectionSetting{Key: setting + ".addresses", Value: strings.Join(addresses, ",")})
+		}
+	}
+	return connType, name, ifname, settings
+}
+
+// GetConnectionProfileWithSecrets is GetConnectionProfileByID including
+// secrets (`nmcli -s`). It needs permission to read the profile's secrets.
+func GetConnectionProfileWithSecrets(profileIdentifier string) (ConnectionProfile, error) {
+	if strings.TrimSpace(profileIdentifier) == "" {
+		return nil, fmt.Errorf("profile identifier cannot be empty")
+	}
+	data, err := clibInternal("-s", "-m", "multiline", "connection", "show", profileIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return ConnectionProfile(data[0]), nil
+}
+
+// ExportKeyfile fetches a profile and renders it as a keyfile.
+func ExportKeyfile(profileIdentifier string, withSecrets bool) (*Keyfile, error) {
+	fetch := GetConnectionProfileByID
+	if withSecrets {
+		fetch = GetConnectionProfileWithSecrets
+	}
+	p, err := fetch(profileIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("profile %s not found", profileIdentifier)
+	}
+	return KeyfileFromProfile(p), nil
+}
+
+// ImportKeyfile creates the keyfile's profile, keeping its UUID. When a
+// profile with that UUID exists it returns ErrProfileExists unless replace is
+// set, in which case the existing profile is replaced. BeforeProfileChange
+// sees the replacement as a delete.
+func ImportKeyfile(kf *Keyfile, replace bool) (string, error) {
+	return importKeyfile(kf, replace, true)
+}
+
+// RestoreKeyfile puts a snapshot back, replacing any profile with its UUID.
+// It skips BeforeProfileChange: undoing a change should not add another
+// snapshot of the version being undone.
+func RestoreKeyfile(kf *Keyfile) (string, error) {
+	return importKeyfile(kf, true, false)
+}
+
+func importKeyfile(kf *Keyfile, replace, record bool) (string, error) {
+	connType, name, ifname, settings := kf.NmcliSettings()
+	uuid := kf.UUID()
+	if uuid == "" {
+		return AddConnection(connType, name, ifname, settings)
+	}
+	if existing, err := GetConnectionProfileByID(uuid); err != nil || existing == nil {
+		return AddConnection(connType, name, ifname, settings)
+	}
+	if !replace {
+		return "", fmt.Errorf("%s (%s): %w", name, uuid, ErrProfileExists)
+	}
+	if record {
+		if err := beforeProfileChange(uuid, ProfileChangeDelete); err != nil {
+			return "", err
+		}
+	}
+	// The UUID has to be free before the add, so keep the current version,
+	// secrets included, to put back if the add is rejected.
+	current, err := ExportKeyfile(uuid, true)
+	if err != nil {
+		return "", fmt.Errorf("replacing %s: %w", name, err)
+	}
+	if _, err := cliInternal("connection", "delete", uuid); err != nil {
+		return "", fmt.Errorf("replacing %s: %w", name, err)
+	}
+	out, err := AddConnection(connType, name, ifname, settings)
+	if err != nil {
+		cType, cName, cIfname, cSettings := current.NmcliSettings()
+		if _, rerr := AddConnection(cType, cName, cIfname, cSettings); rerr != nil {
+			return "", fmt.Errorf("replacing %s: %w; putting the previous version back also failed: %v", name, err, rerr)
+		}
+		return "", fmt.Errorf("replacing %s (previous version kept): %w", name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,261 @@
+package gonetworkmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeyfileRoundTrip(t *testing.T) {
+	profile := ConnectionProfile{
+		"connection.id":                      "Café Wi-Fi",
+		"connection.uuid":                    "u-cafe",
+		"connection.type":                    "802-11-wireless",
+		"connection.interface-name":          "--",
+		"connection.autoconnect":             "yes",
+		"connection.autoconnect-priority":    "5",
+		"connection.autoconnect-retries":     "-1 (default)",
+		"connection.timestamp":               "1700000000",
+		"802-11-wireless.ssid":               `C:\cafe`,
+		"802-11-wireless.hidden":             "no",
+		"802-11-wireless-security.key-mgmt":  "wpa-psk",
+		"802-11-wireless-security.psk":       "<hidden>",
+		"802-11-wireless-security.psk-flags": "0 (none)",
+		"ipv4.method":                        "manual",
+		"ipv4.addresses":                     "10.0.0.5/24, 10.0.0.6/24",
+		"ipv4.dns":                           "1.1.1.1,9.9.9.9",
+		"GENERAL.STATE":                      "activated",
+		"IP4.ADDRESS[1]":                     "10.0.0.5/24",
+	}
+	kf := KeyfileFromProfile(profile)
+	data := string(kf.Marshal())
+	if !strings.HasPrefix(data, "[connection]\nid=Café Wi-Fi\nuuid=u-cafe\ntype=wifi\n") {
+		t.Fatalf("unexpected keyfile head:\n%s", data)
+	}
+	for _, want := range []string{"autoconnect=true\n", "autoconnect-retries=-1\n", "[wifi]\nhidden=false\nssid=C:\\\\cafe\n",
+		"[wifi-security]\nkey-mgmt=wpa-psk\npsk-flags=0\n", "address1=10.0.0.5/24\naddress2=10.0.0.6/24\n", "dns=1.1.1.1;9.9.9.9;\n"} {
+		if !strings.Contains(data, want) {
+			t.Fatalf("keyfile missing %q:\n%s", want, data)
+		}
+	}
+	for _, unwanted := range []string{"<hidden>", "timestamp", "GENERAL", "IP4", "interface-name"} {
+		if strings.Contains(data, unwanted) {
+			t.Fatalf("keyfile should not contain %q:\n%s", unwanted, data)
+		}
+	}
+
+	parsed, err := ParseKeyfile([]byte("# exported\n" + data))
+	if err != nil {
+		t.Fatalf("ParseKeyfile: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, kf) {
+		t.Fatalf("round trip changed the keyfile:\n%+v\n%+v", parsed, kf)
+	}
+	connType, name, ifname, settings := parsed.NmcliSettings()
+	if connType != "802-11-wireless" || name != "Café Wi-Fi" || ifname != "" {
+		t.Fatalf("unexpected identity %q %q %q", connType, name, ifname)
+	}
+	got := map[string]string{}
+	for _, s := range settings {
+		got[s.Key] = s.Value
+	}
+	want := map[string]string{
+		"connection.uuid": "u-cafe", "connection.autoconnect": "yes", "connection.autoconnect-priority": "5",
+		"connection.autoconnect-retries": "-1", "802-11-wireless.ssid": `C:\cafe`, "802-11-wireless.hidden": "no",
+		"802-11-wireless-security.key-mgmt": "wpa-psk", "802-11-wireless-security.psk-flags": "0",
+		"ipv4.method": "manual", "ipv4.addresses": "10.0.0.5/24,10.0.0.6/24", "ipv4.dns": "1.1.1.1,9.9.9.9",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("nmcli settings = %v\nwant %v", got, want)
+	}
+}
+
+// A full `nmcli -m multiline connection show` dump of a WPA2 profile as
+// NetworkManager 1.46 prints it, including hex flag values.
+const fullWifiProfileDump = `connection.id:                          Office
+connection.uuid:                        6f1b0c4e-2f0a-4c1e-9d55-0a4e3f9b7d21
+connection.stable-id:                   --
+connection.type:                        802-11-wireless
+connection.interface-name:              --
+connection.autoconnect:                 yes
+connection.autoconnect-priority:        0
+connection.autoconnect-retries:         -1 (default)
+connection.multi-connect:               0 (default)
+connection.auth-retries:                -1
+connection.timestamp:                   1729240000
+connection.read-only:                   no
+connection.permissions:                 --
+connection.zone:                        --
+connection.master:                      --
+connection.slave-type:                  --
+connection.autoconnect-slaves:          -1 (default)
+connection.secondaries:                 --
+connection.gateway-ping-timeout:        0
+connection.metered:                     unknown
+connection.lldp:                        default
+connection.mdns:                        -1 (default)
+connection.llmnr:                       -1 (default)
+connection.dns-over-tls:                -1 (default)
+connection.mptcp-flags:                 0x0 (default)
+connection.wait-device-timeout:         -1
+connection.wait-activation-delay:       -1
+802-11-wireless.ssid:                   corp-5g
+802-11-wireless.mode:                   infrastructure
+802-11-wireless.band:                   --
+802-11-wireless.channel:                0
+802-11-wireless.bssid:                  --
+802-11-wireless.mac-address:            --
+802-11-wireless.cloned-mac-address:     --
+802-11-wireless.mac-address-blacklist:  --
+802-11-wireless.mac-address-randomization:default
+802-11-wireless.mtu:                    auto
+802-11-wireless.seen-bssids:            AA:BB:CC:DD:EE:FF
+802-11-wireless.hidden:                 no
+802-11-wireless.powersave:              0 (default)
+802-11-wireless.wake-on-wlan:           0x1 (default)
+802-11-wireless.ap-isolation:           -1 (default)
+802-11-wireless-security.key-mgmt:      wpa-psk
+802-11-wireless-security.wep-tx-keyidx: 0
+802-11-wireless-security.auth-alg:      --
+802-11-wireless-security.proto:         --
+802-11-wireless-security.pairwise:      --
+802-11-wireless-security.group:         --
+802-11-wireless-security.pmf:           0 (default)
+802-11-wireless-security.leap-username: --
+802-11-wireless-security.wep-key0:      <hidden>
+802-11-wireless-security.wep-key-flags: 0 (none)
+802-11-wireless-security.wep-key-type:  unknown
+802-11-wireless-security.psk:           <hidden>
+802-11-wireless-security.psk-flags:     0 (none)
+802-11-wireless-security.leap-password-flags:0 (none)
+802-11-wireless-security.wps-method:    0x0 (default)
+802-11-wireless-security.fils:          0 (default)
+ipv4.method:                            auto
+ipv4.dns:                               --
+ipv4.dns-search:                        --
+ipv4.dns-options:                       --
+ipv4.dns-priority:                      0
+ipv4.addresses:                         --
+ipv4.gateway:                           --
+ipv4.routes:                            --
+ipv4.route-metric:                      -1
+ipv4.ignore-auto-routes:                no
+ipv4.ignore-auto-dns:                   no
+ipv4.dhcp-client-id:                    --
+ipv4.dhcp-iaid:                         --
+ipv4.dhcp-timeout:                      0 (default)
+ipv4.dhcp-send-hostname:                yes
+ipv4.dhcp-hostname:                     --
+ipv4.dhcp-fqdn:                         --
+ipv4.dhcp-hostname-flags:               0x0 (none)
+ipv4.never-default:                     no
+ipv4.may-fail:                          yes
+ipv4.required-timeout:                  -1 (default)
+ipv4.dad-timeout:                       -1 (default)
+ipv4.link-local:                        0 (default)
+ipv6.method:                            auto
+ipv6.ip6-privacy:                       -1 (unknown)
+ipv6.addr-gen-mode:                     stable-privacy
+ipv6.ra-timeout:                        0 (default)
+ipv6.dhcp-hostname-flags:               0x3 (fqdn-serv-update, fqdn-encoded)
+proxy.method:                           none
+proxy.browser-only:                     no
+GENERAL.NAME:                           Office
+GENERAL.STATE:                          activated
+IP4.ADDRESS[1]:                         192.168.1.20/24
+`
+
+func TestKeyfileFromFullNmcliDump(t *testing.T) {
+	records, err := parseNmcliMultilineOutput(fullWifiProfileDump)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("parseNmcliMultilineOutput = %v, %v", records, err)
+	}
+	kf := KeyfileFromProfile(ConnectionProfile(records[0]))
+	data := string(kf.Marshal())
+	for _, want := range []string{"mptcp-flags=0\n", "wake-on-wlan=1\n", "wps-method=0\n", "dhcp-hostname-flags=0\n", "dhcp-hostname-flags=3\n", "ip6-privacy=-1\n"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("keyfile missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(data, "(") {
+		t.Fatalf("keyfile still carries nmcli annotations:\n%s", data)
+	}
+
+	parsed, err := ParseKeyfile(kf.Marshal())
+	if err != nil {
+		t.Fatalf("ParseKeyfile: %v", err)
+	}
+	connType, name, _, settings := parsed.NmcliSettings()
+	if connType != "802-11-wireless" || name != "Office" {
+		t.Fatalf("unexpected identity %q %q", connType, name)
+	}
+	for _, s := range settings {
+		if strings.ContainsAny(s.Value, "()") || s.Value == "<hidden>" {
+			t.Errorf("connection add would get %s=%q", s.Key, s.Value)
+		}
+	}
+}
+
+func TestKeyfileVPNData(t *testing.T) {
+	kf := KeyfileFromProfile(ConnectionProfile{
+		"connection.id": "Corp", "connection.type": "vpn",
+		"vpn.service-type": "org.freedesktop.NetworkManager.openvpn",
+		"vpn.data":         "connection-type = password, remote = vpn.example.com",
+		"vpn.secrets":      "password = hunter2",
+	})
+	data := string(kf.Marshal())
+	if !strings.Contains(data, "[vpn]\nconnection-type=password\nremote=vpn.example.com\nservice-type=") ||
+		!strings.Contains(data, "[vpn-secrets]\npassword=hunter2\n") {
+		t.Fatalf("unexpected vpn keyfile:\n%s", data)
+	}
+	_, _, _, settings := kf.NmcliSettings()
+	var data2, secrets string
+	for _, s := range settings {
+		switch s.Key {
+		case "vpn.data":
+			data2 = s.Value
+		case "vpn.secrets":
+			secrets = s.Value
+		}
+	}
+	if data2 != "connection-type = password, remote = vpn.example.com, service-type = org.freedesktop.NetworkManager.openvpn" || secrets != "password = hunter2" {
+		t.Fatalf("unexpected vpn settings %v", settings)
+	}
+}
+
+func TestParseKeyfileRejectsGarbage(t *testing.T) {
+	for _, in := range []string{"id=x\n", "[connection]\nno equals\n", "[wifi]\nssid=x\n"} {
+		if _, err := ParseKeyfile([]byte(in)); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestImportKeyfileKeepsUUID(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	setupScriptedNmcli(t, `echo "$*" >> "`+calls+`"
+case "$*" in
+  "-m multiline connection show u-home"|"-s -m multiline connection show u-home") printf 'connection.id: Home\nconnection.uuid: u-home\nconnection.type: 802-11-wireless\n' ;;
+  "-m multiline connection show "*) echo "Error: no such connection profile." >&2; exit 10 ;;
+esac
+`)
+	kf, err := ParseKeyfile([]byte("[connection]\nid=Home\nuuid=u-home\ntype=wifi\n\n[wifi]\nssid=Home\n\n[wifi-security]\nkey-mgmt=wpa-psk\npsk=secret123\n"))
+	if err != nil {
+		t.Fatalf("ParseKeyfile: %v", err)
+	}
+	if _, err := ImportKeyfile(kf, false); !errors.Is(err, ErrProfileExists) {
+		t.Fatalf("expected ErrProfileExists, got %v", err)
+	}
+	if _, err := ImportKeyfile(kf, true); err != nil {
+		t.Fatalf("replace import failed: %v", err)
+	}
+	data, _ := os.ReadFile(calls)
+	log := string(data)
+	if !strings.Contains(log, "connection delete u-home\n") ||
+		!strings.Contains(log, "connection add type 802-11-wireless con-name Home ifname * connection.uuid u-home 802-11-wireless.ssid Home 802-11-wireless-security.key-mgmt wpa-psk 802-11-wireless-security.psk secret123") {
+		t.Fatalf("unexpected nmcli calls:\n%s", log)
+	}
+}
@@ -0,0 +1,148 @@
+// nmtui/gonetworkmanager/stats.go
+package gonetworkmanager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SysfsNetRoot is the directory holding per-interface statistics. It can be
+// pointed at a fixture tree in tests.
+var SysfsNetRoot = "/sys/class/net"
+
+// ProcNetWirelessPath is the kernel wireless extensions status file.
+var ProcNetWirelessPath = "/proc/net/wireless"
+
+// wirelessLinkMax is the scale most drivers use for the link column.
+const wirelessLinkMax = 70.0
+
+// InterfaceStats holds the cumulative kernel counters for a network interface.
+type InterfaceStats struct {
+	RxBytes   uint64 `json:"rxBytes"`
+	TxBytes   uint64 `json:"txBytes"`
+	RxPackets uint64 `json:"rxPackets"`
+	TxPackets uint64 `json:"txPackets"`
+	RxErrors  uint64 `json:"rxErrors"`
+	TxErrors  uint64 `json:"txErrors"`
+	RxDropped uint64 `json:"rxDropped"`
+	TxDropped uint64 `json:"txDropped"`
+}
+
+// WirelessLink is the link quality reported in /proc/net/wireless.
+type WirelessLink struct {
+	Interface string  `json:"interface"`
+	Link      float64 `json:"link"`
+	LevelDBm  float64 `json:"levelDbm"`
+	NoiseDBm  float64 `json:"noiseDbm"`
+}
+
+// QualityPercent converts the raw link value into a 0-100 percentage.
+func (w WirelessLink) QualityPercent() int {
+	p := int(w.Link / wirelessLinkMax * 100)
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+type statCounter struct {
+	file string
+	dst  *uint64
+}
+
+// ReadInterfaceStats reads the counters under <SysfsNetRoot>/<dev>/statistics.
+func ReadInterfaceStats(deviceName string) (*InterfaceStats, error) {
+	if strings.TrimSpace(deviceName) == "" {
+		return nil, fmt.Errorf("device name cannot be empty")
+	}
+	st := &InterfaceStats{}
+	counters := []statCounter{
+		{"rx_bytes", &st.RxBytes}, {"tx_bytes", &st.TxBytes},
+		{"rx_packets", &st.RxPackets}, {"tx_packets", &st.TxPackets},
+		{"rx_errors", &st.RxErrors}, {"tx_errors", &st.TxErrors},
+		{"rx_dropped", &st.RxDropped}, {"tx_dropped", &st.TxDropped},
+	}
+	dir := filepath.Join(SysfsNetRoot, deviceName, "statistics")
+	for _, c := range counters {
+		raw, err := os.ReadFile(filepath.Join(dir, c.file))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s for %s: %w", c.file, deviceName, err)
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s for %s: %w", c.file, deviceName, err)
+		}
+		*c.dst = v
+	}
+	return st, nil
+}
+
+// ReadWirelessLink returns the link quality for deviceName from ProcNetWirelessPath.
+// It returns nil, nil when the interface is not listed (e.g. not associated).
+func ReadWirelessLink(deviceName string) (*WirelessLink, error) {
+	if strings.TrimSpace(deviceName) == "" {
+		return nil, fmt.Errorf("device name cannot be empty")
+	}
+	f, err := os.Open(ProcNetWirelessPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", ProcNetWirelessPath, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != deviceName {
+			continue
+		}
+		// Columns after the colon: status, link, level, noise, ...
+		cols := strings.Fields(parts[1])
+		if len(cols) < 4 {
+			return nil, fmt.Errorf("malformed wireless line for %s: %q", deviceName, line)
+		}
+		link := &WirelessLink{Interface: deviceName}
+		for i, dst := range []*float64{&link.Link, &link.LevelDBm, &link.NoiseDBm} {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(cols[i+1], "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing wireless column %d for %s: %w", i+1, deviceName, err)
+			}
+			*dst = v
+		}
+		return link, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ProcNetWirelessPath, err)
+	}
+	return nil, nil
+}
+
+// WifiLinkBitrate reports the bitrate currently negotiated on deviceName, as
+// NetworkManager shows it in CAPABILITIES.SPEED for Wi-Fi devices (e.g.
+// "866 Mb/s"). The RATE column of the scan list would be the access point's
+// maximum instead. It returns "" when the rate is unknown (e.g. not
+// associated).
+func WifiLinkBitrate(deviceName string) (string, error) {
+	if strings.TrimSpace(deviceName) == "" {
+		return "", fmt.Errorf("device name cannot be empty")
+	}
+	data, err := clibInternal("-m", "multiline", "-f", "CAPABILITIES.SPEED", "device", "show", deviceName)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+	rate := strings.TrimSpace(data[0]["CAPABILITIES.SPEED"])
+	if rate == "" || rate == "--" || strings.EqualFold(rate, "unknown") {
+		return "", nil
+	}
+	return rate, nil
+}
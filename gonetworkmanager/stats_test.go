@@ -0,0 +1,90 @@
+package gonetworkmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func writeFakeSysfs(t *testing.T, dev string, values map[string]uint64) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, dev, "statistics")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, v := range values {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strconv.FormatUint(v, 10)+"\n"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestReadInterfaceStatsFromOverriddenRoot(t *testing.T) {
+	root := writeFakeSysfs(t, "wlan0", map[string]uint64{
+		"rx_bytes": 1000, "tx_bytes": 2000, "rx_packets": 10, "tx_packets": 20,
+		"rx_errors": 1, "tx_errors": 2, "rx_dropped": 3, "tx_dropped": 4,
+	})
+	old := SysfsNetRoot
+	SysfsNetRoot = root
+	t.Cleanup(func() { SysfsNetRoot = old })
+
+	st, err := ReadInterfaceStats("wlan0")
+	if err != nil {
+		t.Fatalf("ReadInterfaceStats unexpected error: %v", err)
+	}
+	want := InterfaceStats{RxBytes: 1000, TxBytes: 2000, RxPackets: 10, TxPackets: 20, RxErrors: 1, TxErrors: 2, RxDropped: 3, TxDropped: 4}
+	if *st != want {
+		t.Fatalf("got %+v, want %+v", *st, want)
+	}
+
+	if _, err := ReadInterfaceStats("eth9"); err == nil {
+		t.Fatalf("expected error for missing interface")
+	}
+}
+
+func TestReadWirelessLink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wireless")
+	content := "Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE\n" +
+		" face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22\n" +
+		" wlan0: 0000   56.  -54.  -256        0      0      0      0     12        0\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	old := ProcNetWirelessPath
+	ProcNetWirelessPath = path
+	t.Cleanup(func() { ProcNetWirelessPath = old })
+
+	link, err := ReadWirelessLink("wlan0")
+	if err != nil {
+		t.Fatalf("ReadWirelessLink unexpected error: %v", err)
+	}
+	if link == nil || link.Link != 56 || link.LevelDBm != -54 {
+		t.Fatalf("unexpected link: %+v", link)
+	}
+	if got := link.QualityPercent(); got != 80 {
+		t.Fatalf("QualityPercent = %d, want 80", got)
+	}
+
+	missing, err := ReadWirelessLink("wlan1")
+	if err != nil || missing != nil {
+		t.Fatalf("expected nil link for unlisted interface, got %+v / %v", missing, err)
+	}
+}
+
+func TestWifiLinkBitrate(t *testing.T) {
+	setupScriptedNmcli(t, `case "$*" in
+  "-m multiline -f CAPABILITIES.SPEED device show wlan0") printf 'CAPABILITIES.SPEED: 866 Mb/s\n' ;;
+  "-m multiline -f CAPABILITIES.SPEED device show wlan1") printf 'CAPABILITIES.SPEED: unknown\n' ;;
+  *) exit 10 ;;
+esac
+`)
+	if rate, err := WifiLinkBitrate("wlan0"); err != nil || rate != "866 Mb/s" {
+		t.Fatalf("WifiLinkBitrate(wlan0) = %q, %v", rate, err)
+	}
+	if rate, err := WifiLinkBitrate("wlan1"); err != nil || rate != "" {
+		t.Fatalf("an unknown rate should be empty, got %q, %v", rate, err)
+	}
+}